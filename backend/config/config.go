@@ -3,6 +3,8 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -19,6 +21,37 @@ type Config struct {
 	AmqpPassword string
 	AmqpHost     string
 	AmqpPort     string
+
+	// DBAutoMigrate applies the pending schema migrations on startup
+	DBAutoMigrate bool
+	// CompressAfter is the age after which raw chunks are compressed, zero disables compression.
+	// Compressed chunks lose the spatial index, so the default is past the 31 day
+	// range of the endpoints scanning raw readings.
+	CompressAfter time.Duration
+	// RawRetention is how long raw readings are kept, zero keeps them forever.
+	// The hourly and daily rollups are kept either way.
+	RawRetention time.Duration
+	// MinuteRollupRetention is how long the minutely rollup is kept, zero keeps it forever
+	MinuteRollupRetention time.Duration
+
+	// AmqpChannelPoolSize is how many idle publishing channels are kept open
+	AmqpChannelPoolSize int
+	// PublishConfirmTimeout is how long a publisher waits for the broker to confirm a message
+	PublishConfirmTimeout time.Duration
+
+	// IngestMaxRetries is how many times a failed ingest message is retried
+	// before it is moved to the dead-letter queue
+	IngestMaxRetries int
+	// IngestRetryDelay is the delay before the first retry, it doubles on every attempt
+	IngestRetryDelay time.Duration
+
+	// TileCacheTTL is how long a rendered map tile is served from memory
+	TileCacheTTL time.Duration
+	// TileCacheSize is the maximum number of tiles kept in memory
+	TileCacheSize int
+
+	// ExportTimeout is how long a single export may stream rows
+	ExportTimeout time.Duration
 }
 
 var cfg *Config
@@ -45,6 +78,22 @@ func LoadConfig() *Config {
 		AmqpPassword: getEnv("AMQP_PASSWORD", "guest"),
 		AmqpHost:     getEnv("AMQP_HOST", "localhost"),
 		AmqpPort:     getEnv("AMQP_PORT", "5672"),
+
+		DBAutoMigrate:         getEnvBool("DB_AUTO_MIGRATE", true),
+		CompressAfter:         getEnvDuration("COMPRESS_AFTER", 35*24*time.Hour),
+		RawRetention:          getEnvDuration("RAW_RETENTION", 0),
+		MinuteRollupRetention: getEnvDuration("ROLLUP_1M_RETENTION", 0),
+
+		AmqpChannelPoolSize:   getEnvInt("AMQP_CHANNEL_POOL_SIZE", 8),
+		PublishConfirmTimeout: getEnvDuration("AMQP_CONFIRM_TIMEOUT", 5*time.Second),
+
+		IngestMaxRetries: getEnvInt("INGEST_MAX_RETRIES", 3),
+		IngestRetryDelay: getEnvDuration("INGEST_RETRY_DELAY", 5*time.Second),
+
+		TileCacheTTL:  getEnvDuration("TILE_CACHE_TTL", time.Minute),
+		TileCacheSize: getEnvInt("TILE_CACHE_SIZE", 2000),
+
+		ExportTimeout: getEnvDuration("EXPORT_TIMEOUT", 30*time.Minute),
 	}
 
 	return cfg
@@ -56,3 +105,45 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Invalid value for %s, using default %d - %s", key, defaultValue, err.Error())
+		return defaultValue
+	}
+	return i
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Invalid value for %s, using default %t - %s", key, defaultValue, err.Error())
+		return defaultValue
+	}
+	return b
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Invalid value for %s, using default %s - %s", key, defaultValue, err.Error())
+		return defaultValue
+	}
+	return d
+}
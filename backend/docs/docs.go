@@ -15,56 +15,70 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/anomalies": {
+        "/api/admin/dead-letters": {
             "get": {
-                "description": "Gets anomalies for a given time range",
+                "description": "Lists the ingest messages that failed after all retries, without removing them from the queue",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "anomalies"
+                    "admin"
                 ],
-                "summary": "Gets anomalies for range",
+                "summary": "Lists dead letters",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Start time",
-                        "name": "from",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "End time",
-                        "name": "to",
-                        "in": "query",
-                        "required": true
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Maximum number of messages to return",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Anomalies",
+                        "description": "Dead letters",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
                                 "type": "array",
                                 "items": {
-                                    "$ref": "#/definitions/pollution.Pollution"
+                                    "$ref": "#/definitions/deadletter.DeadLetter"
                                 }
                             }
                         }
                     },
-                    "400": {
-                        "description": "Invalid params",
+                    "500": {
+                        "description": "Failed to read the dead-letter queue",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
                                 "type": "string"
                             }
                         }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Removes every message from the dead-letter queue",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Purges dead letters",
+                "responses": {
+                    "200": {
+                        "description": "Number of purged messages",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "integer"
+                            }
+                        }
                     },
                     "500": {
-                        "description": "Failed to fetch pollution entries from database",
+                        "description": "Failed to purge the dead-letter queue",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -75,28 +89,28 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/pollutants": {
-            "get": {
-                "description": "Gets distinct pollutants that exists in database",
+        "/api/admin/dead-letters/replay": {
+            "post": {
+                "description": "Publishes every dead-lettered message back to the ingest queue",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "pollutants"
+                    "admin"
                 ],
-                "summary": "Gets pollutants",
+                "summary": "Replays all dead letters",
                 "responses": {
                     "200": {
-                        "description": "Pollutants",
+                        "description": "Number of replayed messages",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
-                                "type": "string"
+                                "type": "integer"
                             }
                         }
                     },
                     "500": {
-                        "description": "Failed to fetch pollutants entries from database",
+                        "description": "Failed to replay the dead letters",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -107,53 +121,85 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/pollutions": {
+        "/api/admin/dead-letters/{id}": {
             "get": {
-                "description": "Gets all pollution values for given time range.",
+                "description": "Gets a single dead-lettered message by its message id",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "pollutions"
+                    "admin"
                 ],
-                "summary": "Gets pollution values",
+                "summary": "Gets a dead letter",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Start time",
-                        "name": "from",
-                        "in": "query",
+                        "description": "Message id",
+                        "name": "id",
+                        "in": "path",
                         "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Dead letter",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/deadletter.DeadLetter"
+                            }
+                        }
                     },
-                    {
-                        "type": "string",
-                        "description": "End time",
-                        "name": "to",
-                        "in": "query",
-                        "required": true
+                    "404": {
+                        "description": "Dead letter not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
                     },
+                    "500": {
+                        "description": "Failed to read the dead-letter queue",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Removes a single message from the dead-letter queue",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Deletes a dead letter",
+                "parameters": [
                     {
                         "type": "string",
-                        "description": "Pollutant",
-                        "name": "pollutant",
-                        "in": "query"
+                        "description": "Message id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Pollution values",
+                        "description": "Dead letter deleted",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
-                                "type": "array",
-                                "items": {
-                                    "$ref": "#/definitions/pollution.Pollution"
-                                }
+                                "type": "string"
                             }
                         }
                     },
-                    "400": {
-                        "description": "Invalid params",
+                    "404": {
+                        "description": "Dead letter not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -162,7 +208,7 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "Failed to fetch pollution entries from database",
+                        "description": "Failed to delete the dead letter",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -171,127 +217,95 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/api/admin/dead-letters/{id}/replay": {
             "post": {
-                "description": "Posts a new pollution entry",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Publishes a dead-lettered message back to the ingest queue with its retry count reset",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "pollutions"
+                    "admin"
                 ],
-                "summary": "Posts pollution entry",
+                "summary": "Replays a dead letter",
                 "parameters": [
                     {
-                        "description": "Request of adding a new pollution entry",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/pollution.Pollution"
-                        }
+                        "type": "string",
+                        "description": "Message id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Successfully received the pollution entry",
+                        "description": "Dead letter replayed",
                         "schema": {
-                            "type": "string"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
-                    "400": {
-                        "description": "Failed to marshal request body",
+                    "404": {
+                        "description": "Dead letter not found",
                         "schema": {
-                            "type": "string"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
                     "500": {
-                        "description": "Failed to publish pollution entry to RabbitMQ queue",
+                        "description": "Failed to replay the dead letter",
                         "schema": {
-                            "type": "string"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     }
                 }
             }
         },
-        "/api/pollutions/density/rect": {
+        "/api/admin/storage": {
             "get": {
-                "description": "Gets pollution densities for a given rect and time range",
+                "description": "Reports the size and compression of the hypertables and continuous aggregates, and the active\ncompression, retention and refresh policies. Chunks are listed on request, newest first.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "pollutions"
+                    "admin"
                 ],
-                "summary": "Gets pollution densities of rect",
+                "summary": "Reports storage usage",
                 "parameters": [
                     {
-                        "type": "number",
-                        "description": "latFrom",
-                        "name": "latFrom",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "number",
-                        "description": "latTo",
-                        "name": "latTo",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "number",
-                        "description": "longFrom",
-                        "name": "longFrom",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "number",
-                        "description": "longTo",
-                        "name": "longTo",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "from",
-                        "name": "from",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "to",
-                        "name": "to",
-                        "in": "query",
-                        "required": true
+                        "type": "boolean",
+                        "default": false,
+                        "description": "List the chunks",
+                        "name": "chunks",
+                        "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "pollutant",
-                        "name": "pollutant",
+                        "description": "Only report this hypertable or continuous aggregate",
+                        "name": "table",
                         "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Pollution Densities",
+                        "description": "Storage report",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
-                                "type": "array",
-                                "items": {
-                                    "$ref": "#/definitions/pollution.PollutionDensity"
-                                }
+                                "$ref": "#/definitions/storage.Report"
                             }
                         }
                     },
-                    "400": {
-                        "description": "Invalid params",
+                    "404": {
+                        "description": "Table not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -300,7 +314,7 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "Failed to fetch pollution entries from database",
+                        "description": "Failed to fetch storage from database",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -311,53 +325,98 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/pollutions/{latitude}/{longitude}": {
+        "/api/anomalies": {
             "get": {
-                "description": "Gets pollution values for given location and time range",
+                "description": "Gets anomalies for a given time range, newest first. Every detector that flagged a reading is a separate anomaly.\nAnomalies are returned a page at a time, next_cursor and next point to the next page if there is one.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "pollutions"
+                    "anomalies"
                 ],
-                "summary": "Gets pollution values",
+                "summary": "Gets anomalies for range",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "latitude",
-                        "name": "latitude",
-                        "in": "path",
+                        "description": "Start time",
+                        "name": "from",
+                        "in": "query",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "longitude",
-                        "name": "longitude",
-                        "in": "path",
+                        "description": "End time",
+                        "name": "to",
+                        "in": "query",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "Start time",
-                        "name": "from",
+                        "default": "UTC",
+                        "description": "IANA time zone of times without an offset and of the returned times",
+                        "name": "tz",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "End time",
-                        "name": "to",
+                        "description": "Pollutant",
+                        "name": "pollutant",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated statuses: open, acknowledged, resolved",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated severities: low, medium, high, critical",
+                        "name": "severity",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unit to return the values in: µg/m³, mg/m³, ppb, ppm",
+                        "name": "unit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1000,
+                        "description": "Maximum number of items, 1-10000",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "desc",
+                        "description": "Order by time: asc or desc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields to return, e.g. time,value",
+                        "name": "fields",
                         "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Pollution Values",
+                        "description": "Anomalies",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
                                 "type": "array",
                                 "items": {
-                                    "$ref": "#/definitions/pollution.PollutionValueResponse"
+                                    "$ref": "#/definitions/pollution.Anomaly"
                                 }
                             }
                         }
@@ -372,7 +431,7 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "Failed to fetch pollution entries from database",
+                        "description": "Failed to fetch anomalies from database",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -382,43 +441,3560 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "pollution.Pollution": {
-            "type": "object",
-            "properties": {
-                "is_anomaly": {
-                    "type": "boolean"
+        },
+        "/api/anomalies/{id}/acknowledge": {
+            "post": {
+                "description": "Marks an open anomaly as acknowledged. The X-User header is recorded as the acknowledging user.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "anomalies"
+                ],
+                "summary": "Acknowledges an anomaly",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Anomaly id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Operator acknowledging the anomaly",
+                        "name": "X-User",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Optional note",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/pollution.anomalyStatusRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Acknowledged anomaly",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/pollution.Anomaly"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid params",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Anomaly not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Anomaly is not open",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to update anomaly",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/anomalies/{id}/resolve": {
+            "post": {
+                "description": "Marks an open or acknowledged anomaly as resolved. The X-User header is recorded as the resolving user.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "anomalies"
+                ],
+                "summary": "Resolves an anomaly",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Anomaly id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Operator resolving the anomaly",
+                        "name": "X-User",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Optional note",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/pollution.anomalyStatusRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Resolved anomaly",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/pollution.Anomaly"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid params",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Anomaly not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Anomaly is already resolved",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to update anomaly",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/aqi/scales": {
+            "get": {
+                "description": "Gets the supported AQI scales with their categories and breakpoint units",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "aqi"
+                ],
+                "summary": "Gets AQI scales",
+                "responses": {
+                    "200": {
+                        "description": "AQI scales",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/aqi/{latitude}/{longitude}": {
+            "get": {
+                "description": "Computes the air quality index around a location from the readings within the radius.\nBy default every pollutant is averaged over the averaging period the scale defines for it, ending at ` + "`" + `to` + "`" + `.\nIf ` + "`" + `from` + "`" + ` is given, every pollutant is averaged over the whole range instead.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "aqi"
+                ],
+                "summary": "Gets the AQI of a location",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "latitude",
+                        "name": "latitude",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "longitude",
+                        "name": "longitude",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start time, overrides the averaging periods of the scale",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End time, defaults to now",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "UTC",
+                        "description": "IANA time zone of times without an offset",
+                        "name": "tz",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "default": 5,
+                        "description": "Radius in km",
+                        "name": "radius",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "epa",
+                        "description": "AQI scale: epa, caqi, daqi",
+                        "name": "scale",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "AQI",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/pollution.AQIResult"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid params",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "No readings to compute the AQI from",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to fetch pollution entries from database",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/config/anomaly-rules": {
+            "get": {
+                "description": "Gets the anomaly detection rules of every pollutant and region",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "config"
+                ],
+                "summary": "Gets anomaly rules",
+                "responses": {
+                    "200": {
+                        "description": "Anomaly rules",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/pollution.AnomalyRule"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to fetch anomaly rules from database",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/config/anomaly-rules/audit": {
+            "get": {
+                "description": "Gets the changes made to the anomaly rules, a page at a time newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "config"
+                ],
+                "summary": "Gets the anomaly rule audit trail",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Pollutant",
+                        "name": "pollutant",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1000,
+                        "description": "Maximum number of entries, 1-10000",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "desc",
+                        "description": "Order by time: asc or desc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields to return, e.g. changed_at,action",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Audit entries",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/pollution.AnomalyRuleAudit"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid params",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to fetch audit trail from database",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/config/anomaly-rules/{pollutant}": {
+            "get": {
+                "description": "Gets the anomaly detection rule of a pollutant, or of a pollutant in a region",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "config"
+                ],
+                "summary": "Gets an anomaly rule",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Pollutant",
+                        "name": "pollutant",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Region name, empty for the global rule",
+                        "name": "region",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Anomaly rule",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/pollution.AnomalyRule"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Anomaly rule not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to fetch anomaly rule from database",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Creates or updates the anomaly detection rule of a pollutant. Fields missing from the body keep their\ncurrent value, or the default value for a new rule. The ingest worker uses the new rule immediately.\nThe X-User header is recorded in the audit trail.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "config"
+                ],
+                "summary": "Creates or updates an anomaly rule",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Pollutant",
+                        "name": "pollutant",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Region name, empty for the global rule",
+                        "name": "region",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Operator making the change",
+                        "name": "X-User",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Anomaly rule",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/pollution.AnomalyRule"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Saved anomaly rule",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/pollution.AnomalyRule"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Unknown pollutant or failed to parse request body",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid anomaly rule, with field level errors",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to save anomaly rule",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Deletes the anomaly detection rule of a pollutant. Pollutants without a rule use the built-in defaults.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "config"
+                ],
+                "summary": "Deletes an anomaly rule",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Pollutant",
+                        "name": "pollutant",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Region name, empty for the global rule",
+                        "name": "region",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Operator making the change",
+                        "name": "X-User",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Anomaly rule deleted",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Anomaly rule not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to delete anomaly rule",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/export": {
+            "get": {
+                "description": "Streams the readings matching the filters as CSV, NDJSON or Parquet, oldest first. Rows are read from\na database cursor and written as they arrive, so exports of any size use constant memory.\nIf the export fails after streaming started the response is cut short.",
+                "produces": [
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/vnd.apache.parquet"
+                ],
+                "tags": [
+                    "export"
+                ],
+                "summary": "Exports readings",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "csv",
+                        "description": "csv, ndjson or parquet",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start time, defaults to 24 hours ago",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End time, defaults to now",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "UTC",
+                        "description": "IANA time zone of times without an offset and of the exported times",
+                        "name": "tz",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated pollutants",
+                        "name": "pollutant",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Id of a saved region the readings must lie in",
+                        "name": "region",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Station id",
+                        "name": "station",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unit to export the values in: µg/m³, mg/m³, ppb, ppm",
+                        "name": "unit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Readings",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid params",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Region not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to fetch region from database",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/pollutants": {
+            "get": {
+                "description": "Gets distinct pollutants that exists in database",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pollutants"
+                ],
+                "summary": "Gets pollutants",
+                "responses": {
+                    "200": {
+                        "description": "Pollutants",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to fetch pollutants entries from database",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/pollutions": {
+            "get": {
+                "description": "Gets all pollution values for given time range, a page at a time ordered by time. If there are more values\nthe response has the cursor of the next page in next_cursor and a link to it in next.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pollutions"
+                ],
+                "summary": "Gets pollution values",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Start time",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "End time",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "UTC",
+                        "description": "IANA time zone of times without an offset and of the returned times",
+                        "name": "tz",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Pollutant",
+                        "name": "pollutant",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unit to return the values in: µg/m³, mg/m³, ppb, ppm",
+                        "name": "unit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1000,
+                        "description": "Maximum number of items, 1-10000",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "desc",
+                        "description": "Order by time: asc or desc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields to return, e.g. time,value",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Pollution values",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/pollution.Pollution"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid params",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to fetch pollution entries from database",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Posts a new pollution entry",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pollutions"
+                ],
+                "summary": "Posts pollution entry",
+                "parameters": [
+                    {
+                        "description": "Request of adding a new pollution entry",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/pollution.Pollution"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully received the pollution entry",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Failed to marshal request body",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid pollution entry, with field level errors",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Failed to publish pollution entry to RabbitMQ queue",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/pollutions/batch": {
+            "post": {
+                "description": "Posts multiple pollution entries at once, either as a JSON array or as NDJSON (one entry per line).\nEvery item is validated on its own; accepted items are published to the ingest queue in chunks.",
+                "consumes": [
+                    "application/json",
+                    "application/x-ndjson"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pollutions"
+                ],
+                "summary": "Posts a batch of pollution entries",
+                "parameters": [
+                    {
+                        "description": "Pollution entries",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/pollution.Pollution"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Per-item accepted/rejected report",
+                        "schema": {
+                            "$ref": "#/definitions/pollution.BatchReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Failed to parse request body",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Batch is too large",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Failed to publish some of the entries",
+                        "schema": {
+                            "$ref": "#/definitions/pollution.BatchReport"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/pollutions/density/area": {
+            "get": {
+                "description": "Aggregates the readings inside the boundary of a saved region per time bucket and pollutant.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pollutions"
+                ],
+                "summary": "Gets statistics of a saved region",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Region id",
+                        "name": "region",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start time, defaults to 24 hours ago",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End time, defaults to now",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "UTC",
+                        "description": "IANA time zone of times without an offset and of the returned times",
+                        "name": "tz",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "1h",
+                        "description": "Bucket size as a duration, e.g. 15m, 1h, or auto",
+                        "name": "step",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 200,
+                        "description": "Number of buckets an auto step aims for, 1-10000",
+                        "name": "points",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Pollutant",
+                        "name": "pollutant",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unit to return the values in: µg/m³, mg/m³, ppb, ppm",
+                        "name": "unit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Statistics per bucket and pollutant",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/pollution.AreaStatistics"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid params",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Region not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to fetch statistics from database",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Aggregates the readings inside a GeoJSON Polygon or MultiPolygon per time bucket and pollutant.\nThe body is either the geometry itself or a Feature wrapping it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pollutions"
+                ],
+                "summary": "Gets statistics of an area",
+                "parameters": [
+                    {
+                        "description": "GeoJSON Polygon, MultiPolygon or Feature",
+                        "name": "area",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start time, defaults to 24 hours ago",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End time, defaults to now",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "UTC",
+                        "description": "IANA time zone of times without an offset and of the returned times",
+                        "name": "tz",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "1h",
+                        "description": "Bucket size as a duration, e.g. 15m, 1h, or auto",
+                        "name": "step",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 200,
+                        "description": "Number of buckets an auto step aims for, 1-10000",
+                        "name": "points",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Pollutant",
+                        "name": "pollutant",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unit to return the values in: µg/m³, mg/m³, ppb, ppm",
+                        "name": "unit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Statistics per bucket and pollutant",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/pollution.AreaStatistics"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid params or geometry",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to fetch statistics from database",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/pollutions/density/rect": {
+            "get": {
+                "description": "Computes an aggregate of the readings inside a rect per pollutant and time bucket, ordered by pollutant and time.\nThe step is either a duration or auto, which picks a step giving about points buckets. Concentration aggregates\ncarry the AQI sub-index of their pollutant, and the response carries the AQI of the rect computed from the\naverages of the whole range.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pollutions"
+                ],
+                "summary": "Gets pollution densities of rect",
+                "parameters": [
+                    {
+                        "type": "number",
+                        "description": "latFrom",
+                        "name": "latFrom",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "latTo",
+                        "name": "latTo",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "longFrom",
+                        "name": "longFrom",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "longTo",
+                        "name": "longTo",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "from",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "to",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "UTC",
+                        "description": "IANA time zone of times without an offset and of the returned times",
+                        "name": "tz",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated pollutants, all if empty",
+                        "name": "pollutant",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "auto",
+                        "description": "Bucket size as a duration, e.g. 15m, 1h, or auto",
+                        "name": "step",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 200,
+                        "description": "Number of buckets an auto step aims for, 1-10000",
+                        "name": "points",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "avg",
+                        "description": "Aggregate: avg, min, max, p50, p95, count, stddev",
+                        "name": "agg",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "epa",
+                        "description": "AQI scale: epa, caqi, daqi",
+                        "name": "scale",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unit to return the densities in: µg/m³, mg/m³, ppb, ppm",
+                        "name": "unit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Pollution densities in data, the step, the AQI of the rect over the whole range in aqi",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid params",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to fetch pollution entries from database",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/pollutions/estimate": {
+            "get": {
+                "description": "Interpolates the concentration of a pollutant at a location from the latest value of the sensors\naround it. method=idw weights the closest ` + "`" + `neighbors` + "`" + ` sensors within the radius by the inverse of\ntheir distance to the power, method=kriging uses ordinary kriging with an exponential variogram\nfitted to the sensors and also returns the kriging variance.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pollutions"
+                ],
+                "summary": "Estimates the pollution at a location",
+                "parameters": [
+                    {
+                        "type": "number",
+                        "description": "Latitude",
+                        "name": "lat",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "Longitude",
+                        "name": "lon",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Pollutant",
+                        "name": "pollutant",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "idw",
+                        "description": "idw or kriging",
+                        "name": "method",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "default": 25,
+                        "description": "Radius of the sensors used, in km",
+                        "name": "radius",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 12,
+                        "description": "Maximum number of closest sensors used",
+                        "name": "neighbors",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "default": 2,
+                        "description": "Distance exponent of IDW",
+                        "name": "power",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start time of the latest readings, defaults to 3 hours ago",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End time of the latest readings, defaults to now",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "UTC",
+                        "description": "IANA time zone of times without an offset",
+                        "name": "tz",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unit to return the value in: µg/m³, mg/m³, ppb, ppm",
+                        "name": "unit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Estimate",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid params",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not enough sensors around the location",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to fetch samples from database",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/pollutions/estimate/grid": {
+            "get": {
+                "description": "Interpolates the concentration of a pollutant at the centre of every cell of a grid over the bounds,\nwith the same methods as /api/pollutions/estimate. Cells start at the south-west corner of the\nbounds, the cell size follows the web map zoom level or is given in degrees with cell_size.\nCells without enough sensors around them are null.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pollutions"
+                ],
+                "summary": "Estimates a pollution surface",
+                "parameters": [
+                    {
+                        "type": "number",
+                        "description": "Southern edge of the area",
+                        "name": "latFrom",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "Northern edge of the area",
+                        "name": "latTo",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "Western edge of the area",
+                        "name": "longFrom",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "Eastern edge of the area",
+                        "name": "longTo",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Pollutant",
+                        "name": "pollutant",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 5,
+                        "description": "Map zoom level, 0-18",
+                        "name": "zoom",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Cell size in degrees, overrides zoom",
+                        "name": "cell_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "idw",
+                        "description": "idw or kriging",
+                        "name": "method",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "default": 25,
+                        "description": "Radius of the sensors used, in km",
+                        "name": "radius",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 12,
+                        "description": "Maximum number of closest sensors used",
+                        "name": "neighbors",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "default": 2,
+                        "description": "Distance exponent of IDW",
+                        "name": "power",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start time of the latest readings, defaults to 3 hours ago",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End time of the latest readings, defaults to now",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "UTC",
+                        "description": "IANA time zone of times without an offset",
+                        "name": "tz",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unit to return the values in: µg/m³, mg/m³, ppb, ppm",
+                        "name": "unit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Surface of the estimates",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid params or too many cells",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not enough sensors around the area",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to fetch samples from database",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/pollutions/grid": {
+            "get": {
+                "description": "Aggregates the readings into a square grid and returns the average, maximum and number of readings\nof every cell and pollutant. The cell size follows the web map zoom level, 8 cells per 256px tile,\nor is given in degrees with cell_size. Cells are aligned to 0,0 so they are stable while panning.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pollutions"
+                ],
+                "summary": "Gets gridded pollution values",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Start time, defaults to 24 hours ago",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End time, defaults to now",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "UTC",
+                        "description": "IANA time zone of times without an offset",
+                        "name": "tz",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Pollutant",
+                        "name": "pollutant",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 5,
+                        "description": "Map zoom level, 0-18",
+                        "name": "zoom",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Cell size in degrees, overrides zoom",
+                        "name": "cell_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Southern edge of the area",
+                        "name": "latFrom",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Northern edge of the area",
+                        "name": "latTo",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Western edge of the area",
+                        "name": "longFrom",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Eastern edge of the area",
+                        "name": "longTo",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unit to return the values in: µg/m³, mg/m³, ppb, ppm",
+                        "name": "unit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "GridCell list and the cell size",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid params or too many cells",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to fetch grid from database",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/pollutions/near": {
+            "get": {
+                "description": "Searches around a location within the radius, closest first, and returns the distance of every result.\nmode=readings returns the closest readings, mode=latest the latest value of every pollutant of the\nclosest ` + "`" + `limit` + "`" + ` sensors, and mode=stations the closest registered stations.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pollutions"
+                ],
+                "summary": "Gets pollution values near a location",
+                "parameters": [
+                    {
+                        "type": "number",
+                        "description": "Latitude",
+                        "name": "lat",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "Longitude",
+                        "name": "lon",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "default": 10,
+                        "description": "Radius in km",
+                        "name": "radius",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Maximum number of readings, sensors or stations",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "readings",
+                        "description": "readings, latest or stations",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start time, defaults to 24 hours ago",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End time, defaults to now",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "UTC",
+                        "description": "IANA time zone of times without an offset and of the returned times",
+                        "name": "tz",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Pollutant",
+                        "name": "pollutant",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unit to return the values in: µg/m³, mg/m³, ppb, ppm",
+                        "name": "unit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "NearbyReading, NearbySensor or NearbyStation list depending on mode",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid params",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to fetch pollution entries from database",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/pollutions/{latitude}/{longitude}": {
+            "get": {
+                "description": "Gets pollution values for given location and time range, a page at a time ordered by time",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pollutions"
+                ],
+                "summary": "Gets pollution values",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "latitude",
+                        "name": "latitude",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "longitude",
+                        "name": "longitude",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start time",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End time",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "UTC",
+                        "description": "IANA time zone of times without an offset and of the returned times",
+                        "name": "tz",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unit to return the values in: µg/m³, mg/m³, ppb, ppm",
+                        "name": "unit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1000,
+                        "description": "Maximum number of values, 1-10000",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Order by time: asc or desc, by pollutant and newest first if not set",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields to return, e.g. time,value",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Pollution Values",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/pollution.PollutionValueResponse"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid params",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to fetch pollution entries from database",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/regions": {
+            "get": {
+                "description": "Lists the saved regions without their boundaries, a page at a time ordered by name",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "regions"
+                ],
+                "summary": "Lists regions",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 1000,
+                        "description": "Maximum number of regions, 1-10000",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "asc",
+                        "description": "Order by name: asc or desc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields to return, e.g. id,name",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Regions",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/region.Region"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid params",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to fetch regions from database",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Saves a named region. The boundary is a GeoJSON Polygon, MultiPolygon or a Feature wrapping one.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "regions"
+                ],
+                "summary": "Saves a region",
+                "parameters": [
+                    {
+                        "description": "Region",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/region.Region"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Saved region",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/region.Region"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Failed to parse request body",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Region already exists",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid region, with field level errors",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to save region",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/regions/import": {
+            "post": {
+                "description": "Saves every feature of a GeoJSON FeatureCollection as a region, named by the name_property property\nof the feature. Regions that already exist are replaced. Either all features are imported or none.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "regions"
+                ],
+                "summary": "Imports regions from GeoJSON",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "name",
+                        "description": "Feature property holding the region name",
+                        "name": "name_property",
+                        "in": "query"
+                    },
+                    {
+                        "description": "GeoJSON FeatureCollection",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Number of created and updated regions",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/region.ImportResult"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Failed to parse request body",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid features, with field level errors",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to import regions",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/regions/{id}": {
+            "get": {
+                "description": "Gets a saved region with its boundary",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "regions"
+                ],
+                "summary": "Gets a region",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Region id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Region",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/region.Region"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid id",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Region not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to fetch region from database",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Updates a saved region, fields missing from the body keep their current value.\nA region can not be renamed while anomaly rules use its boundary.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "regions"
+                ],
+                "summary": "Updates a region",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Region id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Region",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/region.Region"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated region",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/region.Region"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Failed to parse request body",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Region not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Region name is taken or used by anomaly rules",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid region, with field level errors",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to save region",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Deletes a saved region. A region can not be deleted while anomaly rules use its boundary.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "regions"
+                ],
+                "summary": "Deletes a region",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Region id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Region deleted",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid id",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Region not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Region is used by anomaly rules",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to delete region",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/regions/{id}/anomalies": {
+            "get": {
+                "description": "Gets the anomalies inside the region for a time range, newest first, a page at a time",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "regions"
+                ],
+                "summary": "Gets the anomalies of a region",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Region id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start time",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "End time",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "UTC",
+                        "description": "IANA time zone of times without an offset and of the returned times",
+                        "name": "tz",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Pollutant",
+                        "name": "pollutant",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated statuses: open, acknowledged, resolved",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated severities: low, medium, high, critical",
+                        "name": "severity",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unit to return the values in: µg/m³, mg/m³, ppb, ppm",
+                        "name": "unit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1000,
+                        "description": "Maximum number of anomalies, 1-10000",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "desc",
+                        "description": "Order by time: asc or desc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields to return, e.g. time,value",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Anomalies",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/pollution.Anomaly"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid params",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Region not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to fetch anomalies from database",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/regions/{id}/aqi": {
+            "get": {
+                "description": "Computes the air quality index from the readings inside the region.\nBy default every pollutant is averaged over the averaging period the scale defines for it, ending at ` + "`" + `to` + "`" + `.\nIf ` + "`" + `from` + "`" + ` is given, every pollutant is averaged over the whole range instead.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "regions"
+                ],
+                "summary": "Gets the AQI of a region",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Region id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start time, overrides the averaging periods of the scale",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End time, defaults to now",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "UTC",
+                        "description": "IANA time zone of times without an offset",
+                        "name": "tz",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "epa",
+                        "description": "AQI scale: epa, caqi, daqi",
+                        "name": "scale",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "AQI",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/pollution.AQIResult"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid params",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Region not found or no readings to compute the AQI from",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to fetch readings from database",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/regions/{id}/timeseries": {
+            "get": {
+                "description": "Aggregates the readings inside the region per time bucket and pollutant",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "regions"
+                ],
+                "summary": "Gets the time series of a region",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Region id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start time, defaults to 24 hours ago",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End time, defaults to now",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "UTC",
+                        "description": "IANA time zone of times without an offset and of the returned times",
+                        "name": "tz",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "1h",
+                        "description": "Bucket size as a duration, e.g. 15m, 1h, or auto",
+                        "name": "step",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 200,
+                        "description": "Number of buckets an auto step aims for, 1-10000",
+                        "name": "points",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Pollutant",
+                        "name": "pollutant",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unit to return the values in: µg/m³, mg/m³, ppb, ppm",
+                        "name": "unit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Statistics per bucket and pollutant",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/pollution.AreaStatistics"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid params",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Region not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to fetch statistics from database",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/stations": {
+            "get": {
+                "description": "Lists the registered stations, a page at a time ordered by id",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stations"
+                ],
+                "summary": "Lists stations",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Only list active stations",
+                        "name": "active",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1000,
+                        "description": "Maximum number of stations, 1-10000",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "asc",
+                        "description": "Order by id: asc or desc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields to return, e.g. id,name",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Stations",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/station.Station"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid params",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to fetch stations from database",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Registers a new station. Stations are active unless the body says otherwise.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stations"
+                ],
+                "summary": "Registers a station",
+                "parameters": [
+                    {
+                        "description": "Station",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/station.Station"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Registered station",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/station.Station"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Failed to parse request body",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Station already exists",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid station, with field level errors",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to save station",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/stations/{id}": {
+            "get": {
+                "description": "Gets a registered station by its id",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stations"
+                ],
+                "summary": "Gets a station",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Station id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Station",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/station.Station"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Station not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to fetch station from database",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Updates a registered station, fields missing from the body keep their current value.\nMoving a station does not move the readings it already reported.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stations"
+                ],
+                "summary": "Updates a station",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Station id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Station",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/station.Station"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated station",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/station.Station"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Failed to parse request body",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Station not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid station, with field level errors",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to save station",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Removes a station from the registry. Its readings are kept and can still be queried by station id.\nSet active to false instead to stop accepting readings but keep the station.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stations"
+                ],
+                "summary": "Deletes a station",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Station id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Station deleted",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Station not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to delete station",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/stations/{id}/readings": {
+            "get": {
+                "description": "Gets the readings reported by a station for a time range, a page at a time ordered by time",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stations"
+                ],
+                "summary": "Gets station readings",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Station id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start time, defaults to 24 hours ago",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End time, defaults to now",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "UTC",
+                        "description": "IANA time zone of times without an offset and of the returned times",
+                        "name": "tz",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Pollutant",
+                        "name": "pollutant",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unit to return the values in: µg/m³, mg/m³, ppb, ppm",
+                        "name": "unit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1000,
+                        "description": "Maximum number of readings, 1-10000",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Order by time: asc or desc, by pollutant and newest first if not set",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields to return, e.g. time,value",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Readings",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/pollution.PollutionValueResponse"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid params",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Station not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to fetch readings from database",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/tiles/{z}/{x}/{y}.{format}": {
+            "get": {
+                "description": "Serves the readings of a web mercator tile as a Mapbox Vector Tile (.mvt) or a PNG heat map (.png).\nVector tiles have a \"pollution\" layer with a point per pixel and pollutant, carrying avg, max and count.\nPNG tiles require a pollutant and colour the readings by the category of the AQI scale.\nTiles are cached in memory for TILE_CACHE_TTL.",
+                "produces": [
+                    "application/vnd.mapbox-vector-tile",
+                    "image/png"
+                ],
+                "tags": [
+                    "tiles"
+                ],
+                "summary": "Gets a map tile",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Zoom level, 0-22",
+                        "name": "z",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Tile column",
+                        "name": "x",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Tile row",
+                        "name": "y",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "mvt or png",
+                        "name": "format",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start time, defaults to 24 hours ago",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End time, defaults to now",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "UTC",
+                        "description": "IANA time zone of times without an offset",
+                        "name": "tz",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Pollutant, required for PNG tiles",
+                        "name": "pollutant",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unit of the vector tile values: µg/m³, mg/m³, ppb, ppm",
+                        "name": "unit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "epa",
+                        "description": "AQI scale of the PNG colours: epa, caqi, daqi",
+                        "name": "scale",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Tile",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid params",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Unknown format",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to build the tile",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "deadletter.DeadLetter": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "type": "object"
+                },
+                "failed_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "retry_count": {
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "pollution.AQIResult": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string"
+                },
+                "dominant_pollutant": {
+                    "type": "string"
+                },
+                "index": {
+                    "type": "number"
+                },
+                "scale": {
+                    "type": "string"
+                },
+                "sub_indices": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/pollution.AQISubIndex"
+                    }
+                }
+            }
+        },
+        "pollution.AQISubIndex": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string"
+                },
+                "concentration": {
+                    "type": "number"
+                },
+                "index": {
+                    "type": "number"
+                },
+                "pollutant": {
+                    "type": "string"
+                },
+                "unit": {
+                    "type": "string"
+                }
+            }
+        },
+        "pollution.Anomaly": {
+            "type": "object",
+            "properties": {
+                "acknowledged_at": {
+                    "type": "string"
+                },
+                "acknowledged_by": {
+                    "type": "string"
+                },
+                "baseline_mean": {
+                    "type": "number"
+                },
+                "baseline_stddev": {
+                    "type": "number"
+                },
+                "detected_at": {
+                    "type": "string"
+                },
+                "detector": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "latitude": {
+                    "type": "number"
+                },
+                "longitude": {
+                    "type": "number"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "pollutant": {
+                    "type": "string"
+                },
+                "reading_id": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "resolved_at": {
+                    "type": "string"
+                },
+                "resolved_by": {
+                    "type": "string"
+                },
+                "score": {
+                    "type": "number"
+                },
+                "severity": {
+                    "type": "string"
+                },
+                "station_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "threshold": {
+                    "type": "number"
+                },
+                "time": {
+                    "type": "string"
+                },
+                "unit": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "number"
+                }
+            }
+        },
+        "pollution.AnomalyRule": {
+            "type": "object",
+            "properties": {
+                "boundary": {
+                    "description": "Boundary is a GeoJSON Polygon or MultiPolygon. Regional rules without one\napply inside the saved region (see /api/regions) with the same name.",
+                    "type": "object"
                 },
-                "latitude": {
+                "detectors": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "ewma_alpha": {
                     "type": "number"
                 },
-                "longitude": {
+                "ewma_threshold": {
+                    "type": "number"
+                },
+                "ewma_window_minutes": {
+                    "type": "integer"
+                },
+                "max_increase_percent": {
+                    "type": "number"
+                },
+                "pollutant": {
+                    "type": "string"
+                },
+                "radius_km": {
+                    "type": "number"
+                },
+                "rate_window_minutes": {
+                    "type": "integer"
+                },
+                "region": {
+                    "type": "string"
+                },
+                "seasonal_days": {
+                    "type": "integer"
+                },
+                "seasonal_threshold": {
+                    "type": "number"
+                },
+                "static_threshold": {
+                    "type": "number"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "updated_by": {
+                    "type": "string"
+                },
+                "window_minutes": {
+                    "type": "integer"
+                },
+                "zscore_threshold": {
+                    "type": "number"
+                }
+            }
+        },
+        "pollution.AnomalyRuleAudit": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "changed_at": {
+                    "type": "string"
+                },
+                "changed_by": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "new_rule": {
+                    "type": "object"
+                },
+                "old_rule": {
+                    "type": "object"
+                },
+                "pollutant": {
+                    "type": "string"
+                },
+                "region": {
+                    "type": "string"
+                }
+            }
+        },
+        "pollution.AreaStatistics": {
+            "type": "object",
+            "properties": {
+                "avg": {
+                    "type": "number"
+                },
+                "count": {
+                    "type": "integer"
+                },
+                "max": {
+                    "type": "number"
+                },
+                "min": {
                     "type": "number"
                 },
                 "pollutant": {
                     "type": "string"
                 },
+                "stddev": {
+                    "type": "number"
+                },
                 "time": {
                     "type": "string"
                 },
-                "value": {
+                "unit": {
+                    "type": "string"
+                }
+            }
+        },
+        "pollution.BatchItemResult": {
+            "type": "object",
+            "properties": {
+                "accepted": {
+                    "type": "boolean"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "fields": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/pollution.FieldError"
+                    }
+                },
+                "index": {
+                    "type": "integer"
+                }
+            }
+        },
+        "pollution.BatchReport": {
+            "type": "object",
+            "properties": {
+                "accepted": {
+                    "type": "integer"
+                },
+                "rejected": {
+                    "type": "integer"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/pollution.BatchItemResult"
+                    }
+                }
+            }
+        },
+        "pollution.Detection": {
+            "type": "object",
+            "properties": {
+                "detector": {
+                    "type": "string"
+                },
+                "mean": {
+                    "description": "Baseline the reading was compared with and the limit Score was compared to",
+                    "type": "number"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "score": {
+                    "type": "number"
+                },
+                "stddev": {
                     "type": "number"
+                },
+                "threshold": {
+                    "type": "number"
+                }
+            }
+        },
+        "pollution.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
                 }
             }
         },
-        "pollution.PollutionDensity": {
+        "pollution.Pollution": {
             "type": "object",
             "properties": {
-                "density": {
+                "anomalies": {
+                    "description": "Anomalies holds the detectors that flagged the reading, set by the ingest service",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/pollution.Detection"
+                    }
+                },
+                "is_anomaly": {
+                    "type": "boolean"
+                },
+                "latitude": {
+                    "type": "number"
+                },
+                "longitude": {
                     "type": "number"
                 },
                 "pollutant": {
                     "type": "string"
                 },
+                "pressure": {
+                    "type": "number"
+                },
+                "station_id": {
+                    "description": "StationID references a registered station, the reading then takes the\nlocation of the station instead of Latitude and Longitude",
+                    "type": "string"
+                },
+                "temperature": {
+                    "description": "Temperature in °C and Pressure in hPa the reading was taken at, used to\nconvert ppb/ppm into µg/m³. Standard conditions are assumed if missing.",
+                    "type": "number"
+                },
                 "time": {
                     "type": "string"
+                },
+                "unit": {
+                    "description": "Unit of Value, readings are converted to the canonical unit of their\npollutant before they are stored. Empty means the canonical unit.",
+                    "type": "string"
+                },
+                "value": {
+                    "type": "number"
                 }
             }
         },
@@ -431,10 +4007,234 @@ const docTemplate = `{
                 "time": {
                     "type": "string"
                 },
+                "unit": {
+                    "type": "string"
+                },
                 "value": {
                     "type": "number"
                 }
             }
+        },
+        "pollution.anomalyStatusRequest": {
+            "type": "object",
+            "properties": {
+                "note": {
+                    "type": "string"
+                }
+            }
+        },
+        "region.ImportResult": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "integer"
+                },
+                "updated": {
+                    "type": "integer"
+                }
+            }
+        },
+        "region.Region": {
+            "type": "object",
+            "properties": {
+                "area_km2": {
+                    "type": "number"
+                },
+                "boundary": {
+                    "description": "Boundary is a GeoJSON Polygon or MultiPolygon, left out of region lists",
+                    "type": "object"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "station.Calibration": {
+            "type": "object",
+            "properties": {
+                "last_calibrated_at": {
+                    "type": "string"
+                },
+                "next_due_at": {
+                    "type": "string"
+                },
+                "notes": {
+                    "type": "string"
+                }
+            }
+        },
+        "station.Station": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "description": "Active stations accept readings, decommissioned ones keep their history",
+                    "type": "boolean"
+                },
+                "calibration": {
+                    "$ref": "#/definitions/station.Calibration"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "latitude": {
+                    "type": "number"
+                },
+                "longitude": {
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "owner": {
+                    "type": "string"
+                },
+                "pollutants": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "storage.Chunk": {
+            "type": "object",
+            "properties": {
+                "before_compression_bytes": {
+                    "description": "BeforeCompressionBytes is the size of a compressed chunk before it was\ncompressed, its TotalBytes is the size after",
+                    "type": "integer"
+                },
+                "compressed": {
+                    "type": "boolean"
+                },
+                "compression_ratio": {
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "range_end": {
+                    "type": "string"
+                },
+                "range_start": {
+                    "type": "string"
+                },
+                "table": {
+                    "type": "string"
+                },
+                "total_bytes": {
+                    "type": "integer"
+                }
+            }
+        },
+        "storage.Policy": {
+            "type": "object",
+            "properties": {
+                "config": {
+                    "description": "Config holds the settings of the job like compress_after or drop_after",
+                    "type": "object"
+                },
+                "job_id": {
+                    "type": "integer"
+                },
+                "kind": {
+                    "description": "Kind is compression, retention or refresh",
+                    "type": "string"
+                },
+                "last_run_status": {
+                    "type": "string"
+                },
+                "last_successful_finish": {
+                    "type": "string"
+                },
+                "next_start": {
+                    "type": "string"
+                },
+                "schedule_interval": {
+                    "type": "string"
+                },
+                "scheduled": {
+                    "type": "boolean"
+                },
+                "table": {
+                    "type": "string"
+                },
+                "total_failures": {
+                    "type": "integer"
+                }
+            }
+        },
+        "storage.Report": {
+            "type": "object",
+            "properties": {
+                "chunks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/storage.Chunk"
+                    }
+                },
+                "policies": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/storage.Policy"
+                    }
+                },
+                "tables": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/storage.Table"
+                    }
+                }
+            }
+        },
+        "storage.Table": {
+            "type": "object",
+            "properties": {
+                "after_compression_bytes": {
+                    "type": "integer"
+                },
+                "before_compression_bytes": {
+                    "description": "BeforeCompressionBytes and AfterCompressionBytes only cover the\ncompressed chunks",
+                    "type": "integer"
+                },
+                "chunks": {
+                    "type": "integer"
+                },
+                "compressed_chunks": {
+                    "type": "integer"
+                },
+                "compression_ratio": {
+                    "description": "CompressionRatio is BeforeCompressionBytes / AfterCompressionBytes",
+                    "type": "number"
+                },
+                "kind": {
+                    "description": "Kind is hypertable or continuous_aggregate",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "total_bytes": {
+                    "type": "integer"
+                }
+            }
         }
     }
 }`
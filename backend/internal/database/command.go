@@ -0,0 +1,111 @@
+package database
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/AkifSahn/pollution-tracker/config"
+)
+
+// RunMigrate is the migrate subcommand, it applies, reverts or lists the
+// migrations and returns the exit code.
+//
+//	pollution-tracker migrate up [n]
+//	pollution-tracker migrate down [n]
+//	pollution-tracker migrate status
+func RunMigrate(args []string) int {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: pollution-tracker migrate up [n] | down [n] | status")
+		fmt.Fprintln(fs.Output(), "  up      applies the pending migrations, only the next n if given")
+		fmt.Fprintln(fs.Output(), "  down    reverts the last n applied migrations, 1 by default")
+		fmt.Fprintln(fs.Output(), "  status  lists the migrations and when they were applied")
+		fs.PrintDefaults()
+	}
+	timeout := fs.Duration("timeout", time.Hour, "Maximum time the command may take")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if fs.NArg() == 0 || fs.NArg() > 2 {
+		fs.Usage()
+		return 2
+	}
+	command := fs.Arg(0)
+
+	n := 0
+	if command == "down" {
+		n = 1
+	}
+	if fs.NArg() == 2 {
+		var err error
+		n, err = strconv.Atoi(fs.Arg(1))
+		if err != nil || n <= 0 || command == "status" {
+			fs.Usage()
+			return 2
+		}
+	}
+
+	// Connect without migrating, the command decides what to apply
+	DBPool = connectDB(config.LoadConfig())
+	defer DBPool.Close()
+
+	migrator, err := NewMigrator(DBPool)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	var done []MigrationStatus
+	switch command {
+	case "up":
+		done, err = migrator.Up(ctx, n)
+		for _, m := range done {
+			fmt.Fprintf(os.Stderr, "Applied %d_%s\n", m.Version, m.Name)
+		}
+	case "down":
+		done, err = migrator.Down(ctx, n)
+		for _, m := range done {
+			fmt.Fprintf(os.Stderr, "Reverted %d_%s\n", m.Version, m.Name)
+		}
+	case "status":
+		done, err = migrator.Status(ctx)
+		if err == nil {
+			printStatus(done)
+		}
+	default:
+		fs.Usage()
+		return 2
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	if command != "status" && len(done) == 0 {
+		fmt.Fprintln(os.Stderr, "Nothing to do")
+	}
+
+	return 0
+}
+
+func printStatus(statuses []MigrationStatus) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+	for _, s := range statuses {
+		applied := "pending"
+		if s.AppliedAt != nil {
+			applied = s.AppliedAt.Local().Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, applied)
+	}
+	w.Flush()
+}
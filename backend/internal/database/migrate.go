@@ -0,0 +1,397 @@
+package database
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Migrations are embedded into the binary, a migration is a pair of
+// <version>_<name>.up.sql and <version>_<name>.down.sql files
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// noTransactionDirective as the first line of a file runs its statements one
+// by one outside a transaction. TimescaleDB steps like creating a continuous
+// aggregate with data can not run inside one. Such a file must be idempotent,
+// a failure leaves the statements before it applied.
+const noTransactionDirective = "-- migrate:no-transaction"
+
+// migrationLockKey is the advisory lock held while migrating, so instances
+// starting at the same time do not apply the same migration twice
+const migrationLockKey = 7402641
+
+type migrationScript struct {
+	sql           string
+	noTransaction bool
+}
+
+type migration struct {
+	version int
+	name    string
+	up      migrationScript
+	down    migrationScript
+}
+
+// MigrationStatus is a known migration and when it was applied, AppliedAt is
+// nil for a pending migration
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+// parseScript reads the directive on the first line of a migration file
+func parseScript(content string) migrationScript {
+	firstLine, _, _ := strings.Cut(content, "\n")
+	return migrationScript{
+		sql:           content,
+		noTransaction: strings.TrimSpace(firstLine) == noTransactionDirective,
+	}
+}
+
+func loadMigrations() ([]migration, error) {
+	files, err := fs.Glob(migrationFiles, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*migration)
+	for _, file := range files {
+		base := path.Base(file)
+
+		var direction string
+		switch {
+		case strings.HasSuffix(base, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(base, ".down.sql"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("migration %s must end in .up.sql or .down.sql", base)
+		}
+
+		versionStr, name, ok := strings.Cut(strings.TrimSuffix(base, "."+direction+".sql"), "_")
+		version, err := strconv.Atoi(versionStr)
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s must be named <version>_<name>", base)
+		}
+
+		content, err := migrationFiles.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		script := parseScript(string(content))
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &migration{version: version, name: name}
+			byVersion[version] = m
+		}
+		if m.name != name {
+			return nil, fmt.Errorf("migration version %d is used by %s and %s", version, m.name, name)
+		}
+
+		if direction == "up" {
+			m.up = script
+		} else {
+			m.down = script
+		}
+	}
+
+	migrations := make([]migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up.sql == "" || m.down.sql == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", m.version, m.name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+
+	return migrations, nil
+}
+
+// Migrator applies and reverts the embedded migrations, the applied versions
+// are tracked in the schema_migrations table
+type Migrator struct {
+	pool       *pgxpool.Pool
+	migrations []migration
+}
+
+func NewMigrator(pool *pgxpool.Pool) (*Migrator, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations - %s", err.Error())
+	}
+
+	return &Migrator{pool: pool, migrations: migrations}, nil
+}
+
+// Up applies up to n pending migrations in order, all of them if n <= 0
+func (m *Migrator) Up(ctx context.Context, n int) ([]MigrationStatus, error) {
+	var done []MigrationStatus
+	err := m.withLock(ctx, func(conn *pgxpool.Conn, applied map[int]time.Time) error {
+		for _, mig := range m.migrations {
+			if n > 0 && len(done) == n {
+				break
+			}
+			if _, ok := applied[mig.version]; ok {
+				continue
+			}
+
+			err := run(ctx, conn, mig.up, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, mig.version, mig.name)
+			if err != nil {
+				return fmt.Errorf("failed to apply migration %d_%s - %s", mig.version, mig.name, err.Error())
+			}
+
+			now := time.Now()
+			done = append(done, MigrationStatus{Version: mig.version, Name: mig.name, AppliedAt: &now})
+		}
+		return nil
+	})
+
+	return done, err
+}
+
+// Down reverts the last n applied migrations, newest first
+func (m *Migrator) Down(ctx context.Context, n int) ([]MigrationStatus, error) {
+	var done []MigrationStatus
+	err := m.withLock(ctx, func(conn *pgxpool.Conn, applied map[int]time.Time) error {
+		for i := len(m.migrations) - 1; i >= 0 && len(done) < n; i-- {
+			mig := m.migrations[i]
+			if _, ok := applied[mig.version]; !ok {
+				continue
+			}
+
+			err := run(ctx, conn, mig.down, `DELETE FROM schema_migrations WHERE version = $1`, mig.version)
+			if err != nil {
+				return fmt.Errorf("failed to revert migration %d_%s - %s", mig.version, mig.name, err.Error())
+			}
+
+			done = append(done, MigrationStatus{Version: mig.version, Name: mig.name})
+		}
+		return nil
+	})
+
+	return done, err
+}
+
+// Status lists the known migrations in order
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+	err := m.withLock(ctx, func(conn *pgxpool.Conn, applied map[int]time.Time) error {
+		for _, mig := range m.migrations {
+			status := MigrationStatus{Version: mig.version, Name: mig.name}
+			if t, ok := applied[mig.version]; ok {
+				status.AppliedAt = &t
+			}
+			statuses = append(statuses, status)
+		}
+		return nil
+	})
+
+	return statuses, err
+}
+
+// withLock runs f on a single connection holding the migration lock, with the
+// versions applied so far
+func (m *Migrator) withLock(ctx context.Context, f func(conn *pgxpool.Conn, applied map[int]time.Time) error) error {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, migrationLockKey); err != nil {
+		return fmt.Errorf("failed to acquire the migration lock - %s", err.Error())
+	}
+	defer conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockKey)
+
+	_, err = conn.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version     BIGINT       PRIMARY KEY,
+			name        TEXT         NOT NULL,
+			applied_at  TIMESTAMPTZ  NOT NULL DEFAULT now()
+		);`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations - %s", err.Error())
+	}
+
+	rows, err := conn.Query(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return fmt.Errorf("Unable to query - %s", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return fmt.Errorf("Unable to scan - %s", err)
+		}
+		applied[version] = appliedAt
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	// The connection is busy until the rows are closed
+	rows.Close()
+
+	return f(conn, applied)
+}
+
+// run executes the script and then the bookkeeping statement, in a single
+// transaction unless the script opts out of it
+func run(ctx context.Context, conn *pgxpool.Conn, script migrationScript, record string, args ...any) error {
+	if script.noTransaction {
+		for _, stmt := range splitStatements(script.sql) {
+			if _, err := conn.Exec(ctx, stmt); err != nil {
+				return err
+			}
+		}
+
+		_, err := conn.Exec(ctx, record, args...)
+		return err
+	}
+
+	return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		// Without arguments the whole file is sent at once
+		if _, err := tx.Exec(ctx, script.sql); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(ctx, record, args...)
+		return err
+	})
+}
+
+// splitStatements splits a script on the semicolons outside of quotes,
+// dollar quotes and comments. Pieces without a statement are dropped.
+func splitStatements(sql string) []string {
+	var statements []string
+
+	start := 0
+	hasCode := false
+	for i := 0; i < len(sql); i++ {
+		switch {
+		case strings.HasPrefix(sql[i:], "--"):
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				end = len(sql) - i
+			}
+			i += end
+			continue
+		case strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				end = len(sql) - i - 4
+			}
+			i += end + 3
+			continue
+		case sql[i] == '\'' || sql[i] == '"':
+			end := strings.IndexByte(sql[i+1:], sql[i])
+			if end < 0 {
+				end = len(sql) - i - 1
+			}
+			i += end + 1
+		case sql[i] == '$':
+			// $tag$ ... $tag$, a lone $ is a parameter or part of a name
+			tagEnd := strings.IndexByte(sql[i+1:], '$')
+			if tagEnd >= 0 && isDollarTag(sql[i+1:i+1+tagEnd]) {
+				tag := sql[i : i+tagEnd+2]
+				end := strings.Index(sql[i+len(tag):], tag)
+				if end < 0 {
+					end = len(sql) - i - 2*len(tag)
+				}
+				i += len(tag) + end + len(tag) - 1
+			}
+		case sql[i] == ';':
+			if hasCode {
+				statements = append(statements, strings.TrimSpace(sql[start:i]))
+			}
+			start = i + 1
+			hasCode = false
+			continue
+		case sql[i] == ' ' || sql[i] == '\t' || sql[i] == '\r' || sql[i] == '\n':
+			continue
+		}
+
+		hasCode = true
+	}
+
+	if hasCode {
+		statements = append(statements, strings.TrimSpace(sql[start:]))
+	}
+
+	return statements
+}
+
+func isDollarTag(tag string) bool {
+	for i, r := range tag {
+		if r != '_' && (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (i == 0 || r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// migrateOnStartup applies the pending migrations or stops the server
+func migrateOnStartup() {
+	migrator, err := NewMigrator(DBPool)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	applied, err := migrator.Up(ctx, 0)
+	for _, m := range applied {
+		log.Printf("Applied migration %d_%s", m.Version, m.Name)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(applied) == 0 {
+		log.Println("Database schema is up to date")
+	}
+}
+
+// warnPendingMigrations logs the migrations that still have to be applied
+// with the migrate command
+func warnPendingMigrations() {
+	migrator, err := NewMigrator(DBPool)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		log.Println("Failed to check the migrations - ", err)
+		return
+	}
+
+	var pending []string
+	for _, s := range statuses {
+		if s.AppliedAt == nil {
+			pending = append(pending, fmt.Sprintf("%d_%s", s.Version, s.Name))
+		}
+	}
+	if len(pending) > 0 {
+		log.Printf("%d migrations are pending, run 'migrate up' - %s", len(pending), strings.Join(pending, ", "))
+	}
+}
@@ -0,0 +1,136 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want []string
+	}{
+		{
+			name: "statements",
+			sql:  "CREATE TABLE a (id INT);\nCREATE TABLE b (id INT);\n",
+			want: []string{"CREATE TABLE a (id INT)", "CREATE TABLE b (id INT)"},
+		},
+		{
+			name: "last statement without a semicolon",
+			sql:  "SELECT 1;\nSELECT 2",
+			want: []string{"SELECT 1", "SELECT 2"},
+		},
+		{
+			name: "empty pieces",
+			sql:  ";;\n  ;SELECT 1;;",
+			want: []string{"SELECT 1"},
+		},
+		{
+			name: "semicolon in a string",
+			sql:  "INSERT INTO a VALUES ('x;y');SELECT 1;",
+			want: []string{"INSERT INTO a VALUES ('x;y')", "SELECT 1"},
+		},
+		{
+			name: "escaped quote in a string",
+			sql:  "INSERT INTO a VALUES ('it''s;');SELECT 1;",
+			want: []string{"INSERT INTO a VALUES ('it''s;')", "SELECT 1"},
+		},
+		{
+			name: "semicolon in a quoted identifier",
+			sql:  `SELECT 1 AS "a;b";SELECT 2;`,
+			want: []string{`SELECT 1 AS "a;b"`, "SELECT 2"},
+		},
+		{
+			name: "semicolon in a line comment",
+			sql:  "-- first; second\nSELECT 1;",
+			want: []string{"-- first; second\nSELECT 1"},
+		},
+		{
+			name: "semicolon in a block comment",
+			sql:  "SELECT /* a; b */ 1;SELECT 2;",
+			want: []string{"SELECT /* a; b */ 1", "SELECT 2"},
+		},
+		{
+			name: "trailing comment",
+			sql:  "SELECT 1;\n-- done;\n",
+			want: []string{"SELECT 1"},
+		},
+		{
+			name: "dollar quoted function body",
+			sql:  "CREATE FUNCTION f() RETURNS INT AS $$\nBEGIN\n  PERFORM 1;\n  RETURN 2;\nEND;\n$$ LANGUAGE plpgsql;\nSELECT f();",
+			want: []string{
+				"CREATE FUNCTION f() RETURNS INT AS $$\nBEGIN\n  PERFORM 1;\n  RETURN 2;\nEND;\n$$ LANGUAGE plpgsql",
+				"SELECT f()",
+			},
+		},
+		{
+			name: "tagged dollar quote",
+			sql:  "DO $body$ BEGIN PERFORM '$$;'; END $body$;SELECT 1;",
+			want: []string{"DO $body$ BEGIN PERFORM '$$;'; END $body$", "SELECT 1"},
+		},
+		{
+			name: "parameter is not a dollar quote",
+			sql:  "PREPARE p AS SELECT $1;SELECT 2;",
+			want: []string{"PREPARE p AS SELECT $1", "SELECT 2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitStatements(tt.sql)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitStatements() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseScript(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		noTransaction bool
+	}{
+		{"directive", "-- migrate:no-transaction\nSELECT 1;\n", true},
+		{"directive with CRLF", "-- migrate:no-transaction\r\nSELECT 1;\r\n", true},
+		{"only the directive", "-- migrate:no-transaction", true},
+		{"no directive", "SELECT 1;\n", false},
+		{"directive after the first line", "SELECT 1;\n-- migrate:no-transaction\n", false},
+		{"longer directive", "-- migrate:no-transactions\nSELECT 1;\n", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script := parseScript(tt.content)
+			if script.noTransaction != tt.noTransaction {
+				t.Errorf("noTransaction = %v, want %v", script.noTransaction, tt.noTransaction)
+			}
+			if script.sql != tt.content {
+				t.Errorf("sql = %q, want the whole file", script.sql)
+			}
+		})
+	}
+}
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		t.Fatalf("loadMigrations() error = %v", err)
+	}
+
+	for i, m := range migrations {
+		if m.version != i+1 {
+			t.Fatalf("migration %d_%s, want version %d", m.version, m.name, i+1)
+		}
+
+		// Only the continuous aggregates need to run outside a transaction
+		want := m.name == "rollups"
+		if m.up.noTransaction != want || m.down.noTransaction != want {
+			t.Errorf("migration %d_%s noTransaction = %v/%v, want %v", m.version, m.name, m.up.noTransaction, m.down.noTransaction, want)
+		}
+		if m.up.noTransaction && len(splitStatements(m.up.sql)) == 0 {
+			t.Errorf("migration %d_%s has no statements", m.version, m.name)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+CREATE EXTENSION IF NOT EXISTS postgis;
+
+CREATE TABLE IF NOT EXISTS air_pollution (
+	time        TIMESTAMPTZ       NOT NULL,
+	pollutant   TEXT              NOT NULL,
+	value       DOUBLE PRECISION  NOT NULL,
+	is_anomaly  BOOLEAN           NOT NULL DEFAULT false,
+	latitude    DOUBLE PRECISION  NOT NULL,
+	longitude   DOUBLE PRECISION  NOT NULL,
+	geog        GEOGRAPHY(POINT, 4326) GENERATED ALWAYS AS (
+		ST_SetSRID(ST_MakePoint(longitude, latitude), 4326)
+	) STORED
+);
+
+SELECT create_hypertable('air_pollution', 'time', if_not_exists => TRUE);
+
+-- Tables created before the migrations may miss the later columns
+ALTER TABLE air_pollution ADD COLUMN IF NOT EXISTS anomaly_reasons JSONB;
+
+CREATE INDEX IF NOT EXISTS air_pollution_geog_idx ON air_pollution USING GIST (geog);
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS rejected_readings (
+	id          BIGINT       GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+	received_at TIMESTAMPTZ  NOT NULL DEFAULT now(),
+	payload     TEXT         NOT NULL,
+	errors      JSONB        NOT NULL
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS anomaly_rule_audit;
+DROP TABLE IF EXISTS anomaly_rules;
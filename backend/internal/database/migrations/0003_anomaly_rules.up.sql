@@ -0,0 +1,42 @@
+CREATE TABLE IF NOT EXISTS anomaly_rules (
+	pollutant            TEXT              NOT NULL,
+	region               TEXT              NOT NULL DEFAULT '',
+	boundary             GEOGRAPHY,
+	detectors            TEXT[]            NOT NULL DEFAULT '{zscore}',
+	radius_km            DOUBLE PRECISION  NOT NULL DEFAULT 25,
+	window_minutes       INTEGER           NOT NULL DEFAULT 1440,
+	zscore_threshold     DOUBLE PRECISION  NOT NULL DEFAULT 2,
+	static_threshold     DOUBLE PRECISION,
+	rate_window_minutes  INTEGER           NOT NULL DEFAULT 60,
+	max_increase_percent DOUBLE PRECISION  NOT NULL DEFAULT 200,
+	ewma_window_minutes  INTEGER           NOT NULL DEFAULT 360,
+	ewma_alpha           DOUBLE PRECISION  NOT NULL DEFAULT 0.3,
+	ewma_threshold       DOUBLE PRECISION  NOT NULL DEFAULT 3,
+	seasonal_days        INTEGER           NOT NULL DEFAULT 14,
+	seasonal_threshold   DOUBLE PRECISION  NOT NULL DEFAULT 3,
+	updated_at           TIMESTAMPTZ       NOT NULL DEFAULT now(),
+	updated_by           TEXT              NOT NULL DEFAULT 'system',
+	PRIMARY KEY (pollutant, region)
+);
+
+-- Seed the rules that used to be hard-coded in the ingest service, a z-score
+-- above 2 within 25 km over the last 24 hours or a value at or above the
+-- fixed threshold. The other detectors are enabled per rule through the API.
+INSERT INTO anomaly_rules (pollutant, detectors, static_threshold) VALUES
+	('PM2.5', '{zscore,threshold}', 150),
+	('PM10',  '{zscore,threshold}', 180),
+	('NO2',   '{zscore,threshold}', 150),
+	('SO2',   '{zscore,threshold}', 100),
+	('O3',    '{zscore,threshold}', 200)
+ON CONFLICT (pollutant, region) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS anomaly_rule_audit (
+	id          BIGINT       GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+	changed_at  TIMESTAMPTZ  NOT NULL DEFAULT now(),
+	changed_by  TEXT         NOT NULL,
+	action      TEXT         NOT NULL,
+	pollutant   TEXT         NOT NULL,
+	region      TEXT         NOT NULL,
+	old_rule    JSONB,
+	new_rule    JSONB
+);
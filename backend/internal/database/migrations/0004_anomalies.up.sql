@@ -0,0 +1,30 @@
+-- One row per detector that flagged a reading, linked to the reading in
+-- air_pollution by time, pollutant and position
+CREATE TABLE IF NOT EXISTS anomalies (
+	id               BIGINT            GENERATED ALWAYS AS IDENTITY,
+	time             TIMESTAMPTZ       NOT NULL,
+	pollutant        TEXT              NOT NULL,
+	latitude         DOUBLE PRECISION  NOT NULL,
+	longitude        DOUBLE PRECISION  NOT NULL,
+	value            DOUBLE PRECISION  NOT NULL,
+	detector         TEXT              NOT NULL,
+	score            DOUBLE PRECISION  NOT NULL,
+	baseline_mean    DOUBLE PRECISION  NOT NULL DEFAULT 0,
+	baseline_stddev  DOUBLE PRECISION  NOT NULL DEFAULT 0,
+	threshold        DOUBLE PRECISION  NOT NULL,
+	severity         TEXT              NOT NULL CHECK (severity IN ('low', 'medium', 'high', 'critical')),
+	reason           TEXT              NOT NULL,
+	status           TEXT              NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'acknowledged', 'resolved')),
+	detected_at      TIMESTAMPTZ       NOT NULL DEFAULT now(),
+	acknowledged_at  TIMESTAMPTZ,
+	acknowledged_by  TEXT,
+	resolved_at      TIMESTAMPTZ,
+	resolved_by      TEXT,
+	note             TEXT,
+	PRIMARY KEY (id, time)
+);
+
+SELECT create_hypertable('anomalies', 'time', if_not_exists => TRUE);
+
+CREATE INDEX IF NOT EXISTS anomalies_status_time_idx ON anomalies (status, time DESC);
+CREATE INDEX IF NOT EXISTS anomalies_id_idx ON anomalies (id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS air_pollution_station_time_idx;
+ALTER TABLE air_pollution DROP COLUMN IF EXISTS station_id;
+DROP TABLE IF EXISTS stations;
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS stations (
+	id                  TEXT              PRIMARY KEY,
+	name                TEXT              NOT NULL,
+	latitude            DOUBLE PRECISION  NOT NULL,
+	longitude           DOUBLE PRECISION  NOT NULL,
+	geog                GEOGRAPHY(POINT, 4326) GENERATED ALWAYS AS (
+		ST_SetSRID(ST_MakePoint(longitude, latitude), 4326)
+	) STORED,
+	owner               TEXT              NOT NULL DEFAULT '',
+	pollutants          TEXT[]            NOT NULL,
+	active              BOOLEAN           NOT NULL DEFAULT true,
+	last_calibrated_at  TIMESTAMPTZ,
+	calibration_due_at  TIMESTAMPTZ,
+	calibration_notes   TEXT              NOT NULL DEFAULT '',
+	created_at          TIMESTAMPTZ       NOT NULL DEFAULT now(),
+	updated_at          TIMESTAMPTZ       NOT NULL DEFAULT now()
+);
+
+-- Readings of registered stations, NULL for readings sent with bare coordinates
+ALTER TABLE air_pollution ADD COLUMN IF NOT EXISTS station_id TEXT;
+
+CREATE INDEX IF NOT EXISTS air_pollution_station_time_idx ON air_pollution (station_id, time DESC) WHERE station_id IS NOT NULL;
@@ -0,0 +1,12 @@
+-- Saved areas that can be queried by id instead of sending their boundary
+CREATE TABLE IF NOT EXISTS regions (
+	id          BIGINT       GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+	name        TEXT         NOT NULL UNIQUE,
+	boundary    GEOGRAPHY    NOT NULL,
+	created_at  TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+ALTER TABLE regions ADD COLUMN IF NOT EXISTS description TEXT NOT NULL DEFAULT '';
+ALTER TABLE regions ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+CREATE INDEX IF NOT EXISTS regions_boundary_idx ON regions USING GIST (boundary);
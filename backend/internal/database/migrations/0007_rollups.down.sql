@@ -0,0 +1,4 @@
+-- migrate:no-transaction
+DROP MATERIALIZED VIEW IF EXISTS air_pollution_1d;
+DROP MATERIALIZED VIEW IF EXISTS air_pollution_1h;
+DROP MATERIALIZED VIEW IF EXISTS air_pollution_1m;
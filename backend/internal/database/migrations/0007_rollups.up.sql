@@ -0,0 +1,73 @@
+-- migrate:no-transaction
+-- Continuous aggregates of air_pollution per pollutant and 0.01 degree cell,
+-- the cells are identified by their center. The hourly and daily rollups are
+-- built on the minutely one. Real time aggregation is enabled so the rollups
+-- include the readings that are not materialized yet.
+CREATE MATERIALIZED VIEW IF NOT EXISTS air_pollution_1m
+WITH (timescaledb.continuous, timescaledb.materialized_only = false) AS
+SELECT time_bucket(INTERVAL '1 minute', time) AS bucket,
+	pollutant,
+	(floor(latitude * 100) + 0.5) / 100 AS latitude,
+	(floor(longitude * 100) + 0.5) / 100 AS longitude,
+	AVG(value) AS avg_value,
+	MIN(value) AS min_value,
+	MAX(value) AS max_value,
+	SUM(value) AS sum_value,
+	SUM(value * value) AS sum_squares,
+	COUNT(*) AS count
+FROM air_pollution
+GROUP BY time_bucket(INTERVAL '1 minute', time), pollutant,
+	(floor(latitude * 100) + 0.5) / 100, (floor(longitude * 100) + 0.5) / 100
+WITH DATA;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS air_pollution_1h
+WITH (timescaledb.continuous, timescaledb.materialized_only = false) AS
+SELECT time_bucket(INTERVAL '1 hour', bucket) AS bucket,
+	pollutant,
+	latitude,
+	longitude,
+	SUM(sum_value) / SUM(count) AS avg_value,
+	MIN(min_value) AS min_value,
+	MAX(max_value) AS max_value,
+	SUM(sum_value) AS sum_value,
+	SUM(sum_squares) AS sum_squares,
+	SUM(count)::bigint AS count
+FROM air_pollution_1m
+GROUP BY time_bucket(INTERVAL '1 hour', bucket), pollutant, latitude, longitude
+WITH DATA;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS air_pollution_1d
+WITH (timescaledb.continuous, timescaledb.materialized_only = false) AS
+SELECT time_bucket(INTERVAL '1 day', bucket) AS bucket,
+	pollutant,
+	latitude,
+	longitude,
+	SUM(sum_value) / SUM(count) AS avg_value,
+	MIN(min_value) AS min_value,
+	MAX(max_value) AS max_value,
+	SUM(sum_value) AS sum_value,
+	SUM(sum_squares) AS sum_squares,
+	SUM(count)::bigint AS count
+FROM air_pollution_1h
+GROUP BY time_bucket(INTERVAL '1 day', bucket), pollutant, latitude, longitude
+WITH DATA;
+
+-- The refresh windows cover the readings accepted by the API, which may be up
+-- to 7 days old. Older imports refresh the rollups themselves.
+SELECT add_continuous_aggregate_policy('air_pollution_1m',
+	start_offset => INTERVAL '8 days',
+	end_offset => INTERVAL '1 minute',
+	schedule_interval => INTERVAL '1 minute',
+	if_not_exists => TRUE);
+
+SELECT add_continuous_aggregate_policy('air_pollution_1h',
+	start_offset => INTERVAL '8 days',
+	end_offset => INTERVAL '1 hour',
+	schedule_interval => INTERVAL '30 minutes',
+	if_not_exists => TRUE);
+
+SELECT add_continuous_aggregate_policy('air_pollution_1d',
+	start_offset => INTERVAL '9 days',
+	end_offset => INTERVAL '1 day',
+	schedule_interval => INTERVAL '1 hour',
+	if_not_exists => TRUE);
@@ -0,0 +1,5 @@
+SELECT remove_compression_policy('air_pollution', if_exists => TRUE);
+
+SELECT decompress_chunk(c, if_compressed => TRUE) FROM show_chunks('air_pollution') c;
+
+ALTER TABLE air_pollution SET (timescaledb.compress = false);
@@ -0,0 +1,7 @@
+-- Compressed chunks are segmented by pollutant, the queries filter on it. The
+-- compression policy itself is configured on startup.
+ALTER TABLE air_pollution SET (
+	timescaledb.compress,
+	timescaledb.compress_segmentby = 'pollutant',
+	timescaledb.compress_orderby = 'time DESC, latitude, longitude'
+);
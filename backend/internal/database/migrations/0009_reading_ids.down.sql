@@ -0,0 +1,14 @@
+SELECT remove_compression_policy('air_pollution', if_exists => TRUE);
+
+SELECT decompress_chunk(c, if_compressed => TRUE) FROM show_chunks('air_pollution') c;
+
+ALTER TABLE air_pollution SET (timescaledb.compress = false);
+
+-- The sequence is owned by the column and dropped with it
+ALTER TABLE air_pollution DROP COLUMN IF EXISTS id;
+
+ALTER TABLE air_pollution SET (
+	timescaledb.compress,
+	timescaledb.compress_segmentby = 'pollutant',
+	timescaledb.compress_orderby = 'time DESC, latitude, longitude'
+);
@@ -0,0 +1,19 @@
+-- Readings get a unique id, it breaks ties between readings of the same time
+-- when paginating. A column with a generated default can not be added while
+-- compression is enabled, so the compressed chunks are decompressed first.
+-- The compression policy is added again on startup.
+SELECT remove_compression_policy('air_pollution', if_exists => TRUE);
+
+SELECT decompress_chunk(c, if_compressed => TRUE) FROM show_chunks('air_pollution') c;
+
+ALTER TABLE air_pollution SET (timescaledb.compress = false);
+
+CREATE SEQUENCE IF NOT EXISTS air_pollution_id_seq AS BIGINT;
+ALTER TABLE air_pollution ADD COLUMN IF NOT EXISTS id BIGINT NOT NULL DEFAULT nextval('air_pollution_id_seq');
+ALTER SEQUENCE air_pollution_id_seq OWNED BY air_pollution.id;
+
+ALTER TABLE air_pollution SET (
+	timescaledb.compress,
+	timescaledb.compress_segmentby = 'pollutant',
+	timescaledb.compress_orderby = 'time DESC, latitude, longitude'
+);
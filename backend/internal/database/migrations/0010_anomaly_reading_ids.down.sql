@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS anomalies_reading_id_idx;
+
+ALTER TABLE anomalies DROP COLUMN IF EXISTS reading_id;
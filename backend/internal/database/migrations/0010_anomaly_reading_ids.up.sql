@@ -0,0 +1,20 @@
+-- Anomalies refer to their reading by its id, time, pollutant and position do
+-- not identify a reading. air_pollution is a hypertable without a unique id,
+-- so the reference is not a foreign key, the inserts take the id from the
+-- sequence of air_pollution.
+ALTER TABLE anomalies ADD COLUMN IF NOT EXISTS reading_id BIGINT;
+
+-- Link the stored anomalies where the old key matches a single reading
+UPDATE anomalies a SET reading_id = r.id
+FROM air_pollution r
+WHERE a.reading_id IS NULL
+  AND r.time = a.time AND r.pollutant = a.pollutant
+  AND r.latitude = a.latitude AND r.longitude = a.longitude
+  AND NOT EXISTS (
+      SELECT FROM air_pollution o
+      WHERE o.time = a.time AND o.pollutant = a.pollutant
+        AND o.latitude = a.latitude AND o.longitude = a.longitude
+        AND o.id <> r.id
+  );
+
+CREATE INDEX IF NOT EXISTS anomalies_reading_id_idx ON anomalies (reading_id, time DESC);
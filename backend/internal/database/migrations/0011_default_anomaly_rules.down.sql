@@ -0,0 +1 @@
+ALTER TABLE anomaly_rules ALTER COLUMN detectors SET DEFAULT '{zscore,rate_of_change}';
@@ -0,0 +1,14 @@
+-- The seeded rules enabled detectors that were not used before the rules were
+-- stored. Rules that were not changed since the seed go back to the z-score
+-- and threshold detectors, and new rules only use the z-score detector.
+ALTER TABLE anomaly_rules ALTER COLUMN detectors SET DEFAULT '{zscore}';
+
+UPDATE anomaly_rules SET detectors = '{zscore,threshold}'
+WHERE region = '' AND updated_by = 'system'
+  AND (pollutant, detectors) IN (
+	('PM2.5', '{zscore,rate_of_change,ewma,threshold}'),
+	('PM10',  '{zscore,rate_of_change,ewma,threshold}'),
+	('NO2',   '{zscore,rate_of_change,seasonal,threshold}'),
+	('SO2',   '{zscore,rate_of_change,threshold}'),
+	('O3',    '{zscore,rate_of_change,seasonal,threshold}')
+  );
@@ -0,0 +1,130 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/AkifSahn/pollution-tracker/config"
+)
+
+// minRetention is the shortest retention of the raw readings and the minutely
+// rollup. The rollups built on them refresh the last 8 days, a refresh over a
+// dropped range would delete the rollup rows of it.
+const minRetention = 10 * 24 * time.Hour
+
+// effectiveRetention is the retention a policy is applied with, zero keeps
+// the data forever
+func effectiveRetention(after time.Duration) time.Duration {
+	if after > 0 && after < minRetention {
+		return minRetention
+	}
+	return after
+}
+
+// RetentionCutoff is the oldest time readings can be backfilled at. Older raw
+// chunks may have been dropped, refreshing the minutely rollup over them would
+// rebuild it from the new readings only. Likewise the hourly rollup is rebuilt
+// from the minutely one, so whole hours of it must still be kept. A zero time
+// means there is no limit.
+func RetentionCutoff(cfg *config.Config, now time.Time) time.Time {
+	var cutoff time.Time
+	for _, after := range []time.Duration{cfg.RawRetention, cfg.MinuteRollupRetention} {
+		if after = effectiveRetention(after); after > 0 && now.Add(-after).After(cutoff) {
+			cutoff = now.Add(-after)
+		}
+	}
+	if cutoff.IsZero() {
+		return cutoff
+	}
+
+	// The refreshes cover whole hours
+	return cutoff.Truncate(time.Hour).Add(time.Hour)
+}
+
+// storagePolicy is a TimescaleDB compression or retention job of a hypertable
+// or continuous aggregate
+type storagePolicy struct {
+	relation string
+	kind     string
+	// after is the age of the chunks the policy applies to, zero removes it
+	after time.Duration
+}
+
+const (
+	policyCompression = "compression"
+	policyRetention   = "retention"
+)
+
+// setting is the config key of the interval in the job of the policy
+func (p storagePolicy) setting() string {
+	if p.kind == policyCompression {
+		return "compress_after"
+	}
+	return "drop_after"
+}
+
+// applyStoragePolicies brings the policy jobs in line with the config. A job
+// is only replaced when its interval changed, so restarts keep its schedule.
+func applyStoragePolicies(cfg *config.Config) {
+	policies := []storagePolicy{
+		{relation: "air_pollution", kind: policyCompression, after: cfg.CompressAfter},
+		{relation: "air_pollution", kind: policyRetention, after: cfg.RawRetention},
+		{relation: "air_pollution_1m", kind: policyRetention, after: cfg.MinuteRollupRetention},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	for _, p := range policies {
+		if p.kind == policyRetention && p.after != effectiveRetention(p.after) {
+			log.Printf("Retention of %s raised from %s to %s to keep the rollups intact", p.relation, p.after, minRetention)
+			p.after = minRetention
+		}
+
+		if err := p.apply(ctx); err != nil {
+			log.Printf("Failed to apply the %s policy of %s - %s", p.kind, p.relation, err.Error())
+		}
+	}
+}
+
+func (p storagePolicy) apply(ctx context.Context) error {
+	// Jobs of continuous aggregates belong to their materialization hypertable
+	var exists, same bool
+	err := DBPool.QueryRow(ctx, `
+        SELECT count(*) > 0, COALESCE(bool_and((j.config->>$3)::interval = $4::interval), false)
+        FROM timescaledb_information.jobs j
+        LEFT JOIN timescaledb_information.continuous_aggregates ca
+            ON ca.materialization_hypertable_schema = j.hypertable_schema
+           AND ca.materialization_hypertable_name = j.hypertable_name
+        WHERE j.proc_name = $1 AND COALESCE(ca.view_name, j.hypertable_name) = $2
+    `, "policy_"+p.kind, p.relation, p.setting(), p.after).Scan(&exists, &same)
+	if err != nil {
+		return fmt.Errorf("Unable to query - %s", err.Error())
+	}
+
+	if (exists && same) || (!exists && p.after == 0) {
+		return nil
+	}
+
+	if exists {
+		_, err := DBPool.Exec(ctx, fmt.Sprintf(`SELECT remove_%s_policy($1::text::regclass, if_exists => true)`, p.kind), p.relation)
+		if err != nil {
+			return err
+		}
+		log.Printf("Removed the %s policy of %s", p.kind, p.relation)
+	}
+
+	if p.after == 0 {
+		return nil
+	}
+
+	_, err = DBPool.Exec(ctx, fmt.Sprintf(`SELECT add_%s_policy($1::text::regclass, %s => $2::interval)`, p.kind, p.setting()), p.relation, p.after)
+	if err != nil {
+		return err
+	}
+	log.Printf("Added the %s policy of %s after %s", p.kind, p.relation, p.after)
+
+	return nil
+}
@@ -0,0 +1,181 @@
+package deadletter
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func SetupRoutes(app *fiber.App) {
+
+	admin := app.Group("/api/admin")
+
+	admin.Get("dead-letters", GetDeadLetters)
+	admin.Delete("dead-letters", PurgeDeadLetters)
+	admin.Post("dead-letters/replay", ReplayAllDeadLetters)
+
+	admin.Get("dead-letters/:id", GetDeadLetter)
+	admin.Delete("dead-letters/:id", DeleteDeadLetter)
+	admin.Post("dead-letters/:id/replay", ReplayDeadLetter)
+}
+
+// GetDeadLetters
+//
+//	@Summary		Lists dead letters
+//	@Description	Lists the ingest messages that failed after all retries, without removing them from the queue
+//	@Tags			admin
+//	@Produce		json
+//
+//	@Param			limit	query		int							false	"Maximum number of messages to return"	default(100)
+//
+//	@Failure		500		{object}	map[string]string			"Failed to read the dead-letter queue"
+//	@Success		200		{object}	map[string][]DeadLetter		"Dead letters"
+//	@Router			/api/admin/dead-letters [get]
+func GetDeadLetters(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", 100)
+	if limit <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Limit must be positive!",
+		})
+	}
+
+	deadLetters, err := List(limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to read the dead-letter queue: " + err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"data": deadLetters,
+	})
+}
+
+// GetDeadLetter
+//
+//	@Summary		Gets a dead letter
+//	@Description	Gets a single dead-lettered message by its message id
+//	@Tags			admin
+//	@Produce		json
+//
+//	@Param			id	path		string					true	"Message id"
+//
+//	@Failure		404	{object}	map[string]string		"Dead letter not found"
+//	@Failure		500	{object}	map[string]string		"Failed to read the dead-letter queue"
+//	@Success		200	{object}	map[string]DeadLetter	"Dead letter"
+//	@Router			/api/admin/dead-letters/{id} [get]
+func GetDeadLetter(c *fiber.Ctx) error {
+	deadLetter, err := Get(c.Params("id"))
+	if err != nil {
+		return errorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"data": deadLetter,
+	})
+}
+
+// ReplayDeadLetter
+//
+//	@Summary		Replays a dead letter
+//	@Description	Publishes a dead-lettered message back to the ingest queue with its retry count reset
+//	@Tags			admin
+//	@Produce		json
+//
+//	@Param			id	path		string				true	"Message id"
+//
+//	@Failure		404	{object}	map[string]string	"Dead letter not found"
+//	@Failure		500	{object}	map[string]string	"Failed to replay the dead letter"
+//	@Success		200	{object}	map[string]string	"Dead letter replayed"
+//	@Router			/api/admin/dead-letters/{id}/replay [post]
+func ReplayDeadLetter(c *fiber.Ctx) error {
+	if err := Replay(c.Params("id")); err != nil {
+		return errorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Dead letter replayed",
+	})
+}
+
+// ReplayAllDeadLetters
+//
+//	@Summary		Replays all dead letters
+//	@Description	Publishes every dead-lettered message back to the ingest queue
+//	@Tags			admin
+//	@Produce		json
+//
+//	@Failure		500	{object}	map[string]string	"Failed to replay the dead letters"
+//	@Success		200	{object}	map[string]int		"Number of replayed messages"
+//	@Router			/api/admin/dead-letters/replay [post]
+func ReplayAllDeadLetters(c *fiber.Ctx) error {
+	count, err := ReplayAll()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":    "Failed to replay the dead letters: " + err.Error(),
+			"replayed": count,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"replayed": count,
+	})
+}
+
+// DeleteDeadLetter
+//
+//	@Summary		Deletes a dead letter
+//	@Description	Removes a single message from the dead-letter queue
+//	@Tags			admin
+//	@Produce		json
+//
+//	@Param			id	path		string				true	"Message id"
+//
+//	@Failure		404	{object}	map[string]string	"Dead letter not found"
+//	@Failure		500	{object}	map[string]string	"Failed to delete the dead letter"
+//	@Success		200	{object}	map[string]string	"Dead letter deleted"
+//	@Router			/api/admin/dead-letters/{id} [delete]
+func DeleteDeadLetter(c *fiber.Ctx) error {
+	if err := Delete(c.Params("id")); err != nil {
+		return errorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Dead letter deleted",
+	})
+}
+
+// PurgeDeadLetters
+//
+//	@Summary		Purges dead letters
+//	@Description	Removes every message from the dead-letter queue
+//	@Tags			admin
+//	@Produce		json
+//
+//	@Failure		500	{object}	map[string]string	"Failed to purge the dead-letter queue"
+//	@Success		200	{object}	map[string]int		"Number of purged messages"
+//	@Router			/api/admin/dead-letters [delete]
+func PurgeDeadLetters(c *fiber.Ctx) error {
+	count, err := Purge()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to purge the dead-letter queue: " + err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"purged": count,
+	})
+}
+
+func errorResponse(c *fiber.Ctx, err error) error {
+	if errors.Is(err, ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Dead letter not found",
+		})
+	}
+
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": "Failed to access the dead-letter queue: " + err.Error(),
+	})
+}
@@ -0,0 +1,15 @@
+package deadletter
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type DeadLetter struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type,omitempty"`
+	RetryCount int             `json:"retry_count"`
+	LastError  string          `json:"last_error,omitempty"`
+	FailedAt   *time.Time      `json:"failed_at,omitempty"`
+	Body       json.RawMessage `json:"body" swaggertype:"object"`
+}
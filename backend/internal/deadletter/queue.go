@@ -0,0 +1,196 @@
+package deadletter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/AkifSahn/pollution-tracker/internal/rabbitmq"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// maxScan bounds how many messages a single operation reads from the queue
+const maxScan = 10000
+
+var ErrNotFound = errors.New("dead letter not found")
+
+// Every operation runs on its own channel. Messages are fetched without
+// acknowledging them, so whatever is not explicitly acked is put back into the
+// queue when the channel is closed.
+func withChannel(fn func(ch *amqp.Channel) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return rabbitmq.WithChannel(ctx, fn)
+}
+
+// scan fetches messages from the dead-letter queue one by one and calls visit
+// for each of them until visit returns false or the queue is exhausted.
+func scan(ch *amqp.Channel, visit func(d amqp.Delivery) (bool, error)) error {
+	for i := 0; i < maxScan; i++ {
+		d, ok, err := ch.Get(rabbitmq.DeadLetterQueue, false)
+		if err != nil {
+			return fmt.Errorf("Failed to get a message - %s", err.Error())
+		}
+		if !ok {
+			return nil
+		}
+
+		more, err := visit(d)
+		if err != nil || !more {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func toDeadLetter(d amqp.Delivery) DeadLetter {
+	dl := DeadLetter{
+		ID:         d.MessageId,
+		Type:       d.Type,
+		RetryCount: rabbitmq.RetryCount(d.Headers),
+	}
+
+	if s, ok := d.Headers[rabbitmq.LastErrorHeader].(string); ok {
+		dl.LastError = s
+	}
+
+	if s, ok := d.Headers[rabbitmq.FailedAtHeader].(string); ok {
+		if t, err := time.Parse(time.RFC3339, s); err == nil {
+			dl.FailedAt = &t
+		}
+	}
+
+	if json.Valid(d.Body) {
+		dl.Body = d.Body
+	} else {
+		// Keep the response valid JSON even if the payload is not
+		dl.Body, _ = json.Marshal(string(d.Body))
+	}
+
+	return dl
+}
+
+func List(limit int) ([]DeadLetter, error) {
+	var result []DeadLetter
+	err := withChannel(func(ch *amqp.Channel) error {
+		return scan(ch, func(d amqp.Delivery) (bool, error) {
+			result = append(result, toDeadLetter(d))
+			return len(result) < limit, nil
+		})
+	})
+
+	return result, err
+}
+
+func Get(id string) (DeadLetter, error) {
+	var result DeadLetter
+	found := false
+	err := withChannel(func(ch *amqp.Channel) error {
+		return scan(ch, func(d amqp.Delivery) (bool, error) {
+			if d.MessageId != id {
+				return true, nil
+			}
+			result = toDeadLetter(d)
+			found = true
+			return false, nil
+		})
+	})
+
+	if err == nil && !found {
+		err = ErrNotFound
+	}
+
+	return result, err
+}
+
+// Replay publishes the message back to the ingest queue with its retry count
+// reset and removes it from the dead-letter queue.
+func Replay(id string) error {
+	found := false
+	err := withChannel(func(ch *amqp.Channel) error {
+		return scan(ch, func(d amqp.Delivery) (bool, error) {
+			if d.MessageId != id {
+				return true, nil
+			}
+			found = true
+			return false, replay(ch, d)
+		})
+	})
+
+	if err == nil && !found {
+		err = ErrNotFound
+	}
+
+	return err
+}
+
+// ReplayAll replays every message in the dead-letter queue and returns how many were replayed.
+func ReplayAll() (int, error) {
+	count := 0
+	err := withChannel(func(ch *amqp.Channel) error {
+		return scan(ch, func(d amqp.Delivery) (bool, error) {
+			if err := replay(ch, d); err != nil {
+				return false, err
+			}
+			count++
+			return true, nil
+		})
+	})
+
+	return count, err
+}
+
+func replay(ch *amqp.Channel, d amqp.Delivery) error {
+	err := rabbitmq.PublishOn(context.Background(), ch, "", "ingest_queue",
+		amqp.Publishing{
+			ContentType: d.ContentType,
+			Type:        d.Type,
+			MessageId:   d.MessageId,
+			Timestamp:   d.Timestamp,
+			Body:        d.Body,
+		})
+	if err != nil {
+		return fmt.Errorf("Failed to publish the message - %s", err.Error())
+	}
+
+	if err = d.Ack(false); err != nil {
+		return fmt.Errorf("Failed to ack the message - %s", err.Error())
+	}
+
+	return nil
+}
+
+func Delete(id string) error {
+	found := false
+	err := withChannel(func(ch *amqp.Channel) error {
+		return scan(ch, func(d amqp.Delivery) (bool, error) {
+			if d.MessageId != id {
+				return true, nil
+			}
+			found = true
+			return false, d.Ack(false)
+		})
+	})
+
+	if err == nil && !found {
+		err = ErrNotFound
+	}
+
+	return err
+}
+
+// Purge removes every message from the dead-letter queue and returns how many were removed.
+func Purge() (int, error) {
+	count := 0
+	err := withChannel(func(ch *amqp.Channel) error {
+		var err error
+		count, err = ch.QueuePurge(rabbitmq.DeadLetterQueue, false)
+		return err
+	})
+
+	return count, err
+}
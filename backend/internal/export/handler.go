@@ -0,0 +1,174 @@
+package export
+
+import (
+	"bufio"
+	"context"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AkifSahn/pollution-tracker/config"
+	"github.com/AkifSahn/pollution-tracker/internal/database"
+	"github.com/AkifSahn/pollution-tracker/internal/pollution"
+	"github.com/gofiber/fiber/v2"
+)
+
+// flushEvery is the number of rows after which the buffered response is sent
+// to the client
+const flushEvery = 1000
+
+var timeout time.Duration
+
+func SetupRoutes(app *fiber.App, cfg *config.Config) {
+	timeout = cfg.ExportTimeout
+
+	api := app.Group("/api")
+
+	api.Get("export", GetExport)
+}
+
+// GetExport
+//
+//	@Summary		Exports readings
+//	@Description	Streams the readings matching the filters as CSV, NDJSON or Parquet, oldest first. Rows are read from
+//	@Description	a database cursor and written as they arrive, so exports of any size use constant memory.
+//	@Description	If the export fails after streaming started the response is cut short.
+//	@Tags			export
+//	@Produce		text/csv
+//	@Produce		application/x-ndjson
+//	@Produce		application/vnd.apache.parquet
+//
+//	@Param			format		query		string				false	"csv, ndjson or parquet"	default(csv)
+//	@Param			from		query		string				false	"Start time, defaults to 24 hours ago"
+//	@Param			to			query		string				false	"End time, defaults to now"
+//	@Param			tz			query		string				false	"IANA time zone of times without an offset and of the exported times"	default(UTC)
+//	@Param			pollutant	query		string				false	"Comma separated pollutants"
+//	@Param			region		query		int					false	"Id of a saved region the readings must lie in"
+//	@Param			station		query		string				false	"Station id"
+//	@Param			unit		query		string				false	"Unit to export the values in: µg/m³, mg/m³, ppb, ppm"
+//
+//	@Failure		400			{object}	map[string]string	"Invalid params"
+//	@Failure		404			{object}	map[string]string	"Region not found"
+//	@Failure		500			{object}	map[string]string	"Failed to fetch region from database"
+//	@Success		200			{file}		file				"Readings"
+//	@Router			/api/export [get]
+func GetExport(c *fiber.Ctx) error {
+	format := c.Query("format", FormatCSV)
+	if !slices.Contains(Formats, format) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Incorrect format, expected one of " + strings.Join(Formats, ", "),
+		})
+	}
+
+	fromStr := c.Query("from", "now-24h")
+	toStr := c.Query("to", "now")
+
+	loc, msg := pollution.ParseTimeZone(c.Query("tz"))
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	var filter Filter
+	ok, msg := pollution.ParseTimeRange(fromStr, toStr, loc, 0, &filter.From, &filter.To)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	for _, p := range strings.Split(c.Query("pollutant"), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			filter.Pollutants = append(filter.Pollutants, p)
+		}
+	}
+	filter.StationID = c.Query("station")
+
+	// A single pollutant is checked against the unit, like the other endpoints
+	var pollutant string
+	if len(filter.Pollutants) == 1 {
+		pollutant = filter.Pollutants[0]
+	}
+	unit, msg := pollution.ParseUnitParam(c.Query("unit"), pollutant)
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	if s := c.Query("region"); s != "" {
+		id, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Incorrect region id!",
+			})
+		}
+
+		repo := pollution.NewPollutionRepo(database.DBPool)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		boundary, found, err := repo.GetRegionBoundary(ctx, id)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to fetch region from database: " + err.Error(),
+			})
+		}
+		if !found {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Region not found",
+			})
+		}
+		filter.Area = boundary
+	}
+
+	c.Set(fiber.HeaderContentType, ContentType(format))
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="readings.`+format+`"`)
+
+	// The stream writer runs after the handler returned, once the headers are sent
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		if err := stream(ctx, w, format, filter, unit, loc); err != nil {
+			log.Println("Export failed - ", err)
+		}
+	})
+
+	return nil
+}
+
+// stream writes the readings of the filter to w in the format, with the times
+// in loc
+func stream(ctx context.Context, w *bufio.Writer, format string, filter Filter, unit string, loc *time.Location) error {
+	rw := newRowWriter(format, w)
+
+	var count int
+	repo := NewExportRepo(database.DBPool)
+	err := repo.StreamReadings(ctx, filter, func(r Reading) error {
+		r.Value, r.Unit = pollution.ConvertValue(r.Pollutant, r.Value, unit)
+		r.Time = r.Time.In(loc)
+		if err := rw.Write(r); err != nil {
+			return err
+		}
+
+		// A failing flush means the client went away
+		count++
+		if count%flushEvery == 0 {
+			return w.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := rw.Close(); err != nil {
+		return err
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,32 @@
+package export
+
+import "time"
+
+const (
+	FormatCSV     = "csv"
+	FormatNDJSON  = "ndjson"
+	FormatParquet = "parquet"
+)
+
+var Formats = []string{FormatCSV, FormatNDJSON, FormatParquet}
+
+// Reading is a row of an export
+type Reading struct {
+	Time      time.Time `json:"time" parquet:"time,timestamp(millisecond)"`
+	Pollutant string    `json:"pollutant" parquet:"pollutant,dict"`
+	Value     float64   `json:"value" parquet:"value"`
+	Unit      string    `json:"unit" parquet:"unit,dict"`
+	Latitude  float64   `json:"latitude" parquet:"latitude"`
+	Longitude float64   `json:"longitude" parquet:"longitude"`
+	StationID string    `json:"station_id,omitempty" parquet:"station_id,optional"`
+	IsAnomaly bool      `json:"is_anomaly" parquet:"is_anomaly"`
+}
+
+// Filter selects the readings of an export, empty fields do not filter
+type Filter struct {
+	From, To   time.Time
+	Pollutants []string
+	// Area is a GeoJSON Polygon or MultiPolygon the readings must lie in
+	Area      string
+	StationID string
+}
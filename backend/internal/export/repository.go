@@ -0,0 +1,100 @@
+package export
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// fetchSize is the number of rows fetched from the cursor at a time
+const fetchSize = 5000
+
+type ExportRepo interface {
+	StreamReadings(ctx context.Context, filter Filter, fn func(Reading) error) error
+}
+
+type ExportRepoImpl struct {
+	DB *pgxpool.Pool
+}
+
+func NewExportRepo(db *pgxpool.Pool) *ExportRepoImpl {
+	return &ExportRepoImpl{
+		DB: db,
+	}
+}
+
+// StreamReadings calls fn for every reading matching the filter, oldest
+// first. Rows are read through a server side cursor in batches of fetchSize,
+// so only a batch is held in memory at a time. It stops at the first error
+// returned by fn.
+func (repo *ExportRepoImpl) StreamReadings(ctx context.Context, filter Filter, fn func(Reading) error) error {
+	query := `
+    DECLARE export_cursor NO SCROLL CURSOR FOR
+    SELECT time, pollutant, value, latitude, longitude, COALESCE(station_id, ''), is_anomaly
+    FROM air_pollution
+    WHERE time BETWEEN $1 AND $2
+    `
+	var args []interface{}
+	args = append(args, filter.From, filter.To)
+
+	if len(filter.Pollutants) > 0 {
+		args = append(args, filter.Pollutants)
+		query += fmt.Sprintf(" AND pollutant = ANY($%d)", len(args))
+	}
+	if filter.StationID != "" {
+		args = append(args, filter.StationID)
+		query += fmt.Sprintf(" AND station_id = $%d", len(args))
+	}
+	if filter.Area != "" {
+		args = append(args, filter.Area)
+		query += fmt.Sprintf(" AND ST_Covers(ST_GeomFromGeoJSON($%d::text)::geography, geog)", len(args))
+	}
+	query += " ORDER BY time"
+
+	// Cursors only live inside a transaction
+	tx, err := repo.DB.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return fmt.Errorf("Unable to begin transaction - %s", err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("Unable to declare cursor - %s", err.Error())
+	}
+
+	fetch := fmt.Sprintf("FETCH FORWARD %d FROM export_cursor;", fetchSize)
+	for {
+		rows, err := tx.Query(ctx, fetch)
+		if err != nil {
+			return fmt.Errorf("Unable to query - %s", err.Error())
+		}
+
+		var count int
+		for rows.Next() {
+			var r Reading
+			err := rows.Scan(&r.Time, &r.Pollutant, &r.Value, &r.Latitude, &r.Longitude, &r.StationID, &r.IsAnomaly)
+			if err != nil {
+				rows.Close()
+				return fmt.Errorf("Unable to scan - %s", err.Error())
+			}
+			count++
+
+			if err := fn(r); err != nil {
+				rows.Close()
+				return err
+			}
+		}
+		rows.Close()
+
+		if rows.Err() != nil {
+			return fmt.Errorf("rows Error: %v\n", rows.Err())
+		}
+		if count < fetchSize {
+			break
+		}
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,114 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// rowsPerRowGroup bounds the rows a Parquet writer buffers before flushing
+// them as a row group
+const rowsPerRowGroup = 50000
+
+// rowWriter encodes the rows of an export. Close writes anything buffered,
+// for Parquet the footer, but does not close the underlying writer.
+type rowWriter interface {
+	Write(r Reading) error
+	Close() error
+}
+
+func newRowWriter(format string, w io.Writer) rowWriter {
+	switch format {
+	case FormatNDJSON:
+		return &ndjsonWriter{enc: json.NewEncoder(w)}
+	case FormatParquet:
+		return &parquetWriter{w: parquet.NewGenericWriter[Reading](w, parquet.MaxRowsPerRowGroup(rowsPerRowGroup))}
+	}
+
+	return newCSVWriter(w)
+}
+
+// ContentType returns the MIME type of the format
+func ContentType(format string) string {
+	switch format {
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	case FormatParquet:
+		return "application/vnd.apache.parquet"
+	}
+
+	return "text/csv; charset=utf-8"
+}
+
+type csvWriter struct {
+	w      *csv.Writer
+	header bool
+}
+
+var csvHeader = []string{"time", "pollutant", "value", "unit", "latitude", "longitude", "station_id", "is_anomaly"}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (cw *csvWriter) Write(r Reading) error {
+	if !cw.header {
+		cw.header = true
+		if err := cw.w.Write(csvHeader); err != nil {
+			return err
+		}
+	}
+
+	return cw.w.Write([]string{
+		r.Time.Format(time.RFC3339Nano),
+		r.Pollutant,
+		strconv.FormatFloat(r.Value, 'f', -1, 64),
+		r.Unit,
+		strconv.FormatFloat(r.Latitude, 'f', -1, 64),
+		strconv.FormatFloat(r.Longitude, 'f', -1, 64),
+		r.StationID,
+		strconv.FormatBool(r.IsAnomaly),
+	})
+}
+
+func (cw *csvWriter) Close() error {
+	// An empty export still has the header
+	if !cw.header {
+		cw.header = true
+		if err := cw.w.Write(csvHeader); err != nil {
+			return err
+		}
+	}
+
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+type ndjsonWriter struct {
+	enc *json.Encoder
+}
+
+func (nw *ndjsonWriter) Write(r Reading) error {
+	return nw.enc.Encode(r)
+}
+
+func (nw *ndjsonWriter) Close() error {
+	return nil
+}
+
+type parquetWriter struct {
+	w *parquet.GenericWriter[Reading]
+}
+
+func (pw *parquetWriter) Write(r Reading) error {
+	_, err := pw.w.Write([]Reading{r})
+	return err
+}
+
+func (pw *parquetWriter) Close() error {
+	return pw.w.Close()
+}
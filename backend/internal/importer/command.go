@@ -0,0 +1,220 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/AkifSahn/pollution-tracker/config"
+	"github.com/AkifSahn/pollution-tracker/internal/database"
+	"github.com/AkifSahn/pollution-tracker/internal/pollution"
+)
+
+const (
+	// maxReportedErrors is the number of invalid readings listed in the report
+	maxReportedErrors = 20
+	// batchTimeout bounds a single batch, detection queries the database for
+	// every reading
+	batchTimeout = 5 * time.Minute
+	// refreshTimeout bounds refreshing the rollups after the import
+	refreshTimeout = time.Hour
+)
+
+// Run is the import subcommand, it loads the given files into air_pollution
+// and returns the exit code.
+//
+//	pollution-tracker import [-format=csv|openaq] [-detect] [-batch-size=5000] file...
+func Run(args []string) int {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: pollution-tracker import [flags] file...")
+		fmt.Fprintln(fs.Output(), "Imports historical readings, - reads from stdin.")
+		fs.PrintDefaults()
+	}
+	format := fs.String("format", "", "File format: "+strings.Join(Formats, ", ")+", guessed from the extension if empty")
+	detect := fs.Bool("detect", false, "Run anomaly detection over the imported readings")
+	batchSize := fs.Int("batch-size", 5000, "Readings inserted per batch")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if fs.NArg() == 0 || *batchSize <= 0 {
+		fs.Usage()
+		return 2
+	}
+	if *format != "" && !slices.Contains(Formats, *format) {
+		fmt.Fprintf(os.Stderr, "Unknown format %q, expected one of %s\n", *format, strings.Join(Formats, ", "))
+		return 2
+	}
+
+	cfg := config.LoadConfig()
+	database.InitDB(cfg)
+
+	importer := pollution.NewImporter(pollution.NewPollutionRepo(database.DBPool), *detect)
+	importer.MinTime = database.RetentionCutoff(cfg, time.Now())
+	if !importer.MinTime.IsZero() {
+		fmt.Fprintf(os.Stderr, "Readings before %s are skipped, they are past the retention of the raw readings or the minutely rollup\n",
+			importer.MinTime.Format(time.RFC3339))
+	}
+
+	var total pollution.ImportStats
+	start := time.Now()
+	failed := false
+	for _, path := range fs.Args() {
+		fileFormat := *format
+		if fileFormat == "" {
+			fileFormat = guessFormat(path)
+		}
+
+		stats, err := importFile(importer, path, fileFormat, *batchSize)
+		total.Add(stats)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: import stopped - %s\n", path, err.Error())
+			failed = true
+			break
+		}
+	}
+
+	// Batches inserted before a failure are kept, so their rollups are refreshed as well
+	if total.Inserted > 0 {
+		fmt.Fprintln(os.Stderr, "Refreshing the rollups of the imported time range")
+		ctx, cancel := context.WithTimeout(context.Background(), refreshTimeout)
+		err := importer.RefreshRollups(ctx)
+		cancel()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to refresh the rollups, run refresh_continuous_aggregate by hand - %s\n", err.Error())
+			failed = true
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "\nImported %d files in %s\n", len(fs.Args()), time.Since(start).Round(time.Second))
+	printStats(total)
+
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+func guessFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json", ".ndjson", ".jsonl":
+		return FormatOpenAQ
+	}
+
+	return FormatCSV
+}
+
+// importFile reads the file and imports it in batches, reporting the
+// progress after every batch
+func importFile(importer *pollution.Importer, path, format string, batchSize int) (pollution.ImportStats, error) {
+	var stats pollution.ImportStats
+
+	var in io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return stats, err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	reader, err := newRecordReader(format, in)
+	if err != nil {
+		return stats, err
+	}
+
+	start := time.Now()
+	batch := make([]pollution.Pollution, 0, batchSize)
+	flush := func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), batchTimeout)
+		defer cancel()
+
+		// Readings inserted before a failure are counted, their rollups are refreshed
+		batchStats, err := importer.Import(ctx, batch)
+		stats.Duplicates += batchStats.Duplicates
+		stats.Expired += batchStats.Expired
+		stats.Inserted += batchStats.Inserted
+		stats.Anomalies += batchStats.Anomalies
+		if err != nil {
+			return err
+		}
+		batch = batch[:0]
+
+		rate := float64(stats.Read) / time.Since(start).Seconds()
+		fmt.Fprintf(os.Stderr, "%s: %d read, %d inserted, %d duplicates, %d expired, %d invalid (%.0f readings/s)\n",
+			path, stats.Read, stats.Inserted, stats.Duplicates, stats.Expired, stats.Invalid, rate)
+		return nil
+	}
+
+	for {
+		entry, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+
+		var recordErr *recordError
+		if errors.As(err, &recordErr) {
+			stats.Read++
+			invalid(&stats, path, reader.Position(), err.Error())
+			continue
+		}
+		if err != nil {
+			return stats, fmt.Errorf("%s - %s", reader.Position(), err.Error())
+		}
+		stats.Read++
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		errs, err := importer.Check(ctx, &entry)
+		cancel()
+		if err != nil {
+			return stats, err
+		}
+		if errs != nil {
+			invalid(&stats, path, reader.Position(), errs.Error())
+			continue
+		}
+
+		batch = append(batch, entry)
+		if len(batch) == batchSize {
+			if err := flush(); err != nil {
+				return stats, err
+			}
+		}
+	}
+
+	if len(batch) > 0 {
+		if err := flush(); err != nil {
+			return stats, err
+		}
+	}
+
+	return stats, nil
+}
+
+func invalid(stats *pollution.ImportStats, path, position, msg string) {
+	stats.Invalid++
+	if stats.Invalid <= maxReportedErrors {
+		fmt.Fprintf(os.Stderr, "%s: %s: %s\n", path, position, msg)
+	}
+	if stats.Invalid == maxReportedErrors+1 {
+		fmt.Fprintf(os.Stderr, "%s: more invalid readings, only the first %d are listed\n", path, maxReportedErrors)
+	}
+}
+
+func printStats(stats pollution.ImportStats) {
+	fmt.Fprintf(os.Stderr, "  read:       %d\n", stats.Read)
+	fmt.Fprintf(os.Stderr, "  invalid:    %d\n", stats.Invalid)
+	fmt.Fprintf(os.Stderr, "  duplicates: %d\n", stats.Duplicates)
+	fmt.Fprintf(os.Stderr, "  expired:    %d\n", stats.Expired)
+	fmt.Fprintf(os.Stderr, "  inserted:   %d\n", stats.Inserted)
+	fmt.Fprintf(os.Stderr, "  anomalies:  %d\n", stats.Anomalies)
+}
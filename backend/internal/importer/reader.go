@@ -0,0 +1,320 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AkifSahn/pollution-tracker/internal/pollution"
+)
+
+const (
+	FormatCSV    = "csv"
+	FormatOpenAQ = "openaq"
+)
+
+var Formats = []string{FormatCSV, FormatOpenAQ}
+
+// recordReader reads the readings of an import file one at a time. Next
+// returns io.EOF after the last reading, a *recordError for a reading that
+// could not be parsed and any other error if the file can not be read on.
+type recordReader interface {
+	Next() (pollution.Pollution, error)
+	// Position is the line or record number of the last reading, for reports
+	Position() string
+}
+
+type recordError struct {
+	msg string
+}
+
+func (e *recordError) Error() string {
+	return e.msg
+}
+
+func newRecordReader(format string, r io.Reader) (recordReader, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVReader(r)
+	case FormatOpenAQ:
+		return newOpenAQReader(r)
+	}
+
+	return nil, fmt.Errorf("unknown format %q, expected one of %s", format, strings.Join(Formats, ", "))
+}
+
+// parseTime accepts RFC 3339 and the time format of the API
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+
+	t, err := time.ParseInLocation(pollution.TimeFormat, s, time.UTC)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("incorrect time %q, expected RFC 3339 or %q", s, pollution.TimeFormat)
+	}
+
+	return t, nil
+}
+
+// csvColumns maps the accepted header names to the fields of a reading. The
+// columns of /api/export are accepted, so exports can be imported again.
+var csvColumns = map[string]string{
+	"time":        "time",
+	"datetime":    "time",
+	"date":        "time",
+	"pollutant":   "pollutant",
+	"parameter":   "pollutant",
+	"value":       "value",
+	"unit":        "unit",
+	"latitude":    "latitude",
+	"lat":         "latitude",
+	"longitude":   "longitude",
+	"lon":         "longitude",
+	"lng":         "longitude",
+	"station_id":  "station_id",
+	"station":     "station_id",
+	"temperature": "temperature",
+	"pressure":    "pressure",
+}
+
+// csvReader reads a CSV file with a header row, unknown columns are ignored
+type csvReader struct {
+	r       *csv.Reader
+	columns map[string]int
+	line    int
+}
+
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	cr := &csvReader{r: csv.NewReader(r), columns: make(map[string]int)}
+	cr.r.ReuseRecord = true
+	cr.r.FieldsPerRecord = -1
+
+	header, err := cr.r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the header - %s", err.Error())
+	}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if field, ok := csvColumns[name]; ok {
+			cr.columns[field] = i
+		}
+	}
+
+	for _, required := range []string{"time", "pollutant", "value"} {
+		if _, ok := cr.columns[required]; !ok {
+			return nil, fmt.Errorf("missing %s column", required)
+		}
+	}
+	_, hasLatitude := cr.columns["latitude"]
+	_, hasLongitude := cr.columns["longitude"]
+	_, hasStation := cr.columns["station_id"]
+	if !(hasLatitude && hasLongitude) && !hasStation {
+		return nil, errors.New("missing latitude and longitude or station_id columns")
+	}
+
+	return cr, nil
+}
+
+func (cr *csvReader) Position() string {
+	return fmt.Sprintf("line %d", cr.line)
+}
+
+func (cr *csvReader) Next() (pollution.Pollution, error) {
+	var entry pollution.Pollution
+
+	record, err := cr.r.Read()
+	if err == io.EOF {
+		return entry, err
+	}
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			cr.line = parseErr.StartLine
+			return entry, &recordError{parseErr.Err.Error()}
+		}
+		return entry, err
+	}
+	cr.line, _ = cr.r.FieldPos(0)
+
+	field := func(name string) string {
+		i, ok := cr.columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+	number := func(name string) (float64, error) {
+		v, err := strconv.ParseFloat(field(name), 64)
+		if err != nil {
+			return 0, &recordError{fmt.Sprintf("incorrect %s %q", name, field(name))}
+		}
+		return v, nil
+	}
+	optional := func(name string) (*float64, error) {
+		if field(name) == "" {
+			return nil, nil
+		}
+		v, err := number(name)
+		return &v, err
+	}
+
+	if entry.Time, err = parseTime(field("time")); err != nil {
+		return entry, &recordError{err.Error()}
+	}
+	entry.Pollutant = field("pollutant")
+	entry.Unit = field("unit")
+	entry.StationID = field("station_id")
+
+	if entry.Value, err = number("value"); err != nil {
+		return entry, err
+	}
+	// Readings of a station take its location
+	if entry.StationID == "" || field("latitude") != "" {
+		if entry.Latitude, err = number("latitude"); err != nil {
+			return entry, err
+		}
+		if entry.Longitude, err = number("longitude"); err != nil {
+			return entry, err
+		}
+	}
+	if entry.Temperature, err = optional("temperature"); err != nil {
+		return entry, err
+	}
+	if entry.Pressure, err = optional("pressure"); err != nil {
+		return entry, err
+	}
+
+	return entry, nil
+}
+
+// openaqMeasurement is a measurement of the OpenAQ API and its archives.
+// Results is set for a whole API response.
+type openaqMeasurement struct {
+	Parameter string   `json:"parameter"`
+	Value     *float64 `json:"value"`
+	Unit      string   `json:"unit"`
+	Date      struct {
+		UTC string `json:"utc"`
+	} `json:"date"`
+	Coordinates *struct {
+		Latitude  float64 `json:"latitude"`
+		Longitude float64 `json:"longitude"`
+	} `json:"coordinates"`
+
+	Results []openaqMeasurement `json:"results"`
+}
+
+// openaqParameters maps the OpenAQ parameter names to our pollutants
+var openaqParameters = map[string]string{
+	"pm25": "PM2.5",
+	"pm10": "PM10",
+	"no2":  "NO2",
+	"so2":  "SO2",
+	"o3":   "O3",
+	"co":   "CO",
+}
+
+// openaqReader reads OpenAQ measurements from a JSON array, an API response
+// with a results array, or newline delimited JSON as in the OpenAQ archives
+type openaqReader struct {
+	dec     *json.Decoder
+	array   bool
+	pending []openaqMeasurement
+	record  int
+}
+
+func newOpenAQReader(r io.Reader) (*openaqReader, error) {
+	br := bufio.NewReader(r)
+
+	// Skip leading whitespace to tell an array from objects
+	var first byte
+	for {
+		b, err := br.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if b != ' ' && b != '\t' && b != '\r' && b != '\n' {
+			first = b
+			br.UnreadByte()
+			break
+		}
+	}
+
+	or := &openaqReader{dec: json.NewDecoder(br)}
+	if first == '[' {
+		if _, err := or.dec.Token(); err != nil {
+			return nil, err
+		}
+		or.array = true
+	}
+
+	return or, nil
+}
+
+func (or *openaqReader) Position() string {
+	return fmt.Sprintf("record %d", or.record)
+}
+
+func (or *openaqReader) Next() (pollution.Pollution, error) {
+	for len(or.pending) == 0 {
+		if or.array && !or.dec.More() {
+			return pollution.Pollution{}, io.EOF
+		}
+
+		var m openaqMeasurement
+		if err := or.dec.Decode(&m); err != nil {
+			// The decoder can not continue after a syntax error
+			return pollution.Pollution{}, err
+		}
+
+		if m.Results != nil {
+			or.pending = m.Results
+			continue
+		}
+		or.pending = []openaqMeasurement{m}
+	}
+
+	m := or.pending[0]
+	or.pending = or.pending[1:]
+	or.record++
+
+	return m.toPollution()
+}
+
+func (m openaqMeasurement) toPollution() (pollution.Pollution, error) {
+	var entry pollution.Pollution
+
+	if m.Value == nil {
+		return entry, &recordError{"missing value"}
+	}
+	if m.Coordinates == nil {
+		return entry, &recordError{"missing coordinates"}
+	}
+
+	t, err := parseTime(m.Date.UTC)
+	if err != nil {
+		return entry, &recordError{err.Error()}
+	}
+
+	entry.Pollutant = m.Parameter
+	if p, ok := openaqParameters[strings.ToLower(m.Parameter)]; ok {
+		entry.Pollutant = p
+	}
+	entry.Time = t
+	entry.Value = *m.Value
+	entry.Unit = m.Unit
+	entry.Latitude = m.Coordinates.Latitude
+	entry.Longitude = m.Coordinates.Longitude
+
+	return entry, nil
+}
@@ -3,46 +3,154 @@ package ingest
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"time"
 
+	"github.com/AkifSahn/pollution-tracker/config"
 	"github.com/AkifSahn/pollution-tracker/internal/database"
 	"github.com/AkifSahn/pollution-tracker/internal/pollution"
 	"github.com/AkifSahn/pollution-tracker/internal/rabbitmq"
+	amqp "github.com/rabbitmq/amqp091-go"
 )
 
-func ListenIngestion() {
-	msgs, err := rabbitmq.AmqpCh.Consume(
-		"ingest_queue", // queue
-		"",             // consumer
-		true,           // auto-ack
-		false,          // exclusive
-		false,          // no-local
-		false,          // no-wait
-		nil,            // args
-	)
-	if err != nil {
-		log.Fatalf("Failed to register a consumer: %s", err.Error())
-	}
+// prefetchCount limits how many unacknowledged messages the consumer holds at once
+const prefetchCount = 10
 
+func ListenIngestion(cfg *config.Config) {
 	repo := pollution.NewPollutionRepo(database.DBPool)
 	service := pollution.NewPollutionService(repo)
-	go func() {
-		for d := range msgs {
-			var data pollution.Pollution
-			// TODO: validate the data before unmarshaling, do the validation either here or before
-			err := json.Unmarshal(d.Body, &data)
-			if err != nil {
-				log.Printf("Failed to unmarshal the data - %s", err.Error())
-				continue
-			}
 
-			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-			// Handle anomaly detection before inserting into the database
-			if err = service.ProcessAndInsertPollutionEntry(ctx, data); err != nil {
-				log.Printf("Failed to insert the data into database - %s", err.Error())
+	// The consumer is re-registered by the rabbitmq package after a reconnect
+	rabbitmq.Consume("ingest_queue", prefetchCount, func(d amqp.Delivery) {
+		var err error
+		if d.Type == pollution.BatchMessageType {
+			err = handleBatch(service, d)
+		} else {
+			err = handleEntry(service, d)
+		}
+		settle(cfg, d, err)
+	})
+}
+
+// settle acknowledges a processed message. Failed messages are sent to the next
+// retry queue, or to the dead-letter exchange once the retries are exhausted.
+func settle(cfg *config.Config, d amqp.Delivery, cause error) {
+	if cause == nil {
+		if err := d.Ack(false); err != nil {
+			log.Printf("Failed to ack the message - %s", err.Error())
+		}
+		return
+	}
+
+	retries := rabbitmq.RetryCount(d.Headers)
+	if retries < cfg.IngestMaxRetries {
+		log.Printf("Failed to process the message, retrying (%d/%d) - %s", retries+1, cfg.IngestMaxRetries, cause.Error())
+		err := republish(d, "", rabbitmq.RetryQueue(cfg, retries+1), rabbitmq.FailureHeaders(retries+1, cause))
+		if err != nil {
+			// Requeueing would redeliver the message right away in a tight
+			// loop, the ingest queue dead-letters it instead
+			log.Printf("Failed to schedule a retry, dead-lettering - %s", err.Error())
+			d.Nack(false, false)
+			return
+		}
+		d.Ack(false)
+		return
+	}
+
+	log.Printf("Failed to process the message after %d retries, dead-lettering - %s", retries, cause.Error())
+	err := republish(d, rabbitmq.DeadLetterExchange, "ingest_queue", rabbitmq.FailureHeaders(retries, cause))
+	if err != nil {
+		// The ingest queue dead-letters rejected messages on its own,
+		// we only lose the failure headers
+		log.Printf("Failed to publish to the dead-letter exchange - %s", err.Error())
+		d.Nack(false, false)
+		return
+	}
+	d.Ack(false)
+}
+
+func republish(d amqp.Delivery, exchange, key string, headers amqp.Table) error {
+	return rabbitmq.Publish(context.Background(), exchange, key,
+		amqp.Publishing{
+			ContentType: d.ContentType,
+			Type:        d.Type,
+			MessageId:   d.MessageId,
+			Timestamp:   d.Timestamp,
+			Headers:     headers,
+			Body:        d.Body,
+		})
+}
+
+func handleEntry(service *pollution.PollutionService, d amqp.Delivery) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var data pollution.Pollution
+	err := json.Unmarshal(d.Body, &data)
+	if err != nil {
+		return reject(ctx, service, d.Body, pollution.ValidationErrors{{Field: "body", Message: err.Error()}})
+	}
+
+	if errs := pollution.DefaultValidator.Check(&data, time.Now()); errs != nil {
+		return reject(ctx, service, d.Body, errs)
+	}
+
+	// Handle anomaly detection before inserting into the database
+	if err = service.ProcessAndInsertPollutionEntry(ctx, data); err != nil {
+		return fmt.Errorf("Failed to insert the data into database - %s", err.Error())
+	}
+
+	return nil
+}
+
+func handleBatch(service *pollution.PollutionService, d amqp.Delivery) error {
+	// Anomaly detection still queries the database per entry, so give
+	// batches more time than single entries
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	var batch []pollution.Pollution
+	err := json.Unmarshal(d.Body, &batch)
+	if err != nil {
+		return reject(ctx, service, d.Body, pollution.ValidationErrors{{Field: "body", Message: err.Error()}})
+	}
+
+	// Invalid entries were already stored on the first attempt of a retried batch
+	firstAttempt := rabbitmq.RetryCount(d.Headers) == 0
+
+	now := time.Now()
+	valid := batch[:0]
+	for _, entry := range batch {
+		if errs := pollution.DefaultValidator.Check(&entry, now); errs != nil {
+			if firstAttempt {
+				payload, _ := json.Marshal(entry)
+				if err := reject(ctx, service, payload, errs); err != nil {
+					log.Print(err.Error())
+				}
 			}
-			cancel()
+			continue
 		}
-	}()
+		valid = append(valid, entry)
+	}
+
+	if len(valid) == 0 {
+		return nil
+	}
+
+	// A retried batch may have been partially inserted before it failed
+	if err = service.ProcessAndInsertPollutionBatch(ctx, valid, !firstAttempt); err != nil {
+		return fmt.Errorf("Failed to insert the batch into database - %s", err.Error())
+	}
+
+	return nil
+}
+
+func reject(ctx context.Context, service *pollution.PollutionService, payload []byte, errs pollution.ValidationErrors) error {
+	log.Printf("Rejected invalid reading - %s", errs.Error())
+	if err := service.RejectReading(ctx, payload, errs); err != nil {
+		return fmt.Errorf("Failed to store the rejected reading - %s", err.Error())
+	}
+
+	return nil
 }
@@ -5,32 +5,22 @@ import (
 	"log"
 
 	"github.com/AkifSahn/pollution-tracker/internal/rabbitmq"
+	amqp "github.com/rabbitmq/amqp091-go"
 )
 
 func ListenAndConsumeNotifications(hub *Hub) {
-
-	msgs, err := rabbitmq.AmqpCh.Consume(
-		"notification_queue", // queue
-		"",                   // consumer
-		true,                 // auto-ack
-		false,                // exclusive
-		false,                // no-local
-		false,                // no-wait
-		nil,                  // args
-	)
-	if err != nil {
-		log.Fatalf("Failed to register a consumer: %s", err.Error())
-	}
-
-	for d := range msgs {
+	// The consumer is re-registered by the rabbitmq package after a reconnect
+	rabbitmq.Consume("notification_queue", 50, func(d amqp.Delivery) {
 		// TODO: validate the incoming data
 		var notification Notification
 		err := json.Unmarshal(d.Body, &notification)
 		if err != nil {
 			log.Printf("Failed to unmarshal the data - %s", err.Error())
-			continue
+			d.Nack(false, false)
+			return
 		}
 
 		hub.broadcast <- d.Body
-	}
+		d.Ack(false)
+	})
 }
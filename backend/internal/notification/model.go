@@ -1,10 +1,19 @@
 package notification
 
 type Notification struct {
-	Type      int     `json:"type"`
-	Message   string  `json:"message"`
-	Latitude  float64 `json:"latitude"`
-	Longitude float64 `json:"longitude"`
-	Value     float64 `json:"value"`
-	Pollutant string  `json:"pollutant"`
+	Type      int      `json:"type"`
+	Message   string   `json:"message"`
+	Latitude  float64  `json:"latitude"`
+	Longitude float64  `json:"longitude"`
+	Value     float64  `json:"value"`
+	Pollutant string   `json:"pollutant"`
+	Reasons   []Reason `json:"reasons,omitempty"`
+}
+
+// Reason describes why a detector flagged the reading
+type Reason struct {
+	Detector string  `json:"detector"`
+	Score    float64 `json:"score"`
+	Severity string  `json:"severity"`
+	Reason   string  `json:"reason"`
 }
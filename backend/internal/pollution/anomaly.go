@@ -0,0 +1,119 @@
+package pollution
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// Anomaly is a detection stored in the anomalies table. Every detector that
+// flagged a reading produces its own anomaly, linked to the reading by its id.
+// Anomalies stored before readings had ids may have no reading id.
+type Anomaly struct {
+	ID        int64     `json:"id"`
+	ReadingID *int64    `json:"reading_id,omitempty"`
+	Time      time.Time `json:"time"`
+	Pollutant string    `json:"pollutant"`
+	Latitude  float64   `json:"latitude"`
+	Longitude float64   `json:"longitude"`
+	Value     float64   `json:"value"`
+	Unit      string    `json:"unit"`
+	StationID string    `json:"station_id,omitempty"`
+
+	Detector       string  `json:"detector"`
+	Score          float64 `json:"score"`
+	BaselineMean   float64 `json:"baseline_mean"`
+	BaselineStdDev float64 `json:"baseline_stddev"`
+	Threshold      float64 `json:"threshold"`
+	Severity       string  `json:"severity"`
+	Reason         string  `json:"reason"`
+
+	Status         string     `json:"status"`
+	DetectedAt     time.Time  `json:"detected_at"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
+	AcknowledgedBy string     `json:"acknowledged_by,omitempty"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty"`
+	ResolvedBy     string     `json:"resolved_by,omitempty"`
+	Note           string     `json:"note,omitempty"`
+}
+
+// AnomalyFilter narrows down the anomalies returned by GetAnomalies, empty
+// fields are not filtered on.
+type AnomalyFilter struct {
+	From, To  time.Time
+	Pollutant string
+	Status    []string
+	Severity  []string
+	// Area is a GeoJSON Polygon or MultiPolygon the anomalies must lie in
+	Area string
+}
+
+const (
+	AnomalyStatusOpen         = "open"
+	AnomalyStatusAcknowledged = "acknowledged"
+	AnomalyStatusResolved     = "resolved"
+)
+
+const (
+	SeverityLow      = "low"
+	SeverityMedium   = "medium"
+	SeverityHigh     = "high"
+	SeverityCritical = "critical"
+)
+
+var (
+	AnomalyStatuses = []string{AnomalyStatusOpen, AnomalyStatusAcknowledged, AnomalyStatusResolved}
+	Severities      = []string{SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical}
+)
+
+var (
+	ErrAnomalyNotFound   = errors.New("anomaly not found")
+	ErrInvalidTransition = errors.New("anomaly can not be moved to this status")
+)
+
+// Severity grades a detection by how far its score exceeds the threshold of
+// its detector.
+func (d Detection) Severity() string {
+	if d.Threshold <= 0 {
+		return SeverityLow
+	}
+
+	ratio := math.Abs(d.Score) / d.Threshold
+	switch {
+	case ratio >= 3:
+		return SeverityCritical
+	case ratio >= 2:
+		return SeverityHigh
+	case ratio >= 1.5:
+		return SeverityMedium
+	default:
+		return SeverityLow
+	}
+}
+
+// allowedFrom returns the statuses an anomaly can be moved to the given status from
+func allowedFrom(status string) []string {
+	switch status {
+	case AnomalyStatusAcknowledged:
+		return []string{AnomalyStatusOpen}
+	case AnomalyStatusResolved:
+		return []string{AnomalyStatusOpen, AnomalyStatusAcknowledged}
+	}
+	return nil
+}
+
+// convertAnomaly converts the concentrations of the anomaly into the unit.
+// Conversions are linear, so the baseline standard deviation scales the same
+// way. Only the threshold detector compares against a concentration, the
+// thresholds of the other detectors are z-scores or percentages.
+func convertAnomaly(a *Anomaly, unit string) {
+	var converted string
+	a.Value, converted = ConvertValue(a.Pollutant, a.Value, unit)
+	a.BaselineMean, _ = ConvertValue(a.Pollutant, a.BaselineMean, unit)
+	a.BaselineStdDev, _ = ConvertValue(a.Pollutant, a.BaselineStdDev, unit)
+	if a.Detector == DetectorThreshold {
+		a.Threshold, _ = ConvertValue(a.Pollutant, a.Threshold, unit)
+		a.Score = a.Value
+	}
+	a.Unit = converted
+}
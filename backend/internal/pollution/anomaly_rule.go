@@ -0,0 +1,185 @@
+package pollution
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// AnomalyRule configures the detectors evaluated for a pollutant. Rules with a
+// region only apply to readings inside their boundary and take precedence over
+// the global rule of the pollutant, which has an empty region.
+type AnomalyRule struct {
+	Pollutant string `json:"pollutant"`
+	Region    string `json:"region,omitempty"`
+	// Boundary is a GeoJSON Polygon or MultiPolygon. Regional rules without one
+	// apply inside the saved region (see /api/regions) with the same name.
+	Boundary json.RawMessage `json:"boundary,omitempty" swaggertype:"object"`
+
+	Detectors []string `json:"detectors"`
+	RadiusKm  float64  `json:"radius_km"`
+
+	WindowMinutes   int     `json:"window_minutes"`
+	ZScoreThreshold float64 `json:"zscore_threshold"`
+
+	StaticThreshold *float64 `json:"static_threshold,omitempty"`
+
+	RateWindowMinutes  int     `json:"rate_window_minutes"`
+	MaxIncreasePercent float64 `json:"max_increase_percent"`
+
+	EWMAWindowMinutes int     `json:"ewma_window_minutes"`
+	EWMAAlpha         float64 `json:"ewma_alpha"`
+	EWMAThreshold     float64 `json:"ewma_threshold"`
+
+	SeasonalDays      int     `json:"seasonal_days"`
+	SeasonalThreshold float64 `json:"seasonal_threshold"`
+
+	UpdatedAt time.Time `json:"updated_at"`
+	UpdatedBy string    `json:"updated_by"`
+}
+
+type AnomalyRuleAudit struct {
+	ID        int64           `json:"id"`
+	ChangedAt time.Time       `json:"changed_at"`
+	ChangedBy string          `json:"changed_by"`
+	Action    string          `json:"action"`
+	Pollutant string          `json:"pollutant"`
+	Region    string          `json:"region,omitempty"`
+	OldRule   json.RawMessage `json:"old_rule,omitempty" swaggertype:"object"`
+	NewRule   json.RawMessage `json:"new_rule,omitempty" swaggertype:"object"`
+}
+
+const (
+	DetectorZScore       = "zscore"
+	DetectorThreshold    = "threshold"
+	DetectorRateOfChange = "rate_of_change"
+	DetectorEWMA         = "ewma"
+	DetectorSeasonal     = "seasonal"
+)
+
+var knownDetectors = []string{DetectorZScore, DetectorThreshold, DetectorRateOfChange, DetectorEWMA, DetectorSeasonal}
+
+// DefaultAnomalyRule is used for pollutants without a stored rule. The values
+// match the column defaults of the anomaly_rules table.
+func DefaultAnomalyRule(pollutant string) AnomalyRule {
+	return AnomalyRule{
+		Pollutant:          pollutant,
+		Detectors:          []string{DetectorZScore},
+		RadiusKm:           25,
+		WindowMinutes:      24 * 60,
+		ZScoreThreshold:    2,
+		RateWindowMinutes:  60,
+		MaxIncreasePercent: 200,
+		EWMAWindowMinutes:  6 * 60,
+		EWMAAlpha:          0.3,
+		EWMAThreshold:      3,
+		SeasonalDays:       14,
+		SeasonalThreshold:  3,
+	}
+}
+
+// Validate returns the problems of the rule as field errors
+func (r AnomalyRule) Validate() ValidationErrors {
+	var errs ValidationErrors
+
+	if len(r.Detectors) == 0 {
+		errs = append(errs, FieldError{"detectors", "at least one detector is required"})
+	}
+	for _, name := range r.Detectors {
+		if !slices.Contains(knownDetectors, name) {
+			errs = append(errs, FieldError{"detectors", fmt.Sprintf("unknown detector %q", name)})
+		}
+	}
+
+	if slices.Contains(r.Detectors, DetectorThreshold) && (r.StaticThreshold == nil || *r.StaticThreshold <= 0) {
+		errs = append(errs, FieldError{"static_threshold", "must be positive when the threshold detector is enabled"})
+	}
+
+	if r.RadiusKm <= 0 {
+		errs = append(errs, FieldError{"radius_km", "must be positive"})
+	}
+	if r.WindowMinutes <= 0 {
+		errs = append(errs, FieldError{"window_minutes", "must be positive"})
+	}
+	if r.ZScoreThreshold <= 0 {
+		errs = append(errs, FieldError{"zscore_threshold", "must be positive"})
+	}
+	if r.RateWindowMinutes <= 0 {
+		errs = append(errs, FieldError{"rate_window_minutes", "must be positive"})
+	}
+	if r.MaxIncreasePercent <= 0 {
+		errs = append(errs, FieldError{"max_increase_percent", "must be positive"})
+	}
+	if r.EWMAWindowMinutes <= 0 {
+		errs = append(errs, FieldError{"ewma_window_minutes", "must be positive"})
+	}
+	if r.EWMAAlpha <= 0 || r.EWMAAlpha > 1 {
+		errs = append(errs, FieldError{"ewma_alpha", "must be in (0, 1]"})
+	}
+	if r.EWMAThreshold <= 0 {
+		errs = append(errs, FieldError{"ewma_threshold", "must be positive"})
+	}
+	if r.SeasonalDays <= 0 {
+		errs = append(errs, FieldError{"seasonal_days", "must be positive"})
+	}
+	if r.SeasonalThreshold <= 0 {
+		errs = append(errs, FieldError{"seasonal_threshold", "must be positive"})
+	}
+
+	// Regional rules without a boundary use the boundary of the saved region
+	// of the same name, which is checked by the handler
+	if len(r.Boundary) > 0 {
+		if r.Region == "" {
+			errs = append(errs, FieldError{"boundary", "is only allowed for regional rules"})
+		} else if _, err := ParseAreaGeometry(r.Boundary); err != nil {
+			errs = append(errs, FieldError{"boundary", err.Error()})
+		}
+	}
+
+	return errs
+}
+
+// BuildDetectors creates the detectors enabled by the rule
+func (r AnomalyRule) BuildDetectors(repo PollutionRepo) []Detector {
+	var detectors []Detector
+	for _, name := range r.Detectors {
+		switch name {
+		case DetectorZScore:
+			detectors = append(detectors, &ZScoreDetector{
+				Repo:      repo,
+				Window:    time.Duration(r.WindowMinutes) * time.Minute,
+				RadiusKm:  r.RadiusKm,
+				Threshold: r.ZScoreThreshold,
+			})
+		case DetectorThreshold:
+			if r.StaticThreshold != nil {
+				detectors = append(detectors, &ThresholdDetector{Limit: *r.StaticThreshold})
+			}
+		case DetectorRateOfChange:
+			detectors = append(detectors, &RateOfChangeDetector{
+				Repo:               repo,
+				Window:             time.Duration(r.RateWindowMinutes) * time.Minute,
+				RadiusKm:           r.RadiusKm,
+				MaxIncreasePercent: r.MaxIncreasePercent,
+			})
+		case DetectorEWMA:
+			detectors = append(detectors, &EWMADetector{
+				Repo:      repo,
+				Window:    time.Duration(r.EWMAWindowMinutes) * time.Minute,
+				RadiusKm:  r.RadiusKm,
+				Alpha:     r.EWMAAlpha,
+				Threshold: r.EWMAThreshold,
+			})
+		case DetectorSeasonal:
+			detectors = append(detectors, &SeasonalDetector{
+				Repo:      repo,
+				Days:      r.SeasonalDays,
+				RadiusKm:  r.RadiusKm,
+				Threshold: r.SeasonalThreshold,
+			})
+		}
+	}
+
+	return detectors
+}
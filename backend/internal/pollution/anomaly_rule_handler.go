@@ -0,0 +1,257 @@
+package pollution
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/AkifSahn/pollution-tracker/internal/database"
+	"github.com/gofiber/fiber/v2"
+)
+
+// changedBy identifies the operator changing a rule for the audit trail
+func changedBy(c *fiber.Ctx) string {
+	if user := c.Get("X-User"); user != "" {
+		return user
+	}
+	return "anonymous@" + c.IP()
+}
+
+// GetAnomalyRules
+//
+//	@Summary		Gets anomaly rules
+//	@Description	Gets the anomaly detection rules of every pollutant and region
+//	@Tags			config
+//	@Produce		json
+//
+//	@Failure		500	{object}	map[string]string			"Failed to fetch anomaly rules from database"
+//	@Success		200	{object}	map[string][]AnomalyRule	"Anomaly rules"
+//	@Router			/api/config/anomaly-rules [get]
+func GetAnomalyRules(c *fiber.Ctx) error {
+	repo := NewPollutionRepo(database.DBPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rules, err := repo.GetAnomalyRules(ctx)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch anomaly rules from database: " + err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"data": rules,
+	})
+}
+
+// GetAnomalyRule
+//
+//	@Summary		Gets an anomaly rule
+//	@Description	Gets the anomaly detection rule of a pollutant, or of a pollutant in a region
+//	@Tags			config
+//	@Produce		json
+//
+//	@Param			pollutant	path		string						true	"Pollutant"
+//	@Param			region		query		string						false	"Region name, empty for the global rule"
+//
+//	@Failure		404			{object}	map[string]string			"Anomaly rule not found"
+//	@Failure		500			{object}	map[string]string			"Failed to fetch anomaly rule from database"
+//	@Success		200			{object}	map[string]AnomalyRule		"Anomaly rule"
+//	@Router			/api/config/anomaly-rules/{pollutant} [get]
+func GetAnomalyRule(c *fiber.Ctx) error {
+	repo := NewPollutionRepo(database.DBPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rule, found, err := repo.GetAnomalyRule(ctx, c.Params("pollutant"), c.Query("region"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch anomaly rule from database: " + err.Error(),
+		})
+	}
+
+	if !found {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Anomaly rule not found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"data": rule,
+	})
+}
+
+// PutAnomalyRule
+//
+//	@Summary		Creates or updates an anomaly rule
+//	@Description	Creates or updates the anomaly detection rule of a pollutant. Fields missing from the body keep their
+//	@Description	current value, or the default value for a new rule. The ingest worker uses the new rule immediately.
+//	@Description	The X-User header is recorded in the audit trail.
+//	@Tags			config
+//	@Accept			json
+//	@Produce		json
+//
+//	@Param			pollutant	path		string					true	"Pollutant"
+//	@Param			region		query		string					false	"Region name, empty for the global rule"
+//	@Param			X-User		header		string					false	"Operator making the change"
+//	@Param			request		body		AnomalyRule				true	"Anomaly rule"
+//
+//	@Failure		400			{object}	map[string]string		"Unknown pollutant or failed to parse request body"
+//	@Failure		422			{object}	map[string]any			"Invalid anomaly rule, with field level errors"
+//	@Failure		500			{object}	map[string]string		"Failed to save anomaly rule"
+//	@Success		200			{object}	map[string]AnomalyRule	"Saved anomaly rule"
+//	@Router			/api/config/anomaly-rules/{pollutant} [put]
+func PutAnomalyRule(c *fiber.Ctx) error {
+	pollutant := c.Params("pollutant")
+	region := c.Query("region")
+
+	if _, ok := KnownPollutants[pollutant]; !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Unknown pollutant %q", pollutant),
+		})
+	}
+
+	repo := NewPollutionRepo(database.DBPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rule, found, err := repo.GetAnomalyRule(ctx, pollutant, region)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch anomaly rule from database: " + err.Error(),
+		})
+	}
+	if !found {
+		rule = DefaultAnomalyRule(pollutant)
+	}
+
+	if err := json.Unmarshal(c.Body(), &rule); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Failed to parse request body - " + err.Error(),
+		})
+	}
+
+	// The path and query identify the rule, not the body
+	rule.Pollutant = pollutant
+	rule.Region = region
+
+	// "boundary": null switches a regional rule to its saved region, a
+	// Feature is accepted and stored as its geometry
+	if string(rule.Boundary) == "null" {
+		rule.Boundary = nil
+	}
+	if geometry, err := ParseAreaGeometry(rule.Boundary); err == nil {
+		rule.Boundary = geometry
+	}
+
+	errs := rule.Validate()
+	if region != "" && len(rule.Boundary) == 0 {
+		exists, err := repo.RegionExists(ctx, region)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to fetch region from database: " + err.Error(),
+			})
+		}
+		if !exists {
+			errs = append(errs, FieldError{"boundary", "is required unless there is a saved region with the same name"})
+		}
+	}
+
+	if errs != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error":  "Invalid anomaly rule",
+			"fields": errs,
+		})
+	}
+
+	saved, err := repo.SaveAnomalyRule(ctx, rule, changedBy(c))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to save anomaly rule: " + err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"data": saved,
+	})
+}
+
+// DeleteAnomalyRule
+//
+//	@Summary		Deletes an anomaly rule
+//	@Description	Deletes the anomaly detection rule of a pollutant. Pollutants without a rule use the built-in defaults.
+//	@Tags			config
+//	@Produce		json
+//
+//	@Param			pollutant	path		string				true	"Pollutant"
+//	@Param			region		query		string				false	"Region name, empty for the global rule"
+//	@Param			X-User		header		string				false	"Operator making the change"
+//
+//	@Failure		404			{object}	map[string]string	"Anomaly rule not found"
+//	@Failure		500			{object}	map[string]string	"Failed to delete anomaly rule"
+//	@Success		200			{object}	map[string]string	"Anomaly rule deleted"
+//	@Router			/api/config/anomaly-rules/{pollutant} [delete]
+func DeleteAnomalyRule(c *fiber.Ctx) error {
+	repo := NewPollutionRepo(database.DBPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	deleted, err := repo.DeleteAnomalyRule(ctx, c.Params("pollutant"), c.Query("region"), changedBy(c))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete anomaly rule: " + err.Error(),
+		})
+	}
+
+	if !deleted {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Anomaly rule not found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Anomaly rule deleted",
+	})
+}
+
+// GetAnomalyRuleAudit
+//
+//	@Summary		Gets the anomaly rule audit trail
+//	@Description	Gets the changes made to the anomaly rules, a page at a time newest first
+//	@Tags			config
+//	@Produce		json
+//
+//	@Param			pollutant	query		string							false	"Pollutant"
+//	@Param			limit		query		int								false	"Maximum number of entries, 1-10000"	default(1000)
+//	@Param			order		query		string							false	"Order by time: asc or desc"	default(desc)
+//	@Param			cursor		query		string							false	"next_cursor of the previous page"
+//	@Param			fields		query		string							false	"Comma separated fields to return, e.g. changed_at,action"
+//
+//	@Failure		400			{object}	map[string]string				"Invalid params"
+//	@Failure		500			{object}	map[string]string				"Failed to fetch audit trail from database"
+//	@Success		200			{object}	map[string][]AnomalyRuleAudit	"Audit entries"
+//	@Router			/api/config/anomaly-rules/audit [get]
+func GetAnomalyRuleAudit(c *fiber.Ctx) error {
+	page, msg := ParsePage[AnomalyRuleAudit](c)
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	repo := NewPollutionRepo(database.DBPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	entries, err := repo.GetAnomalyRuleAudit(ctx, c.Query("pollutant"), page)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch audit trail from database: " + err.Error(),
+		})
+	}
+
+	return WritePage(c, page, entries, func(a AnomalyRuleAudit) Cursor {
+		return Cursor{Time: a.ChangedAt, ID: a.ID}
+	})
+}
@@ -0,0 +1,297 @@
+package pollution
+
+import (
+	"math"
+	"slices"
+	"sort"
+	"time"
+)
+
+// Breakpoint maps the concentration range [CLow, CHigh] linearly onto the
+// index range [ILow, IHigh]. Banded indices use ILow == IHigh.
+type Breakpoint struct {
+	CLow, CHigh float64
+	ILow, IHigh float64
+}
+
+// AQIPollutant describes how a scale turns the concentration of a pollutant
+// into a sub-index.
+type AQIPollutant struct {
+	// Averaging is the period the concentration is averaged over
+	Averaging time.Duration
+	// Unit is the unit of the breakpoints, concentrations are converted to it
+	// from the canonical unit of the pollutant first
+	Unit string
+	// Decimals is the precision concentrations are truncated to before the
+	// breakpoint lookup, so values between two breakpoints fall into one of them
+	Decimals    int
+	Breakpoints []Breakpoint
+}
+
+type AQICategory struct {
+	// Max is the highest index of the category
+	Max  float64
+	Name string
+}
+
+type AQIScale struct {
+	Name        string
+	Description string
+	Pollutants  map[string]AQIPollutant
+	// Categories are ordered by Max, the last one covers everything above
+	Categories []AQICategory
+	// Integer scales round the index to a whole number
+	Integer bool
+}
+
+type AQISubIndex struct {
+	Pollutant     string  `json:"pollutant"`
+	Concentration float64 `json:"concentration"`
+	Unit          string  `json:"unit"`
+	Index         float64 `json:"index"`
+	Category      string  `json:"category"`
+}
+
+type AQIResult struct {
+	Scale      string        `json:"scale"`
+	Index      float64       `json:"index"`
+	Category   string        `json:"category"`
+	Dominant   string        `json:"dominant_pollutant"`
+	SubIndices []AQISubIndex `json:"sub_indices"`
+}
+
+const (
+	AQIScaleEPA  = "epa"
+	AQIScaleCAQI = "caqi"
+	AQIScaleDAQI = "daqi"
+
+	DefaultAQIScale = AQIScaleEPA
+)
+
+// AQIScales holds the supported breakpoint tables
+var AQIScales = map[string]*AQIScale{
+	AQIScaleEPA: {
+		Name:        AQIScaleEPA,
+		Description: "US EPA Air Quality Index (2024 PM2.5 revision), 0-500",
+		Integer:     true,
+		Pollutants: map[string]AQIPollutant{
+			"PM2.5": {Averaging: 24 * time.Hour, Unit: "µg/m³", Decimals: 1, Breakpoints: []Breakpoint{
+				{0, 9.0, 0, 50}, {9.1, 35.4, 51, 100}, {35.5, 55.4, 101, 150},
+				{55.5, 125.4, 151, 200}, {125.5, 225.4, 201, 300}, {225.5, 325.4, 301, 500},
+			}},
+			"PM10": {Averaging: 24 * time.Hour, Unit: "µg/m³", Decimals: 0, Breakpoints: []Breakpoint{
+				{0, 54, 0, 50}, {55, 154, 51, 100}, {155, 254, 101, 150},
+				{255, 354, 151, 200}, {355, 424, 201, 300}, {425, 604, 301, 500},
+			}},
+			"O3": {Averaging: 8 * time.Hour, Unit: "ppb", Decimals: 0, Breakpoints: []Breakpoint{
+				{0, 54, 0, 50}, {55, 70, 51, 100}, {71, 85, 101, 150},
+				{86, 105, 151, 200}, {106, 200, 201, 300},
+			}},
+			"NO2": {Averaging: time.Hour, Unit: "ppb", Decimals: 0, Breakpoints: []Breakpoint{
+				{0, 53, 0, 50}, {54, 100, 51, 100}, {101, 360, 101, 150},
+				{361, 649, 151, 200}, {650, 1249, 201, 300}, {1250, 2049, 301, 500},
+			}},
+			"SO2": {Averaging: time.Hour, Unit: "ppb", Decimals: 0, Breakpoints: []Breakpoint{
+				{0, 35, 0, 50}, {36, 75, 51, 100}, {76, 185, 101, 150},
+				{186, 304, 151, 200}, {305, 604, 201, 300}, {605, 1004, 301, 500},
+			}},
+			"CO": {Averaging: 8 * time.Hour, Unit: "ppm", Decimals: 1, Breakpoints: []Breakpoint{
+				{0, 4.4, 0, 50}, {4.5, 9.4, 51, 100}, {9.5, 12.4, 101, 150},
+				{12.5, 15.4, 151, 200}, {15.5, 30.4, 201, 300}, {30.5, 50.4, 301, 500},
+			}},
+		},
+		Categories: []AQICategory{
+			{50, "Good"},
+			{100, "Moderate"},
+			{150, "Unhealthy for Sensitive Groups"},
+			{200, "Unhealthy"},
+			{300, "Very Unhealthy"},
+			{math.Inf(1), "Hazardous"},
+		},
+	},
+	AQIScaleCAQI: {
+		Name:        AQIScaleCAQI,
+		Description: "European Common Air Quality Index (hourly, background), 0-100+",
+		Integer:     true,
+		Pollutants: map[string]AQIPollutant{
+			"PM2.5": {Averaging: time.Hour, Unit: "µg/m³", Decimals: 1, Breakpoints: []Breakpoint{
+				{0, 15, 0, 25}, {15, 30, 25, 50}, {30, 55, 50, 75}, {55, 110, 75, 100},
+			}},
+			"PM10": {Averaging: time.Hour, Unit: "µg/m³", Decimals: 1, Breakpoints: []Breakpoint{
+				{0, 25, 0, 25}, {25, 50, 25, 50}, {50, 90, 50, 75}, {90, 180, 75, 100},
+			}},
+			"O3": {Averaging: time.Hour, Unit: "µg/m³", Decimals: 1, Breakpoints: []Breakpoint{
+				{0, 60, 0, 25}, {60, 120, 25, 50}, {120, 180, 50, 75}, {180, 240, 75, 100},
+			}},
+			"NO2": {Averaging: time.Hour, Unit: "µg/m³", Decimals: 1, Breakpoints: []Breakpoint{
+				{0, 50, 0, 25}, {50, 100, 25, 50}, {100, 200, 50, 75}, {200, 400, 75, 100},
+			}},
+			"SO2": {Averaging: time.Hour, Unit: "µg/m³", Decimals: 1, Breakpoints: []Breakpoint{
+				{0, 50, 0, 25}, {50, 100, 25, 50}, {100, 350, 50, 75}, {350, 500, 75, 100},
+			}},
+			"CO": {Averaging: 8 * time.Hour, Unit: "mg/m³", Decimals: 2, Breakpoints: []Breakpoint{
+				{0, 5, 0, 25}, {5, 7.5, 25, 50}, {7.5, 10, 50, 75}, {10, 20, 75, 100},
+			}},
+		},
+		Categories: []AQICategory{
+			{25, "Very Low"},
+			{50, "Low"},
+			{75, "Medium"},
+			{100, "High"},
+			{math.Inf(1), "Very High"},
+		},
+	},
+	AQIScaleDAQI: {
+		Name:        AQIScaleDAQI,
+		Description: "UK Daily Air Quality Index, bands 1-10",
+		Integer:     true,
+		Pollutants: map[string]AQIPollutant{
+			"PM2.5": {Averaging: 24 * time.Hour, Unit: "µg/m³", Decimals: 0, Breakpoints: daqiBands(11, 23, 35, 41, 47, 53, 58, 64, 70)},
+			"PM10":  {Averaging: 24 * time.Hour, Unit: "µg/m³", Decimals: 0, Breakpoints: daqiBands(16, 33, 50, 58, 66, 75, 83, 91, 100)},
+			"O3":    {Averaging: 8 * time.Hour, Unit: "µg/m³", Decimals: 0, Breakpoints: daqiBands(33, 66, 100, 120, 140, 160, 187, 213, 240)},
+			"NO2":   {Averaging: time.Hour, Unit: "µg/m³", Decimals: 0, Breakpoints: daqiBands(67, 134, 200, 267, 334, 400, 467, 534, 600)},
+			"SO2":   {Averaging: 15 * time.Minute, Unit: "µg/m³", Decimals: 0, Breakpoints: daqiBands(88, 177, 266, 354, 443, 532, 710, 887, 1064)},
+		},
+		Categories: []AQICategory{
+			{3, "Low"},
+			{6, "Moderate"},
+			{9, "High"},
+			{math.Inf(1), "Very High"},
+		},
+	},
+}
+
+// daqiBands builds the breakpoints of a banded index from the upper limits of
+// bands 1-9, everything above the last limit is band 10.
+func daqiBands(limits ...float64) []Breakpoint {
+	var breakpoints []Breakpoint
+	low := 0.0
+	for i, high := range limits {
+		band := float64(i + 1)
+		breakpoints = append(breakpoints, Breakpoint{low, high, band, band})
+		low = high + 1
+	}
+	breakpoints = append(breakpoints, Breakpoint{low, math.Inf(1), 10, 10})
+
+	return breakpoints
+}
+
+// AQIScaleNames returns the names of the supported scales in a stable order
+func AQIScaleNames() []string {
+	var names []string
+	for name := range AQIScales {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// AveragingPeriods returns the distinct averaging periods of the scale
+func (s *AQIScale) AveragingPeriods() []time.Duration {
+	var periods []time.Duration
+	for _, p := range s.Pollutants {
+		if !slices.Contains(periods, p.Averaging) {
+			periods = append(periods, p.Averaging)
+		}
+	}
+	slices.Sort(periods)
+
+	return periods
+}
+
+// Category returns the name of the category the index falls into
+func (s *AQIScale) Category(index float64) string {
+	for _, c := range s.Categories {
+		if index <= c.Max {
+			return c.Name
+		}
+	}
+
+	return s.Categories[len(s.Categories)-1].Name
+}
+
+// SubIndex converts a concentration given in the canonical unit of the
+// pollutant into its sub-index, false if the scale does not cover the pollutant.
+// Concentrations above the highest breakpoint are extrapolated from the last
+// segment rather than capped, so extreme readings stay distinguishable.
+func (s *AQIScale) SubIndex(pollutant string, concentration float64) (AQISubIndex, bool) {
+	p, ok := s.Pollutants[pollutant]
+	if !ok {
+		return AQISubIndex{}, false
+	}
+
+	c := toAQIUnit(pollutant, concentration, p.Unit)
+	scale := math.Pow(10, float64(p.Decimals))
+	c = math.Max(0, math.Trunc(c*scale)/scale)
+
+	bp := p.Breakpoints[len(p.Breakpoints)-1]
+	for _, b := range p.Breakpoints {
+		if c <= b.CHigh {
+			bp = b
+			break
+		}
+	}
+
+	index := bp.ILow
+	if bp.IHigh != bp.ILow {
+		index = (bp.IHigh-bp.ILow)/(bp.CHigh-bp.CLow)*(c-bp.CLow) + bp.ILow
+	}
+	if s.Integer {
+		index = math.Round(index)
+	}
+
+	return AQISubIndex{
+		Pollutant:     pollutant,
+		Concentration: c,
+		Unit:          p.Unit,
+		Index:         index,
+		Category:      s.Category(index),
+	}, true
+}
+
+// Compute calculates the sub-index of every pollutant covered by the scale
+// and the overall index, which is the highest sub-index. Pollutants the scale
+// does not cover are ignored. ok is false if no pollutant is covered.
+func (s *AQIScale) Compute(concentrations map[string]float64) (result AQIResult, ok bool) {
+	result.Scale = s.Name
+	for pollutant, c := range concentrations {
+		sub, covered := s.SubIndex(pollutant, c)
+		if !covered {
+			continue
+		}
+		result.SubIndices = append(result.SubIndices, sub)
+	}
+
+	if len(result.SubIndices) == 0 {
+		return result, false
+	}
+
+	// The map is iterated in random order, ties go to the pollutant name so
+	// the dominant pollutant does not change between calls
+	sort.Slice(result.SubIndices, func(i, j int) bool {
+		a, b := result.SubIndices[i], result.SubIndices[j]
+		if a.Index != b.Index {
+			return a.Index > b.Index
+		}
+		return a.Pollutant < b.Pollutant
+	})
+
+	result.Index = result.SubIndices[0].Index
+	result.Dominant = result.SubIndices[0].Pollutant
+	result.Category = s.Category(result.Index)
+
+	return result, true
+}
+
+// toAQIUnit converts a concentration from the canonical unit of the pollutant
+// to the unit used by a breakpoint table, at the reference conditions of the EPA
+func toAQIUnit(pollutant string, value float64, unit string) float64 {
+	converted, err := ConvertUnit(pollutant, value, CanonicalUnit(pollutant), unit, StandardConditions)
+	if err != nil {
+		// The tables only use units their pollutants can be converted to
+		return value
+	}
+
+	return converted
+}
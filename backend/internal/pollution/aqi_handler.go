@@ -0,0 +1,202 @@
+package pollution
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/AkifSahn/pollution-tracker/internal/database"
+	"github.com/gofiber/fiber/v2"
+)
+
+// parseAQIScale reads the scale query parameter, the second value is false
+// for an unknown scale
+func parseAQIScale(c *fiber.Ctx) (*AQIScale, bool) {
+	scale, ok := AQIScales[c.Query("scale", DefaultAQIScale)]
+	return scale, ok
+}
+
+// GetAQIScales
+//
+//	@Summary		Gets AQI scales
+//	@Description	Gets the supported AQI scales with their categories and breakpoint units
+//	@Tags			aqi
+//	@Produce		json
+//
+//	@Success		200	{object}	map[string]any	"AQI scales"
+//	@Router			/api/aqi/scales [get]
+func GetAQIScales(c *fiber.Ctx) error {
+	var scales []fiber.Map
+	for _, name := range AQIScaleNames() {
+		scale := AQIScales[name]
+
+		var categories []string
+		for _, category := range scale.Categories {
+			categories = append(categories, category.Name)
+		}
+
+		pollutants := fiber.Map{}
+		for pollutant, p := range scale.Pollutants {
+			pollutants[pollutant] = fiber.Map{
+				"unit":      p.Unit,
+				"averaging": p.Averaging.String(),
+			}
+		}
+
+		scales = append(scales, fiber.Map{
+			"name":        scale.Name,
+			"description": scale.Description,
+			"categories":  categories,
+			"pollutants":  pollutants,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"data": scales,
+	})
+}
+
+// GetAQIOfLocation
+//
+//	@Summary		Gets the AQI of a location
+//	@Description	Computes the air quality index around a location from the readings within the radius.
+//	@Description	By default every pollutant is averaged over the averaging period the scale defines for it, ending at `to`.
+//	@Description	If `from` is given, every pollutant is averaged over the whole range instead.
+//	@Tags			aqi
+//	@Produce		json
+//
+//	@Param			latitude	path		string					true	"latitude"
+//	@Param			longitude	path		string					true	"longitude"
+//	@Param			from		query		string					false	"Start time, overrides the averaging periods of the scale"
+//	@Param			to			query		string					false	"End time, defaults to now"
+//	@Param			tz			query		string					false	"IANA time zone of times without an offset"	default(UTC)
+//	@Param			radius		query		float64					false	"Radius in km"	default(5)
+//	@Param			scale		query		string					false	"AQI scale: epa, caqi, daqi"	default(epa)
+//
+//	@Failure		400			{object}	map[string]string		"Invalid params"
+//	@Failure		404			{object}	map[string]string		"No readings to compute the AQI from"
+//	@Failure		500			{object}	map[string]string		"Failed to fetch pollution entries from database"
+//	@Success		200			{object}	map[string]AQIResult	"AQI"
+//	@Router			/api/aqi/{latitude}/{longitude} [get]
+func GetAQIOfLocation(c *fiber.Ctx) error {
+	var latitude, longitude float64
+	ok, msg := ParseLatLon(c.Params("latitude"), c.Params("longitude"), &latitude, &longitude)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	scale, ok := parseAQIScale(c)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Incorrect scale, expected one of " + strings.Join(AQIScaleNames(), ", "),
+		})
+	}
+
+	radius := c.QueryFloat("radius", 5)
+	if radius <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Radius must be positive!",
+		})
+	}
+
+	repo := NewPollutionRepo(database.DBPool)
+	return writeAQI(c, scale, func(ctx context.Context, from, to time.Time) (map[string]float64, error) {
+		return repo.GetPollutantAveragesNear(ctx, latitude, longitude, radius, from, to)
+	})
+}
+
+// WriteAreaAQI responds with the air quality index inside the geometry, read
+// from the same query parameters as the AQI of a location except radius.
+func WriteAreaAQI(c *fiber.Ctx, geometry string) error {
+	if _, err := ParseAreaGeometry([]byte(geometry)); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Incorrect area, geometry " + err.Error(),
+		})
+	}
+
+	scale, ok := parseAQIScale(c)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Incorrect scale, expected one of " + strings.Join(AQIScaleNames(), ", "),
+		})
+	}
+
+	repo := NewPollutionRepo(database.DBPool)
+	return writeAQI(c, scale, func(ctx context.Context, from, to time.Time) (map[string]float64, error) {
+		return repo.GetPollutantAveragesOfArea(ctx, geometry, from, to)
+	})
+}
+
+// writeAQI computes the index from the averages of the pollutants. By default
+// every pollutant is averaged over the period the scale defines for it, ending
+// at the to query parameter. If from is given, the whole range is averaged.
+func writeAQI(c *fiber.Ctx, scale *AQIScale, averagesOf func(ctx context.Context, from, to time.Time) (map[string]float64, error)) error {
+	fromStr := c.Query("from")
+	toStr := c.Query("to", "now")
+
+	loc, msg := ParseTimeZone(c.Query("tz"))
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	var from, to time.Time
+	if fromStr != "" {
+		if ok, msg := ParseTimeRange(fromStr, toStr, loc, MaxRawSpan, &from, &to); !ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": msg,
+			})
+		}
+	} else {
+		var err error
+		if to, err = ParseTime(toStr, loc, time.Now()); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Incorrect to, " + timeFormatsHelp,
+			})
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	concentrations := make(map[string]float64)
+	if fromStr != "" {
+		averages, err := averagesOf(ctx, from, to)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to fetch averages from database: " + err.Error(),
+			})
+		}
+		concentrations = averages
+	} else {
+		// One query per averaging period, keeping the pollutants averaged over it
+		for _, period := range scale.AveragingPeriods() {
+			averages, err := averagesOf(ctx, to.Add(-period), to)
+			if err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": "Failed to fetch averages from database: " + err.Error(),
+				})
+			}
+
+			for pollutant, avg := range averages {
+				if p, ok := scale.Pollutants[pollutant]; ok && p.Averaging == period {
+					concentrations[pollutant] = avg
+				}
+			}
+		}
+	}
+
+	result, ok := scale.Compute(concentrations)
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "No readings to compute the AQI from",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"data": result,
+	})
+}
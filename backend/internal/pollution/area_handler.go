@@ -0,0 +1,166 @@
+package pollution
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/AkifSahn/pollution-tracker/internal/database"
+	"github.com/gofiber/fiber/v2"
+)
+
+// PostPollutionDensityOfArea
+//
+//	@Summary		Gets statistics of an area
+//	@Description	Aggregates the readings inside a GeoJSON Polygon or MultiPolygon per time bucket and pollutant.
+//	@Description	The body is either the geometry itself or a Feature wrapping it.
+//	@Tags			pollutions
+//	@Accept			json
+//	@Produce		json
+//
+//	@Param			area		body		object					true	"GeoJSON Polygon, MultiPolygon or Feature"
+//	@Param			from		query		string					false	"Start time, defaults to 24 hours ago"
+//	@Param			to			query		string					false	"End time, defaults to now"
+//	@Param			tz			query		string					false	"IANA time zone of times without an offset and of the returned times"	default(UTC)
+//	@Param			step		query		string					false	"Bucket size as a duration, e.g. 15m, 1h, or auto"	default(1h)
+//	@Param			points		query		int						false	"Number of buckets an auto step aims for, 1-10000"	default(200)
+//	@Param			pollutant	query		string					false	"Pollutant"
+//	@Param			unit		query		string					false	"Unit to return the values in: µg/m³, mg/m³, ppb, ppm"
+//
+//	@Failure		400			{object}	map[string]string		"Invalid params or geometry"
+//	@Failure		500			{object}	map[string]string		"Failed to fetch statistics from database"
+//	@Success		200			{object}	[]AreaStatistics		"Statistics per bucket and pollutant"
+//	@Router			/api/pollutions/density/area [post]
+func PostPollutionDensityOfArea(c *fiber.Ctx) error {
+	geometry, err := ParseAreaGeometry(c.Body())
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Incorrect area, body " + err.Error(),
+		})
+	}
+
+	return WriteAreaStatistics(c, string(geometry))
+}
+
+// GetPollutionDensityOfRegion
+//
+//	@Summary		Gets statistics of a saved region
+//	@Description	Aggregates the readings inside the boundary of a saved region per time bucket and pollutant.
+//	@Tags			pollutions
+//	@Produce		json
+//
+//	@Param			region		query		int						true	"Region id"
+//	@Param			from		query		string					false	"Start time, defaults to 24 hours ago"
+//	@Param			to			query		string					false	"End time, defaults to now"
+//	@Param			tz			query		string					false	"IANA time zone of times without an offset and of the returned times"	default(UTC)
+//	@Param			step		query		string					false	"Bucket size as a duration, e.g. 15m, 1h, or auto"	default(1h)
+//	@Param			points		query		int						false	"Number of buckets an auto step aims for, 1-10000"	default(200)
+//	@Param			pollutant	query		string					false	"Pollutant"
+//	@Param			unit		query		string					false	"Unit to return the values in: µg/m³, mg/m³, ppb, ppm"
+//
+//	@Failure		400			{object}	map[string]string		"Invalid params"
+//	@Failure		404			{object}	map[string]string		"Region not found"
+//	@Failure		500			{object}	map[string]string		"Failed to fetch statistics from database"
+//	@Success		200			{object}	[]AreaStatistics		"Statistics per bucket and pollutant"
+//	@Router			/api/pollutions/density/area [get]
+func GetPollutionDensityOfRegion(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Query("region"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Incorrect region id!",
+		})
+	}
+
+	repo := NewPollutionRepo(database.DBPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	geometry, found, err := repo.GetRegionBoundary(ctx, id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch region from database: " + err.Error(),
+		})
+	}
+	if !found {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Region not found",
+		})
+	}
+
+	return WriteAreaStatistics(c, geometry)
+}
+
+// WriteAreaStatistics reads the query parameters of the area endpoints and
+// responds with the statistics of the geometry. It is shared with the region
+// endpoints, which look the geometry up by the region id.
+func WriteAreaStatistics(c *fiber.Ctx, geometry string) error {
+	// A malformed geometry would fail inside the query
+	if _, err := ParseAreaGeometry([]byte(geometry)); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Incorrect area, geometry " + err.Error(),
+		})
+	}
+
+	fromStr := c.Query("from", "now-24h")
+	toStr := c.Query("to", "now")
+	pollutant := c.Query("pollutant")
+
+	loc, msg := ParseTimeZone(c.Query("tz"))
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	var from, to time.Time
+	ok, msg := ParseTimeRange(fromStr, toStr, loc, MaxRollupSpan, &from, &to)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	step, msg := ParseStep(c.Query("step", "1h"), from, to, c.QueryInt("points", DefaultStepPoints))
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	if msg := checkRawSpan(from, to, step, AggAvg, AreaExtent(geometry)); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	unit, msg := ParseUnitParam(c.Query("unit"), pollutant)
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	repo := NewPollutionRepo(database.DBPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	stats, err := repo.GetAreaStatistics(ctx, geometry, from, to, step, pollutant)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch area statistics from database: " + err.Error(),
+		})
+	}
+
+	for i := range stats {
+		s := &stats[i]
+		s.Avg, s.Unit = ConvertValue(s.Pollutant, s.Avg, unit)
+		s.Min, _ = ConvertValue(s.Pollutant, s.Min, unit)
+		s.Max, _ = ConvertValue(s.Pollutant, s.Max, unit)
+		s.StdDev, _ = ConvertValue(s.Pollutant, s.StdDev, unit)
+	}
+	InLocation(stats, loc)
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"data": stats,
+	})
+}
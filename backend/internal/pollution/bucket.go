@@ -0,0 +1,116 @@
+package pollution
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// StepAuto picks the bucket size from the time range and the number of points
+const StepAuto = "auto"
+
+const (
+	// DefaultStepPoints is how many buckets an auto step aims for
+	DefaultStepPoints = 200
+	// maxBuckets caps the number of time buckets of a query
+	maxBuckets = 10000
+)
+
+// niceSteps are the bucket sizes an auto step picks from, they line up with
+// the rollups
+var niceSteps = []time.Duration{
+	time.Minute,
+	5 * time.Minute,
+	10 * time.Minute,
+	15 * time.Minute,
+	30 * time.Minute,
+	time.Hour,
+	3 * time.Hour,
+	6 * time.Hour,
+	12 * time.Hour,
+	24 * time.Hour,
+	7 * 24 * time.Hour,
+}
+
+// AutoStep returns the smallest nice step that splits the time range into at
+// most points buckets, whole weeks past the nice steps
+func AutoStep(from, to time.Time, points int) time.Duration {
+	span := to.Sub(from)
+	for _, step := range niceSteps {
+		if span/step < time.Duration(points) {
+			return step
+		}
+	}
+
+	week := niceSteps[len(niceSteps)-1]
+	return (span/time.Duration(points)/week + 1) * week
+}
+
+// ParseStep parses a step parameter, either a duration or auto. The message
+// is set if the step is incorrect or too small for the time range.
+func ParseStep(str string, from, to time.Time, points int) (time.Duration, string) {
+	if str == StepAuto {
+		if points <= 0 || points > maxBuckets {
+			return 0, fmt.Sprintf("Incorrect points, expected 1-%d", maxBuckets)
+		}
+		return AutoStep(from, to, points), ""
+	}
+
+	step, err := time.ParseDuration(str)
+	if err != nil || step <= 0 {
+		return 0, "Incorrect step, expected auto or a positive duration like 15m or 1h"
+	}
+	if to.Sub(from)/step > maxBuckets {
+		return 0, fmt.Sprintf("Step is too small for the time range, at most %d buckets are allowed", maxBuckets)
+	}
+
+	return step, ""
+}
+
+// Aggregates computed over the readings of a bucket
+const (
+	AggAvg    = "avg"
+	AggMin    = "min"
+	AggMax    = "max"
+	AggP50    = "p50"
+	AggP95    = "p95"
+	AggCount  = "count"
+	AggStdDev = "stddev"
+)
+
+var Aggregates = []string{AggAvg, AggMin, AggMax, AggP50, AggP95, AggCount, AggStdDev}
+
+// ParseAggregate parses an agg parameter, an empty one is the average. The
+// message is set if the aggregate is unknown.
+func ParseAggregate(str string) (string, string) {
+	if str == "" {
+		return AggAvg, ""
+	}
+
+	for _, agg := range Aggregates {
+		if strings.EqualFold(str, agg) {
+			return agg, ""
+		}
+	}
+
+	return "", "Incorrect agg, expected one of " + strings.Join(Aggregates, ", ")
+}
+
+// IsConcentration reports whether the aggregate is a concentration, which has
+// a unit and an AQI sub-index
+func IsConcentration(agg string) bool {
+	return agg != AggCount && agg != AggStdDev
+}
+
+// ParsePollutants splits a comma separated pollutant parameter, an empty one
+// selects all pollutants
+func ParsePollutants(str string) []string {
+	var pollutants []string
+	for _, p := range strings.Split(str, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			pollutants = append(pollutants, p)
+		}
+	}
+
+	return pollutants
+}
@@ -0,0 +1,198 @@
+package pollution
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Detection is the outcome of a single detector for a reading
+type Detection struct {
+	Detector  string  `json:"detector"`
+	Triggered bool    `json:"-"`
+	Score     float64 `json:"score"`
+	Reason    string  `json:"reason"`
+
+	// Baseline the reading was compared with and the limit Score was compared to
+	Mean      float64 `json:"mean"`
+	StdDev    float64 `json:"stddev"`
+	Threshold float64 `json:"threshold"`
+}
+
+// Detector decides whether a reading is an anomaly. Detectors must not modify
+// the entry, several of them are evaluated for the same reading.
+type Detector interface {
+	Name() string
+	Detect(ctx context.Context, entry Pollution) (Detection, error)
+}
+
+// ZScoreDetector flags readings that are more than Threshold standard
+// deviations away from the mean of the readings around them.
+type ZScoreDetector struct {
+	Repo      PollutionRepo
+	Window    time.Duration
+	RadiusKm  float64
+	Threshold float64
+}
+
+func (d *ZScoreDetector) Name() string { return DetectorZScore }
+
+func (d *ZScoreDetector) Detect(ctx context.Context, entry Pollution) (Detection, error) {
+	stats, err := d.Repo.GetValueStats(ctx, entry.Pollutant, d.RadiusKm, entry.Latitude, entry.Longitude, entry.Time.Add(-d.Window), entry.Time)
+	if err != nil {
+		return Detection{}, fmt.Errorf("failed to get mean and std: %s", err.Error())
+	}
+	mean, stddev := stats.Mean, stats.StdDev
+
+	var zscore float64
+	if stddev > 0 {
+		zscore = (entry.Value - mean) / stddev
+	}
+
+	return Detection{
+		Detector:  d.Name(),
+		Triggered: math.Abs(zscore) > d.Threshold,
+		Score:     zscore,
+		Reason:    fmt.Sprintf("z-score %.2f against mean %.2f and stddev %.2f of the last %s within %g km", zscore, mean, stddev, d.Window, d.RadiusKm),
+		Mean:      mean,
+		StdDev:    stddev,
+		Threshold: d.Threshold,
+	}, nil
+}
+
+// ThresholdDetector flags readings at or above a fixed value
+type ThresholdDetector struct {
+	Limit float64
+}
+
+func (d *ThresholdDetector) Name() string { return DetectorThreshold }
+
+func (d *ThresholdDetector) Detect(ctx context.Context, entry Pollution) (Detection, error) {
+	return Detection{
+		Detector:  d.Name(),
+		Triggered: entry.Value >= d.Limit,
+		Score:     entry.Value,
+		Reason:    fmt.Sprintf("value %.2f against limit %.2f", entry.Value, d.Limit),
+		Threshold: d.Limit,
+	}, nil
+}
+
+// RateOfChangeDetector flags readings that increased more than
+// MaxIncreasePercent compared to the previous reading nearby.
+type RateOfChangeDetector struct {
+	Repo               PollutionRepo
+	Window             time.Duration
+	RadiusKm           float64
+	MaxIncreasePercent float64
+}
+
+func (d *RateOfChangeDetector) Name() string { return DetectorRateOfChange }
+
+func (d *RateOfChangeDetector) Detect(ctx context.Context, entry Pollution) (Detection, error) {
+	previous, found, err := d.Repo.GetPreviousValue(ctx, entry.Pollutant, d.RadiusKm, entry.Latitude, entry.Longitude, entry.Time.Add(-d.Window), entry.Time)
+	if err != nil {
+		return Detection{}, fmt.Errorf("failed to get previous value: %s", err.Error())
+	}
+
+	if !found || previous <= 0 {
+		return Detection{Detector: d.Name(), Reason: "no previous reading to compare with"}, nil
+	}
+
+	increase := (entry.Value - previous) / previous * 100
+	return Detection{
+		Detector:  d.Name(),
+		Triggered: increase > d.MaxIncreasePercent,
+		Score:     increase,
+		Reason:    fmt.Sprintf("%.1f%% change from the previous reading %.2f, limit %.1f%%", increase, previous, d.MaxIncreasePercent),
+		Mean:      previous,
+		Threshold: d.MaxIncreasePercent,
+	}, nil
+}
+
+// EWMADetector keeps an exponentially weighted moving average and variance of
+// the recent readings nearby and flags readings more than Threshold weighted
+// standard deviations away from the average. Recent readings weigh more than
+// in ZScoreDetector, so it adapts faster to slow trends.
+type EWMADetector struct {
+	Repo      PollutionRepo
+	Window    time.Duration
+	RadiusKm  float64
+	Alpha     float64
+	Threshold float64
+}
+
+// ewmaMinSamples is the number of readings needed before the EWMA is trusted
+const ewmaMinSamples = 5
+
+func (d *EWMADetector) Name() string { return DetectorEWMA }
+
+func (d *EWMADetector) Detect(ctx context.Context, entry Pollution) (Detection, error) {
+	values, err := d.Repo.GetValues(ctx, entry.Pollutant, d.RadiusKm, entry.Latitude, entry.Longitude, entry.Time.Add(-d.Window), entry.Time)
+	if err != nil {
+		return Detection{}, fmt.Errorf("failed to get values: %s", err.Error())
+	}
+
+	if len(values) < ewmaMinSamples {
+		return Detection{Detector: d.Name(), Reason: "not enough readings for a moving average"}, nil
+	}
+
+	mean, variance := values[0].Value, 0.0
+	for _, v := range values[1:] {
+		diff := v.Value - mean
+		mean += d.Alpha * diff
+		variance = (1 - d.Alpha) * (variance + d.Alpha*diff*diff)
+	}
+
+	var score float64
+	if variance > 0 {
+		score = (entry.Value - mean) / math.Sqrt(variance)
+	}
+
+	return Detection{
+		Detector:  d.Name(),
+		Triggered: math.Abs(score) > d.Threshold,
+		Score:     score,
+		Reason:    fmt.Sprintf("%.2f weighted deviations from the moving average %.2f", score, mean),
+		Mean:      mean,
+		StdDev:    math.Sqrt(variance),
+		Threshold: d.Threshold,
+	}, nil
+}
+
+// SeasonalDetector compares a reading with the readings taken at the same hour
+// of the day over the last Days days, so daily cycles like rush hours are not
+// reported as anomalies.
+type SeasonalDetector struct {
+	Repo      PollutionRepo
+	Days      int
+	RadiusKm  float64
+	Threshold float64
+}
+
+func (d *SeasonalDetector) Name() string { return DetectorSeasonal }
+
+func (d *SeasonalDetector) Detect(ctx context.Context, entry Pollution) (Detection, error) {
+	hour := entry.Time.UTC().Hour()
+	from := entry.Time.AddDate(0, 0, -d.Days)
+	stats, err := d.Repo.GetHourOfDayStats(ctx, entry.Pollutant, d.RadiusKm, entry.Latitude, entry.Longitude, hour, from, entry.Time)
+	if err != nil {
+		return Detection{}, fmt.Errorf("failed to get hourly mean and std: %s", err.Error())
+	}
+	mean, stddev := stats.Mean, stats.StdDev
+
+	var zscore float64
+	if stddev > 0 {
+		zscore = (entry.Value - mean) / stddev
+	}
+
+	return Detection{
+		Detector:  d.Name(),
+		Triggered: math.Abs(zscore) > d.Threshold,
+		Score:     zscore,
+		Reason:    fmt.Sprintf("z-score %.2f against the %02d:00 UTC baseline %.2f of the last %d days", zscore, hour, mean, d.Days),
+		Mean:      mean,
+		StdDev:    stddev,
+		Threshold: d.Threshold,
+	}, nil
+}
@@ -0,0 +1,354 @@
+package pollution
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/AkifSahn/pollution-tracker/internal/database"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// maxEstimateRadius caps the search radius of the samples in km
+	maxEstimateRadius = 500
+	// maxEstimateNeighbors caps the samples an estimate is computed from, the
+	// kriging system grows with the square of it
+	maxEstimateNeighbors = 64
+	// maxEstimateSamples caps the sensors fetched for an estimate or surface
+	maxEstimateSamples = 2000
+	// maxSurfaceCells caps the number of cells of a surface
+	maxSurfaceCells = 10000
+
+	kmPerDegree = 111.32
+)
+
+// parseInterpolator reads the method, radius, neighbors and power query
+// parameters shared by the estimate endpoints
+func parseInterpolator(c *fiber.Ctx) (*Interpolator, string) {
+	in := &Interpolator{
+		Method:    c.Query("method", InterpolationIDW),
+		RadiusKm:  c.QueryFloat("radius", 25),
+		Neighbors: c.QueryInt("neighbors", 12),
+		Power:     c.QueryFloat("power", 2),
+	}
+
+	if !slices.Contains(InterpolationMethods, in.Method) {
+		return nil, "Incorrect method, expected one of " + strings.Join(InterpolationMethods, ", ")
+	}
+	if in.RadiusKm <= 0 || in.RadiusKm > maxEstimateRadius {
+		return nil, fmt.Sprintf("Radius must be in (0, %d] km", maxEstimateRadius)
+	}
+	if in.Neighbors <= 0 || in.Neighbors > maxEstimateNeighbors {
+		return nil, fmt.Sprintf("Neighbors must be between 1 and %d", maxEstimateNeighbors)
+	}
+	if in.Power <= 0 || in.Power > 10 {
+		return nil, "Power must be in (0, 10]"
+	}
+
+	return in, ""
+}
+
+// expandBounds grows the bounds by radiusKm on every side, so locations near
+// the edges still see the samples just outside them
+func expandBounds(bounds Bounds, radiusKm float64) Bounds {
+	dLat := radiusKm / kmPerDegree
+	bounds.LatFrom = math.Max(-90, bounds.LatFrom-dLat)
+	bounds.LatTo = math.Min(90, bounds.LatTo+dLat)
+
+	// A degree of longitude is shortest at the latitude closest to a pole
+	maxLat := math.Max(math.Abs(bounds.LatFrom), math.Abs(bounds.LatTo))
+	span := bounds.LongTo - bounds.LongFrom
+	if span < 0 {
+		span += 360
+	}
+
+	cos := math.Cos(maxLat * math.Pi / 180)
+	if cos < 1e-3 {
+		bounds.LongFrom, bounds.LongTo = -180, 180
+		return bounds
+	}
+	dLon := radiusKm / (kmPerDegree * cos)
+	if span+2*dLon >= 360 {
+		bounds.LongFrom, bounds.LongTo = -180, 180
+		return bounds
+	}
+
+	bounds.LongFrom = wrapLongitude(bounds.LongFrom - dLon)
+	bounds.LongTo = wrapLongitude(bounds.LongTo + dLon)
+
+	return bounds
+}
+
+func wrapLongitude(longitude float64) float64 {
+	if longitude < -180 {
+		return longitude + 360
+	}
+	if longitude > 180 {
+		return longitude - 360
+	}
+	return longitude
+}
+
+// fetchSamples reads the time range, fetches the latest sample of every sensor
+// inside the bounds and fits the interpolator to them. On failure the response
+// is written and false returned.
+func fetchSamples(c *fiber.Ctx, in *Interpolator, bounds Bounds, pollutant string) (int, bool, error) {
+	fromStr := c.Query("from", "now-3h")
+	toStr := c.Query("to", "now")
+
+	loc, msg := ParseTimeZone(c.Query("tz"))
+	if msg != "" {
+		return 0, false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	var from, to time.Time
+	ok, msg := ParseTimeRange(fromStr, toStr, loc, MaxEstimateSpan, &from, &to)
+	if !ok {
+		return 0, false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	repo := NewPollutionRepo(database.DBPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	samples, err := repo.GetLatestSamples(ctx, expandBounds(bounds, in.RadiusKm), from, to, pollutant, maxEstimateSamples+1)
+	if err != nil {
+		return 0, false, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch samples from database: " + err.Error(),
+		})
+	}
+
+	if len(samples) > maxEstimateSamples {
+		return 0, false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("More than %d sensors around the area, use a smaller radius or smaller bounds", maxEstimateSamples),
+		})
+	}
+
+	if err := in.Fit(samples); err != nil {
+		return 0, false, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": fmt.Sprintf("Only %d sensors reported %s in the time range, %s", len(samples), pollutant, err.Error()),
+		})
+	}
+
+	return len(samples), true, nil
+}
+
+// GetPollutionEstimate
+//
+//	@Summary		Estimates the pollution at a location
+//	@Description	Interpolates the concentration of a pollutant at a location from the latest value of the sensors
+//	@Description	around it. method=idw weights the closest `neighbors` sensors within the radius by the inverse of
+//	@Description	their distance to the power, method=kriging uses ordinary kriging with an exponential variogram
+//	@Description	fitted to the sensors and also returns the kriging variance.
+//	@Tags			pollutions
+//	@Produce		json
+//
+//	@Param			lat			query		float64				true	"Latitude"
+//	@Param			lon			query		float64				true	"Longitude"
+//	@Param			pollutant	query		string				true	"Pollutant"
+//	@Param			method		query		string				false	"idw or kriging"	default(idw)
+//	@Param			radius		query		float64				false	"Radius of the sensors used, in km"	default(25)
+//	@Param			neighbors	query		int					false	"Maximum number of closest sensors used"	default(12)
+//	@Param			power		query		float64				false	"Distance exponent of IDW"	default(2)
+//	@Param			from		query		string				false	"Start time of the latest readings, defaults to 3 hours ago"
+//	@Param			to			query		string				false	"End time of the latest readings, defaults to now"
+//	@Param			tz			query		string				false	"IANA time zone of times without an offset"	default(UTC)
+//	@Param			unit		query		string				false	"Unit to return the value in: µg/m³, mg/m³, ppb, ppm"
+//
+//	@Failure		400			{object}	map[string]string	"Invalid params"
+//	@Failure		404			{object}	map[string]string	"Not enough sensors around the location"
+//	@Failure		500			{object}	map[string]string	"Failed to fetch samples from database"
+//	@Success		200			{object}	map[string]any		"Estimate"
+//	@Router			/api/pollutions/estimate [get]
+func GetPollutionEstimate(c *fiber.Ctx) error {
+	var latitude, longitude float64
+	ok, msg := ParseLatLon(c.Query("lat"), c.Query("lon"), &latitude, &longitude)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	pollutant := c.Query("pollutant")
+	if pollutant == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Pollutant is required!",
+		})
+	}
+
+	in, msg := parseInterpolator(c)
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	unit, msg := ParseUnitParam(c.Query("unit"), pollutant)
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	point := Bounds{LatFrom: latitude, LatTo: latitude, LongFrom: longitude, LongTo: longitude}
+	if _, ok, err := fetchSamples(c, in, point, pollutant); !ok {
+		return err
+	}
+
+	estimate, ok := in.Estimate(latitude, longitude)
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": fmt.Sprintf("Not enough sensors reported %s within %g km", pollutant, in.RadiusKm),
+		})
+	}
+
+	// Conversions are linear, the variance scales with the square of the factor
+	factor, unit := ConvertValue(pollutant, 1, unit)
+	estimate.Value *= factor
+	estimate.Unit = unit
+	if estimate.Variance != nil {
+		*estimate.Variance *= factor * factor
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"data": estimate,
+	})
+}
+
+// GetPollutionSurface
+//
+//	@Summary		Estimates a pollution surface
+//	@Description	Interpolates the concentration of a pollutant at the centre of every cell of a grid over the bounds,
+//	@Description	with the same methods as /api/pollutions/estimate. Cells start at the south-west corner of the
+//	@Description	bounds, the cell size follows the web map zoom level or is given in degrees with cell_size.
+//	@Description	Cells without enough sensors around them are null.
+//	@Tags			pollutions
+//	@Produce		json
+//
+//	@Param			latFrom		query		float64				true	"Southern edge of the area"
+//	@Param			latTo		query		float64				true	"Northern edge of the area"
+//	@Param			longFrom	query		float64				true	"Western edge of the area"
+//	@Param			longTo		query		float64				true	"Eastern edge of the area"
+//	@Param			pollutant	query		string				true	"Pollutant"
+//	@Param			zoom		query		int					false	"Map zoom level, 0-18"	default(5)
+//	@Param			cell_size	query		float64				false	"Cell size in degrees, overrides zoom"
+//	@Param			method		query		string				false	"idw or kriging"	default(idw)
+//	@Param			radius		query		float64				false	"Radius of the sensors used, in km"	default(25)
+//	@Param			neighbors	query		int					false	"Maximum number of closest sensors used"	default(12)
+//	@Param			power		query		float64				false	"Distance exponent of IDW"	default(2)
+//	@Param			from		query		string				false	"Start time of the latest readings, defaults to 3 hours ago"
+//	@Param			to			query		string				false	"End time of the latest readings, defaults to now"
+//	@Param			tz			query		string				false	"IANA time zone of times without an offset"	default(UTC)
+//	@Param			unit		query		string				false	"Unit to return the values in: µg/m³, mg/m³, ppb, ppm"
+//
+//	@Failure		400			{object}	map[string]string	"Invalid params or too many cells"
+//	@Failure		404			{object}	map[string]string	"Not enough sensors around the area"
+//	@Failure		500			{object}	map[string]string	"Failed to fetch samples from database"
+//	@Success		200			{object}	map[string]any		"Surface of the estimates"
+//	@Router			/api/pollutions/estimate/grid [get]
+func GetPollutionSurface(c *fiber.Ctx) error {
+	bounds, msg := parseBounds(c)
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+	if bounds == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "latFrom, latTo, longFrom and longTo are required!",
+		})
+	}
+
+	pollutant := c.Query("pollutant")
+	if pollutant == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Pollutant is required!",
+		})
+	}
+
+	zoom := c.QueryInt("zoom", 5)
+	if zoom < 0 || zoom > maxGridZoom {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Zoom must be between 0 and %d", maxGridZoom),
+		})
+	}
+
+	cellSize := c.QueryFloat("cell_size", GridCellSize(zoom))
+	if cellSize <= 0 || cellSize > 90 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Cell size must be in (0, 90] degrees",
+		})
+	}
+
+	span := bounds.LongTo - bounds.LongFrom
+	if span < 0 {
+		span += 360
+	}
+	rows := max(1, int(math.Ceil((bounds.LatTo-bounds.LatFrom)/cellSize)))
+	cols := max(1, int(math.Ceil(span/cellSize)))
+	if rows*cols > maxSurfaceCells {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("More than %d cells, use a lower zoom, a larger cell size or smaller bounds", maxSurfaceCells),
+		})
+	}
+
+	in, msg := parseInterpolator(c)
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	unit, msg := ParseUnitParam(c.Query("unit"), pollutant)
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	samples, ok, err := fetchSamples(c, in, *bounds, pollutant)
+	if !ok {
+		return err
+	}
+
+	factor, unit := ConvertValue(pollutant, 1, unit)
+
+	surface := Surface{
+		LatFrom:   bounds.LatFrom,
+		LatTo:     bounds.LatTo,
+		LongFrom:  bounds.LongFrom,
+		LongTo:    bounds.LongTo,
+		Rows:      rows,
+		Cols:      cols,
+		CellLat:   cellSize,
+		CellLon:   cellSize,
+		Values:    make([][]*float64, rows),
+		Unit:      unit,
+		Samples:   samples,
+		Method:    in.Method,
+		Pollutant: pollutant,
+	}
+	for row := range surface.Values {
+		surface.Values[row] = make([]*float64, cols)
+		latitude := math.Min(90, bounds.LatFrom+(float64(row)+0.5)*cellSize)
+		for col := range surface.Values[row] {
+			longitude := wrapLongitude(bounds.LongFrom + (float64(col)+0.5)*cellSize)
+			if estimate, ok := in.Estimate(latitude, longitude); ok {
+				value := estimate.Value * factor
+				surface.Values[row][col] = &value
+			}
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"data": surface,
+	})
+}
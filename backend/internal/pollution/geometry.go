@@ -0,0 +1,124 @@
+package pollution
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+)
+
+type geoJSONObject struct {
+	Type        string          `json:"type"`
+	Geometry    json.RawMessage `json:"geometry"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+// ParseAreaGeometry checks that the GeoJSON is a Polygon or MultiPolygon, or
+// a Feature wrapping one, and returns the geometry. Rings must be closed and
+// have at least four positions inside the longitude and latitude bounds.
+func ParseAreaGeometry(data []byte) (json.RawMessage, error) {
+	geometry, polygons, err := areaPolygons(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(polygons) == 0 {
+		return nil, errors.New("must have at least one polygon")
+	}
+	for _, polygon := range polygons {
+		if len(polygon) == 0 {
+			return nil, errors.New("polygon must have at least one ring")
+		}
+		for _, ring := range polygon {
+			if err := checkRing(ring); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return geometry, nil
+}
+
+// areaPolygons unwraps a Feature and returns the Polygon or MultiPolygon
+// geometry with its polygons
+func areaPolygons(data []byte) (json.RawMessage, [][][][]float64, error) {
+	var object geoJSONObject
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, nil, errors.New("must be GeoJSON")
+	}
+
+	if object.Type == "Feature" {
+		if len(object.Geometry) == 0 {
+			return nil, nil, errors.New("feature has no geometry")
+		}
+		return areaPolygons(object.Geometry)
+	}
+
+	var polygons [][][][]float64
+	switch object.Type {
+	case "Polygon":
+		var polygon [][][]float64
+		if err := json.Unmarshal(object.Coordinates, &polygon); err != nil {
+			return nil, nil, errors.New("coordinates of a Polygon must be a list of rings")
+		}
+		polygons = append(polygons, polygon)
+	case "MultiPolygon":
+		if err := json.Unmarshal(object.Coordinates, &polygons); err != nil {
+			return nil, nil, errors.New("coordinates of a MultiPolygon must be a list of polygons")
+		}
+	default:
+		return nil, nil, errors.New("must be a GeoJSON Polygon or MultiPolygon")
+	}
+
+	return json.RawMessage(data), polygons, nil
+}
+
+// AreaExtent returns the shorter side of the bounding box of a GeoJSON area
+// in degrees, zero if the area can not be read
+func AreaExtent(geometry string) float64 {
+	_, polygons, err := areaPolygons([]byte(geometry))
+	if err != nil {
+		return 0
+	}
+
+	latMin, latMax := math.Inf(1), math.Inf(-1)
+	lonMin, lonMax := math.Inf(1), math.Inf(-1)
+	for _, polygon := range polygons {
+		for _, ring := range polygon {
+			for _, position := range ring {
+				if len(position) < 2 {
+					return 0
+				}
+				lonMin, lonMax = min(lonMin, position[0]), max(lonMax, position[0])
+				latMin, latMax = min(latMin, position[1]), max(latMax, position[1])
+			}
+		}
+	}
+	if latMin > latMax {
+		return 0
+	}
+
+	return min(latMax-latMin, lonMax-lonMin)
+}
+
+func checkRing(ring [][]float64) error {
+	if len(ring) < 4 {
+		return errors.New("ring must have at least four positions")
+	}
+
+	for _, position := range ring {
+		if len(position) < 2 {
+			return errors.New("position must have a longitude and a latitude")
+		}
+		if position[0] < -180 || position[0] > 180 || position[1] < -90 || position[1] > 90 {
+			return fmt.Errorf("position [%g, %g] is out of bounds", position[0], position[1])
+		}
+	}
+
+	first, last := ring[0], ring[len(ring)-1]
+	if first[0] != last[0] || first[1] != last[1] {
+		return errors.New("ring must be closed")
+	}
+
+	return nil
+}
@@ -0,0 +1,158 @@
+package pollution
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/AkifSahn/pollution-tracker/internal/database"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	maxGridZoom = 18
+	// gridCellsPerTile is the number of cells along the side of a 256px map
+	// tile, so a cell is 32px wide at the zoom it was requested for
+	gridCellsPerTile = 8
+	// maxGridCells caps the number of cells of a grid response
+	maxGridCells = 20000
+)
+
+// GridCellSize returns the side of a grid cell in degrees for a web map zoom level
+func GridCellSize(zoom int) float64 {
+	return 360 / math.Exp2(float64(zoom)) / gridCellsPerTile
+}
+
+// parseBounds reads the optional latFrom, latTo, longFrom, longTo query
+// parameters, either all of them or none must be given
+func parseBounds(c *fiber.Ctx) (*Bounds, string) {
+	names := []string{"latFrom", "latTo", "longFrom", "longTo"}
+
+	var given int
+	for _, name := range names {
+		if c.Query(name) != "" {
+			given++
+		}
+	}
+	if given == 0 {
+		return nil, ""
+	}
+	if given != len(names) {
+		return nil, "latFrom, latTo, longFrom and longTo must be given together"
+	}
+
+	bounds := &Bounds{
+		LatFrom:  c.QueryFloat("latFrom"),
+		LatTo:    c.QueryFloat("latTo"),
+		LongFrom: c.QueryFloat("longFrom"),
+		LongTo:   c.QueryFloat("longTo"),
+	}
+	if bounds.LatFrom > bounds.LatTo {
+		bounds.LatFrom, bounds.LatTo = bounds.LatTo, bounds.LatFrom
+	}
+
+	return bounds, ""
+}
+
+// GetPollutionGrid
+//
+//	@Summary		Gets gridded pollution values
+//	@Description	Aggregates the readings into a square grid and returns the average, maximum and number of readings
+//	@Description	of every cell and pollutant. The cell size follows the web map zoom level, 8 cells per 256px tile,
+//	@Description	or is given in degrees with cell_size. Cells are aligned to 0,0 so they are stable while panning.
+//	@Tags			pollutions
+//	@Produce		json
+//
+//	@Param			from		query		string				false	"Start time, defaults to 24 hours ago"
+//	@Param			to			query		string				false	"End time, defaults to now"
+//	@Param			tz			query		string				false	"IANA time zone of times without an offset"	default(UTC)
+//	@Param			pollutant	query		string				false	"Pollutant"
+//	@Param			zoom		query		int					false	"Map zoom level, 0-18"	default(5)
+//	@Param			cell_size	query		float64				false	"Cell size in degrees, overrides zoom"
+//	@Param			latFrom		query		float64				false	"Southern edge of the area"
+//	@Param			latTo		query		float64				false	"Northern edge of the area"
+//	@Param			longFrom	query		float64				false	"Western edge of the area"
+//	@Param			longTo		query		float64				false	"Eastern edge of the area"
+//	@Param			unit		query		string				false	"Unit to return the values in: µg/m³, mg/m³, ppb, ppm"
+//
+//	@Failure		400			{object}	map[string]string	"Invalid params or too many cells"
+//	@Failure		500			{object}	map[string]string	"Failed to fetch grid from database"
+//	@Success		200			{object}	map[string]any		"GridCell list and the cell size"
+//	@Router			/api/pollutions/grid [get]
+func GetPollutionGrid(c *fiber.Ctx) error {
+	fromStr := c.Query("from", "now-24h")
+	toStr := c.Query("to", "now")
+	pollutant := c.Query("pollutant")
+
+	loc, msg := ParseTimeZone(c.Query("tz"))
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	var from, to time.Time
+	ok, msg := ParseTimeRange(fromStr, toStr, loc, MaxRawSpan, &from, &to)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	zoom := c.QueryInt("zoom", 5)
+	if zoom < 0 || zoom > maxGridZoom {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Zoom must be between 0 and %d", maxGridZoom),
+		})
+	}
+
+	cellSize := c.QueryFloat("cell_size", GridCellSize(zoom))
+	if cellSize <= 0 || cellSize > 90 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Cell size must be in (0, 90] degrees",
+		})
+	}
+
+	bounds, msg := parseBounds(c)
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	unit, msg := ParseUnitParam(c.Query("unit"), pollutant)
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	repo := NewPollutionRepo(database.DBPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cells, err := repo.GetGridCells(ctx, cellSize, bounds, from, to, pollutant, maxGridCells+1)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch grid from database: " + err.Error(),
+		})
+	}
+
+	if len(cells) > maxGridCells {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("More than %d cells, use a lower zoom, a larger cell size or smaller bounds", maxGridCells),
+		})
+	}
+
+	for i := range cells {
+		cell := &cells[i]
+		cell.Avg, cell.Unit = ConvertValue(cell.Pollutant, cell.Avg, unit)
+		cell.Max, _ = ConvertValue(cell.Pollutant, cell.Max, unit)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"data":      cells,
+		"cell_size": cellSize,
+	})
+}
@@ -1,13 +1,20 @@
 package pollution
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/AkifSahn/pollution-tracker/internal/database"
 	"github.com/AkifSahn/pollution-tracker/internal/rabbitmq"
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
@@ -16,13 +23,31 @@ func SetupRoutes(app *fiber.App) {
 	api := app.Group("/api")
 
 	api.Post("pollutions", PostPollutionEntry)
+	api.Post("pollutions/batch", PostPollutionBatch)
 
 	api.Get("pollutions", GetAllPolutions)
 	api.Get("pollutions/density/rect", GetPollutionDensityOfRect)
+	api.Post("pollutions/density/area", PostPollutionDensityOfArea)
+	api.Get("pollutions/density/area", GetPollutionDensityOfRegion)
+	api.Get("pollutions/grid", GetPollutionGrid)
+	api.Get("pollutions/estimate", GetPollutionEstimate)
+	api.Get("pollutions/estimate/grid", GetPollutionSurface)
+	api.Get("pollutions/near", GetPollutionsNear)
 	api.Get("pollutions/:latitude/:longitude", GetPollutionsByLatLon)
 
 	api.Get("anomalies", GetAnomaliesOfRange)
+	api.Post("anomalies/:id/acknowledge", AcknowledgeAnomaly)
+	api.Post("anomalies/:id/resolve", ResolveAnomaly)
 	api.Get("pollutants", GetPollutants)
+
+	api.Get("aqi/scales", GetAQIScales)
+	api.Get("aqi/:latitude/:longitude", GetAQIOfLocation)
+
+	api.Get("config/anomaly-rules", GetAnomalyRules)
+	api.Get("config/anomaly-rules/audit", GetAnomalyRuleAudit)
+	api.Get("config/anomaly-rules/:pollutant", GetAnomalyRule)
+	api.Put("config/anomaly-rules/:pollutant", PutAnomalyRule)
+	api.Delete("config/anomaly-rules/:pollutant", DeleteAnomalyRule)
 }
 
 // PostPollutionEntry
@@ -35,7 +60,8 @@ func SetupRoutes(app *fiber.App) {
 //	@Param			request	body		Pollution	true	"Request of adding a new pollution entry"
 //	@Success		400		{string}	string		"Failed to parse request body"
 //	@Success		400		{string}	string		"Failed to marshal request body"
-//	@Success		500		{string}	string		"Failed to publish pollution entry to RabbitMQ queue"
+//	@Failure		422		{object}	map[string]any	"Invalid pollution entry, with field level errors"
+//	@Success		503		{string}	string		"Failed to publish pollution entry to RabbitMQ queue"
 //	@Success		200		{string}	string		"Successfully received the pollution entry"
 //	@Router			/api/pollutions [post]
 func PostPollutionEntry(c *fiber.Ctx) error {
@@ -48,27 +74,45 @@ func PostPollutionEntry(c *fiber.Ctx) error {
 		})
 	}
 
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	stations := newStationResolver(NewPollutionRepo(database.DBPool))
+	errs, err := stations.resolve(ctx, &body)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to look up the station - " + err.Error(),
+		})
+	}
+
+	errs = append(errs, DefaultValidator.Check(&body, time.Now())...)
+	if errs != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error":  "Invalid pollution entry",
+			"fields": errs,
+		})
+	}
+
 	var msg []byte
-	msg, err := json.Marshal(&body)
+	msg, err = json.Marshal(&body)
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "Failed to marshal request body" + err.Error(),
 		})
 	}
 
-	err = rabbitmq.AmqpCh.Publish(
-		"",
-		"ingest_queue",
-		false,
-		false,
+	// Only report success once the broker has confirmed the message
+	err = rabbitmq.Publish(context.Background(), "", "ingest_queue",
 		amqp.Publishing{
 			ContentType: "application/json",
+			MessageId:   uuid.NewString(),
+			Timestamp:   time.Now(),
 			Body:        msg,
 		})
 
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to publish pollution entry to RabbitMQ queue",
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Failed to publish pollution entry to RabbitMQ queue - " + err.Error(),
 		})
 	}
 
@@ -77,16 +121,161 @@ func PostPollutionEntry(c *fiber.Ctx) error {
 	})
 }
 
+// PostPollutionBatch
+//
+//	@Summary		Posts a batch of pollution entries
+//	@Description	Posts multiple pollution entries at once, either as a JSON array or as NDJSON (one entry per line).
+//	@Description	Every item is validated on its own; accepted items are published to the ingest queue in chunks.
+//	@Tags			pollutions
+//	@Accept			json
+//	@Accept			application/x-ndjson
+//	@Produce		json
+//	@Param			request	body		[]Pollution			true	"Pollution entries"
+//	@Success		200		{object}	BatchReport			"Per-item accepted/rejected report"
+//	@Failure		400		{object}	map[string]string	"Failed to parse request body"
+//	@Failure		413		{object}	map[string]string	"Batch is too large"
+//	@Failure		503		{object}	BatchReport			"Failed to publish some of the entries"
+//	@Router			/api/pollutions/batch [post]
+func PostPollutionBatch(c *fiber.Ctx) error {
+	items, err := decodeBatchBody(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Failed to parse request body - " + err.Error(),
+		})
+	}
+
+	if len(items) > MaxBatchSize {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"error": fmt.Sprintf("Batch is too large, at most %d entries are allowed", MaxBatchSize),
+		})
+	}
+
+	report := BatchReport{Results: make([]BatchItemResult, len(items))}
+	var accepted []Pollution
+	var acceptedIdx []int
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	stations := newStationResolver(NewPollutionRepo(database.DBPool))
+
+	now := time.Now()
+	for i, raw := range items {
+		report.Results[i].Index = i
+
+		entry := Pollution{Time: now}
+		if err := json.Unmarshal(raw, &entry); err != nil {
+			report.Results[i].Error = "Failed to parse entry - " + err.Error()
+			continue
+		}
+
+		errs, err := stations.resolve(ctx, &entry)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to look up the station - " + err.Error(),
+			})
+		}
+
+		errs = append(errs, DefaultValidator.Check(&entry, now)...)
+		if errs != nil {
+			report.Results[i].Error = "Invalid pollution entry"
+			report.Results[i].Fields = errs
+			continue
+		}
+
+		report.Results[i].Accepted = true
+		accepted = append(accepted, entry)
+		acceptedIdx = append(acceptedIdx, i)
+	}
+
+	status := fiber.StatusOK
+	for start := 0; start < len(accepted); start += batchChunkSize {
+		end := min(start+batchChunkSize, len(accepted))
+
+		if err := publishBatch(accepted[start:end]); err != nil {
+			status = fiber.StatusServiceUnavailable
+			for _, idx := range acceptedIdx[start:end] {
+				report.Results[idx].Accepted = false
+				report.Results[idx].Error = "Failed to publish entry to RabbitMQ queue"
+			}
+		}
+	}
+
+	for _, r := range report.Results {
+		if r.Accepted {
+			report.Accepted++
+		} else {
+			report.Rejected++
+		}
+	}
+
+	return c.Status(status).JSON(report)
+}
+
+// decodeBatchBody splits the request body into raw entries. NDJSON bodies are
+// read line by line, anything else is expected to be a JSON array.
+func decodeBatchBody(c *fiber.Ctx) ([]json.RawMessage, error) {
+	contentType := strings.ToLower(string(c.Request().Header.ContentType()))
+
+	if !strings.Contains(contentType, "ndjson") {
+		var items []json.RawMessage
+		if err := json.Unmarshal(c.Body(), &items); err != nil {
+			return nil, err
+		}
+		return items, nil
+	}
+
+	var items []json.RawMessage
+	scanner := bufio.NewScanner(bytes.NewReader(c.Body()))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		items = append(items, json.RawMessage(bytes.Clone(line)))
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func publishBatch(entries []Pollution) error {
+	msg, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	return rabbitmq.Publish(context.Background(), "", "ingest_queue",
+		amqp.Publishing{
+			ContentType: "application/json",
+			Type:        BatchMessageType,
+			MessageId:   uuid.NewString(),
+			Timestamp:   time.Now(),
+			Body:        msg,
+		})
+}
+
 // GetAllPollutions
 //
 //	@Summary		Gets pollution values
-//	@Description	Gets all pollution values for given time range.
+//	@Description	Gets all pollution values for given time range, a page at a time ordered by time. If there are more values
+//	@Description	the response has the cursor of the next page in next_cursor and a link to it in next.
 //	@Tags			pollutions
 //	@Produce		json
 //
 //	@Param			from		query		string					true	"Start time"
 //	@Param			to			query		string					true	"End time"
+//	@Param			tz			query		string					false	"IANA time zone of times without an offset and of the returned times"	default(UTC)
 //	@Param			pollutant	query		string					false	"Pollutant"
+//	@Param			unit		query		string					false	"Unit to return the values in: µg/m³, mg/m³, ppb, ppm"
+//	@Param			limit		query		int						false	"Maximum number of items, 1-10000"	default(1000)
+//	@Param			order		query		string					false	"Order by time: asc or desc"	default(desc)
+//	@Param			cursor		query		string					false	"next_cursor of the previous page"
+//	@Param			fields		query		string					false	"Comma separated fields to return, e.g. time,value"
 //
 //	@Success		200			{object}	map[string][]Pollution	"Pollution values"
 //	@Failure		400			{object}	map[string]string		"Invalid params"
@@ -96,9 +285,16 @@ func GetAllPolutions(c *fiber.Ctx) error {
 	fromStr := c.Query("from")
 	toStr := c.Query("to")
 
+	loc, msg := ParseTimeZone(c.Query("tz"))
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
 	// Parse the string times into time.Time
 	var from, to time.Time
-	ok, msg := ParseTimeRange(fromStr, toStr, &from, &to)
+	ok, msg := ParseTimeRange(fromStr, toStr, loc, 0, &from, &to)
 	if !ok {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": msg,
@@ -107,26 +303,45 @@ func GetAllPolutions(c *fiber.Ctx) error {
 
 	pollutant := c.Query("pollutant")
 
+	unit, msg := ParseUnitParam(c.Query("unit"), pollutant)
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	page, msg := ParsePage[Pollution](c)
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
 	repo := NewPollutionRepo(database.DBPool)
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	pollutions, err := repo.GetAllPolutionWithinTimeRange(ctx, from, to, pollutant)
+	pollutions, err := repo.GetAllPolutionWithinTimeRange(ctx, from, to, pollutant, page)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to fetch pollution entries from database ",
 		})
 	}
 
-	return c.JSON(fiber.Map{
-		"data": pollutions,
+	for i, p := range pollutions {
+		pollutions[i].Value, pollutions[i].Unit = ConvertValue(p.Pollutant, p.Value, unit)
+	}
+	InLocation(pollutions, loc)
+
+	return WritePage(c, page, pollutions, func(p Pollution) Cursor {
+		return Cursor{Time: p.Time, ID: p.ID}
 	})
 }
 
 // GetPollutionsByLatLon
 //
 //	@Summary		Gets pollution values
-//	@Description	Gets pollution values for given location and time range
+//	@Description	Gets pollution values for given location and time range, a page at a time ordered by time
 //	@Tags			pollutions
 //	@Produce		json
 //
@@ -134,6 +349,12 @@ func GetAllPolutions(c *fiber.Ctx) error {
 //	@Param			longitude	path		string								true	"longitude"
 //	@Param			from		query		string								false	"Start time"
 //	@Param			to			query		string								false	"End time"
+//	@Param			tz			query		string								false	"IANA time zone of times without an offset and of the returned times"	default(UTC)
+//	@Param			unit		query		string								false	"Unit to return the values in: µg/m³, mg/m³, ppb, ppm"
+//	@Param			limit		query		int									false	"Maximum number of values, 1-10000"	default(1000)
+//	@Param			order		query		string								false	"Order by time: asc or desc, by pollutant and newest first if not set"
+//	@Param			cursor		query		string								false	"next_cursor of the previous page"
+//	@Param			fields		query		string								false	"Comma separated fields to return, e.g. time,value"
 //
 //	@Failure		400			{object}	map[string]string					"Invalid params"
 //	@Failure		500			{object}	map[string]string					"Failed to fetch pollution entries from database"
@@ -153,37 +374,64 @@ func GetPollutionsByLatLon(c *fiber.Ctx) error {
 	}
 
 	// TODO: should we do the 24 hour default time range????
-	fromStr := c.Query("from", time.Now().Add(-24*time.Hour).Format(TimeFormat))
-	toStr := c.Query("to", time.Now().Format(TimeFormat))
+	fromStr := c.Query("from", "now-24h")
+	toStr := c.Query("to", "now")
+
+	loc, msg := ParseTimeZone(c.Query("tz"))
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
 
 	var from, to time.Time
-	ok, msg = ParseTimeRange(fromStr, toStr, &from, &to)
+	ok, msg = ParseTimeRange(fromStr, toStr, loc, 0, &from, &to)
 	if !ok {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": msg,
 		})
 	}
 
+	unit, msg := ParseUnitParam(c.Query("unit"), "")
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	page, msg := ParsePage[PollutionValueResponse](c)
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
 	repo := NewPollutionRepo(database.DBPool)
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	vals, err := repo.GetPollutionValueByPosition(ctx, latitude, longitude, from, to)
+	vals, err := repo.GetPollutionValueByPosition(ctx, latitude, longitude, from, to, page)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to fetch data from database! " + err.Error(),
 		})
 	}
 
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"data": vals,
-	})
+	for i, v := range vals {
+		vals[i].Value, vals[i].Unit = ConvertValue(v.Pollutant, v.Value, unit)
+	}
+	InLocation(vals, loc)
+
+	return WritePage(c, page, vals, PollutionValueCursor)
 }
 
 // GetPollutionDensityOfRect
 //
 //	@Summary		Gets pollution densities of rect
-//	@Description	Gets pollution densities for a given rect and time range
+//	@Description	Computes an aggregate of the readings inside a rect per pollutant and time bucket, ordered by pollutant and time.
+//	@Description	The step is either a duration or auto, which picks a step giving about points buckets. Concentration aggregates
+//	@Description	carry the AQI sub-index of their pollutant, and the response carries the AQI of the rect computed from the
+//	@Description	averages of the whole range.
 //	@Tags			pollutions
 //	@Produce		json
 //
@@ -193,15 +441,21 @@ func GetPollutionsByLatLon(c *fiber.Ctx) error {
 //	@Param			longTo		query		float64							true	"longTo"
 //	@Param			from		query		string							true	"from"
 //	@Param			to			query		string							true	"to"
-//	@Param			pollutant	query		string							false	"pollutant"
+//	@Param			tz			query		string							false	"IANA time zone of times without an offset and of the returned times"	default(UTC)
+//	@Param			pollutant	query		string							false	"Comma separated pollutants, all if empty"
+//	@Param			step		query		string							false	"Bucket size as a duration, e.g. 15m, 1h, or auto"	default(auto)
+//	@Param			points		query		int								false	"Number of buckets an auto step aims for, 1-10000"	default(200)
+//	@Param			agg			query		string							false	"Aggregate: avg, min, max, p50, p95, count, stddev"	default(avg)
+//	@Param			scale		query		string							false	"AQI scale: epa, caqi, daqi"	default(epa)
+//	@Param			unit		query		string							false	"Unit to return the densities in: µg/m³, mg/m³, ppb, ppm"
 //
 //	@Failure		400			{object}	map[string]string				"Invalid params"
 //	@Failure		500			{object}	map[string]string				"Failed to fetch pollution entries from database"
-//	@Success		200			{object}	map[string][]PollutionDensity	"Pollution Densities"
+//	@Success		200			{object}	map[string]any					"Pollution densities in data, the step, the AQI of the rect over the whole range in aqi"
 //	@Router			/api/pollutions/density/rect [get]
 func GetPollutionDensityOfRect(c *fiber.Ctx) error {
-	fromStr := c.Query("from", time.Now().Add(-24*time.Hour).Format(TimeFormat))
-	toStr := c.Query("to", time.Now().Format(TimeFormat))
+	fromStr := c.Query("from", "now-24h")
+	toStr := c.Query("to", "now")
 
 	latFrom := c.QueryFloat("latFrom")
 	latTo := c.QueryFloat("latTo")
@@ -209,73 +463,302 @@ func GetPollutionDensityOfRect(c *fiber.Ctx) error {
 	longFrom := c.QueryFloat("longFrom")
 	longTo := c.QueryFloat("longTo")
 
-	pollutant := c.Query("pollutant")
+	pollutants := ParsePollutants(c.Query("pollutant"))
+
+	loc, msg := ParseTimeZone(c.Query("tz"))
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
 
 	var from, to time.Time
-	ok, msg := ParseTimeRange(fromStr, toStr, &from, &to)
+	ok, msg := ParseTimeRange(fromStr, toStr, loc, MaxRollupSpan, &from, &to)
 	if !ok {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": msg,
 		})
 	}
 
+	step, msg := ParseStep(c.Query("step", StepAuto), from, to, c.QueryInt("points", DefaultStepPoints))
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	agg, msg := ParseAggregate(c.Query("agg"))
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	if msg := checkRawSpan(from, to, step, agg, RectExtent(latFrom, latTo, longFrom, longTo)); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	scale, ok := parseAQIScale(c)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Incorrect scale, expected one of " + strings.Join(AQIScaleNames(), ", "),
+		})
+	}
+
+	// A single pollutant is checked against the unit, the others fall back to
+	// their canonical unit
+	var pollutant string
+	if len(pollutants) == 1 {
+		pollutant = pollutants[0]
+	}
+	unit, msg := ParseUnitParam(c.Query("unit"), pollutant)
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
 	repo := NewPollutionRepo(database.DBPool)
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	densities, err := repo.GetPollutionDensityOfRect(ctx, latFrom, latTo, longFrom, longTo, from, to, 5*time.Minute, pollutant)
+	densities, err := repo.GetPollutionDensityOfRect(ctx, latFrom, latTo, longFrom, longTo, from, to, step, agg, pollutants)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to fetch rect densities from database: " + err.Error(),
 		})
 	}
 
+	if IsConcentration(agg) {
+		for i := range densities {
+			d := &densities[i]
+			if sub, ok := scale.SubIndex(d.Pollutant, d.Density); ok {
+				d.AQI = &sub.Index
+			}
+			d.Density, d.Unit = ConvertValue(d.Pollutant, d.Density, unit)
+		}
+	} else if agg == AggStdDev {
+		for i := range densities {
+			d := &densities[i]
+			d.Density, d.Unit = ConvertValue(d.Pollutant, d.Density, unit)
+		}
+	}
+
+	averages, err := repo.GetPollutantAveragesOfRect(ctx, latFrom, latTo, longFrom, longTo, from, to, pollutants)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch rect averages from database: " + err.Error(),
+		})
+	}
+
+	var aqi *AQIResult
+	if result, ok := scale.Compute(averages); ok {
+		aqi = &result
+	}
+
+	InLocation(densities, loc)
+
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"data": densities,
+		"step": step.String(),
+		"agg":  agg,
+		"aqi":  aqi,
 	})
 }
 
 // GetAnomaliesOfRange
 //
 //	@Summary		Gets anomalies for range
-//	@Description	Gets anomalies for a given time range
+//	@Description	Gets anomalies for a given time range, newest first. Every detector that flagged a reading is a separate anomaly.
+//	@Description	Anomalies are returned a page at a time, next_cursor and next point to the next page if there is one.
 //	@Tags			anomalies
 //	@Produce		json
 //
-//	@Param			from	query		string					true	"Start time"
-//	@Param			to		query		string					true	"End time"
+//	@Param			from		query		string					true	"Start time"
+//	@Param			to			query		string					true	"End time"
+//	@Param			tz			query		string					false	"IANA time zone of times without an offset and of the returned times"	default(UTC)
+//	@Param			pollutant	query		string					false	"Pollutant"
+//	@Param			status		query		string					false	"Comma separated statuses: open, acknowledged, resolved"
+//	@Param			severity	query		string					false	"Comma separated severities: low, medium, high, critical"
+//	@Param			unit		query		string					false	"Unit to return the values in: µg/m³, mg/m³, ppb, ppm"
+//	@Param			limit		query		int						false	"Maximum number of items, 1-10000"	default(1000)
+//	@Param			order		query		string					false	"Order by time: asc or desc"	default(desc)
+//	@Param			cursor		query		string					false	"next_cursor of the previous page"
+//	@Param			fields		query		string					false	"Comma separated fields to return, e.g. time,value"
 //
-//	@Failure		400		{object}	map[string]string		"Invalid params"
-//	@Failure		500		{object}	map[string]string		"Failed to fetch pollution entries from database"
-//	@Success		200		{object}	map[string][]Pollution	"Anomalies"
+//	@Failure		400			{object}	map[string]string		"Invalid params"
+//	@Failure		500			{object}	map[string]string		"Failed to fetch anomalies from database"
+//	@Success		200			{object}	map[string][]Anomaly	"Anomalies"
 //	@Router			/api/anomalies [get]
 func GetAnomaliesOfRange(c *fiber.Ctx) error {
+	return WriteAreaAnomalies(c, "")
+}
+
+// WriteAreaAnomalies responds with the anomalies matching the query parameters
+// of GetAnomaliesOfRange inside the geometry, an empty geometry does not filter
+// on the position.
+func WriteAreaAnomalies(c *fiber.Ctx, geometry string) error {
 	fromStr := c.Query("from")
 	toStr := c.Query("to")
 
+	loc, msg := ParseTimeZone(c.Query("tz"))
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
 	// Parse the string times into time.Time
-	var from, to time.Time
-	ok, msg := ParseTimeRange(fromStr, toStr, &from, &to)
+	var filter AnomalyFilter
+	ok, msg := ParseTimeRange(fromStr, toStr, loc, 0, &filter.From, &filter.To)
 	if !ok {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": msg,
 		})
 	}
 
+	filter.Pollutant = c.Query("pollutant")
+	filter.Area = geometry
+
+	if filter.Status, ok = ParseList(c.Query("status"), AnomalyStatuses); !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Incorrect status, expected one of " + strings.Join(AnomalyStatuses, ", "),
+		})
+	}
+
+	if filter.Severity, ok = ParseList(c.Query("severity"), Severities); !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Incorrect severity, expected one of " + strings.Join(Severities, ", "),
+		})
+	}
+
+	unit, msg := ParseUnitParam(c.Query("unit"), filter.Pollutant)
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	page, msg := ParsePage[Anomaly](c)
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
 	repo := NewPollutionRepo(database.DBPool)
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	// Get anomalies from database
-	pollutions, err := repo.GetAnomaliesWithinTimeRange(ctx, from, to)
+	anomalies, err := repo.GetAnomalies(ctx, filter, page)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch anomalies from database " + err.Error(),
+		})
+	}
+
+	for i := range anomalies {
+		convertAnomaly(&anomalies[i], unit)
+	}
+	InLocation(anomalies, loc)
+
+	return WritePage(c, page, anomalies, func(a Anomaly) Cursor {
+		return Cursor{Time: a.Time, ID: a.ID}
+	})
+}
+
+type anomalyStatusRequest struct {
+	Note string `json:"note"`
+}
+
+// AcknowledgeAnomaly
+//
+//	@Summary		Acknowledges an anomaly
+//	@Description	Marks an open anomaly as acknowledged. The X-User header is recorded as the acknowledging user.
+//	@Tags			anomalies
+//	@Accept			json
+//	@Produce		json
+//
+//	@Param			id		path		int						true	"Anomaly id"
+//	@Param			X-User	header		string					false	"Operator acknowledging the anomaly"
+//	@Param			request	body		anomalyStatusRequest	false	"Optional note"
+//
+//	@Failure		400		{object}	map[string]string		"Invalid params"
+//	@Failure		404		{object}	map[string]string		"Anomaly not found"
+//	@Failure		409		{object}	map[string]string		"Anomaly is not open"
+//	@Failure		500		{object}	map[string]string		"Failed to update anomaly"
+//	@Success		200		{object}	map[string]Anomaly		"Acknowledged anomaly"
+//	@Router			/api/anomalies/{id}/acknowledge [post]
+func AcknowledgeAnomaly(c *fiber.Ctx) error {
+	return updateAnomalyStatus(c, AnomalyStatusAcknowledged)
+}
+
+// ResolveAnomaly
+//
+//	@Summary		Resolves an anomaly
+//	@Description	Marks an open or acknowledged anomaly as resolved. The X-User header is recorded as the resolving user.
+//	@Tags			anomalies
+//	@Accept			json
+//	@Produce		json
+//
+//	@Param			id		path		int						true	"Anomaly id"
+//	@Param			X-User	header		string					false	"Operator resolving the anomaly"
+//	@Param			request	body		anomalyStatusRequest	false	"Optional note"
+//
+//	@Failure		400		{object}	map[string]string		"Invalid params"
+//	@Failure		404		{object}	map[string]string		"Anomaly not found"
+//	@Failure		409		{object}	map[string]string		"Anomaly is already resolved"
+//	@Failure		500		{object}	map[string]string		"Failed to update anomaly"
+//	@Success		200		{object}	map[string]Anomaly		"Resolved anomaly"
+//	@Router			/api/anomalies/{id}/resolve [post]
+func ResolveAnomaly(c *fiber.Ctx) error {
+	return updateAnomalyStatus(c, AnomalyStatusResolved)
+}
+
+func updateAnomalyStatus(c *fiber.Ctx, status string) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Incorrect anomaly id!",
+		})
+	}
+
+	var body anomalyStatusRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&body); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Failed to parse request body - " + err.Error(),
+			})
+		}
+	}
+
+	repo := NewPollutionRepo(database.DBPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	anomaly, err := repo.UpdateAnomalyStatus(ctx, id, status, changedBy(c), body.Note)
+	if errors.Is(err, ErrAnomalyNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Anomaly not found",
+		})
+	}
+	if errors.Is(err, ErrInvalidTransition) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": fmt.Sprintf("Anomaly can not be %s from its current status", status),
+		})
+	}
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to fetch pollution entries from database " + err.Error(),
+			"error": "Failed to update anomaly: " + err.Error(),
 		})
 	}
+	convertAnomaly(&anomaly, "")
 
-	return c.JSON(fiber.Map{
-		"data": pollutions,
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"data": anomaly,
 	})
 }
 
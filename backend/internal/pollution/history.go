@@ -0,0 +1,128 @@
+package pollution
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+)
+
+// ValueStats summarizes the values a detector compares a reading with
+type ValueStats struct {
+	Count  int64
+	Mean   float64
+	StdDev float64
+}
+
+// TimedValue is the value of a reading and when it was taken
+type TimedValue struct {
+	Time  time.Time
+	Value float64
+}
+
+// batchHistory is the history the detectors see while a batch is checked.
+// The entries of the batch are only stored once all of them are checked, so
+// the earlier entries are added to what the repo returns. pending must be in
+// time order.
+type batchHistory struct {
+	PollutionRepo
+	pending []Pollution
+}
+
+// matching returns the values of the pending entries within the radius and
+// the time range, to is included if closed is set
+func (h *batchHistory) matching(pollutant string, radius, latitude, longitude float64, from, to time.Time, closed bool) []TimedValue {
+	var values []TimedValue
+	for _, p := range h.pending {
+		if p.Pollutant != pollutant || p.Time.Before(from) || p.Time.After(to) || (!closed && p.Time.Equal(to)) {
+			continue
+		}
+		if haversineKm(latitude, longitude, p.Latitude, p.Longitude) > radius {
+			continue
+		}
+		values = append(values, TimedValue{Time: p.Time, Value: p.Value})
+	}
+
+	return values
+}
+
+// mergeStats adds values to the stats of the stored readings
+func mergeStats(stats ValueStats, values []TimedValue) ValueStats {
+	if len(values) == 0 {
+		return stats
+	}
+
+	n := float64(stats.Count)
+	sum := n * stats.Mean
+	sumSquares := n * (stats.StdDev*stats.StdDev + stats.Mean*stats.Mean)
+	for _, v := range values {
+		sum += v.Value
+		sumSquares += v.Value * v.Value
+	}
+
+	n += float64(len(values))
+	mean := sum / n
+	return ValueStats{
+		Count:  stats.Count + int64(len(values)),
+		Mean:   mean,
+		StdDev: math.Sqrt(math.Max(sumSquares/n-mean*mean, 0)),
+	}
+}
+
+func (h *batchHistory) GetValueStats(ctx context.Context, pollutant string, radius, latitude, longitude float64, from, to time.Time) (ValueStats, error) {
+	stats, err := h.PollutionRepo.GetValueStats(ctx, pollutant, radius, latitude, longitude, from, to)
+	if err != nil {
+		return stats, err
+	}
+
+	return mergeStats(stats, h.matching(pollutant, radius, latitude, longitude, from, to, true)), nil
+}
+
+func (h *batchHistory) GetHourOfDayStats(ctx context.Context, pollutant string, radius, latitude, longitude float64, hour int, from, to time.Time) (ValueStats, error) {
+	stats, err := h.PollutionRepo.GetHourOfDayStats(ctx, pollutant, radius, latitude, longitude, hour, from, to)
+	if err != nil {
+		return stats, err
+	}
+
+	var values []TimedValue
+	for _, v := range h.matching(pollutant, radius, latitude, longitude, from, to, true) {
+		if v.Time.UTC().Hour() == hour {
+			values = append(values, v)
+		}
+	}
+
+	return mergeStats(stats, values), nil
+}
+
+func (h *batchHistory) GetPreviousValue(ctx context.Context, pollutant string, radius, latitude, longitude float64, from, to time.Time) (float64, bool, error) {
+	values := h.matching(pollutant, radius, latitude, longitude, from, to, false)
+	if len(values) == 0 {
+		return h.PollutionRepo.GetPreviousValue(ctx, pollutant, radius, latitude, longitude, from, to)
+	}
+
+	// Only a stored reading after the latest pending one is more recent
+	latest := values[len(values)-1]
+	previous, found, err := h.PollutionRepo.GetPreviousValue(ctx, pollutant, radius, latitude, longitude, latest.Time, to)
+	if err != nil || found {
+		return previous, found, err
+	}
+
+	return latest.Value, true, nil
+}
+
+func (h *batchHistory) GetValues(ctx context.Context, pollutant string, radius, latitude, longitude float64, from, to time.Time) ([]TimedValue, error) {
+	values, err := h.PollutionRepo.GetValues(ctx, pollutant, radius, latitude, longitude, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := h.matching(pollutant, radius, latitude, longitude, from, to, false)
+	if len(pending) == 0 {
+		return values, nil
+	}
+
+	values = append(values, pending...)
+	sort.SliceStable(values, func(i, j int) bool { return values[i].Time.Before(values[j].Time) })
+
+	return values, nil
+}
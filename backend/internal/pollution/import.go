@@ -0,0 +1,162 @@
+package pollution
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ImportStats counts what happened to the readings of an import
+type ImportStats struct {
+	Read       int `json:"read"`
+	Invalid    int `json:"invalid"`
+	Duplicates int `json:"duplicates"`
+	// Expired readings are older than the retention cutoff, see MinTime
+	Expired   int `json:"expired"`
+	Inserted  int `json:"inserted"`
+	Anomalies int `json:"anomalies"`
+}
+
+func (s *ImportStats) Add(o ImportStats) {
+	s.Read += o.Read
+	s.Invalid += o.Invalid
+	s.Duplicates += o.Duplicates
+	s.Expired += o.Expired
+	s.Inserted += o.Inserted
+	s.Anomalies += o.Anomalies
+}
+
+// Importer loads historical readings. Unlike the ingest queue it accepts
+// readings of any age, skips readings that are already stored and does not
+// notify about the anomalies it finds.
+type Importer struct {
+	service   *PollutionService
+	repo      PollutionRepo
+	stations  *stationResolver
+	validator Validator
+	// Detect runs anomaly detection over the imported readings. Readings are
+	// then inserted one at a time in time order, so every reading is compared
+	// with the readings imported before it.
+	Detect bool
+	// MinTime skips the readings before it, their time range was dropped by
+	// the retention policies. Zero imports readings of any age.
+	MinTime time.Time
+
+	// from and to span the readings inserted so far
+	from, to time.Time
+}
+
+func NewImporter(repo PollutionRepo, detect bool) *Importer {
+	validator := *DefaultValidator
+	validator.MaxAge = 0
+
+	return &Importer{
+		service:   NewPollutionService(repo),
+		repo:      repo,
+		stations:  newStationResolver(repo),
+		validator: validator,
+		Detect:    detect,
+	}
+}
+
+// Check resolves the station of the reading, normalizes its unit and
+// validates it. The error is only set if the station lookup failed.
+func (im *Importer) Check(ctx context.Context, entry *Pollution) (ValidationErrors, error) {
+	errs, err := im.stations.resolve(ctx, entry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up the station - %s", err.Error())
+	}
+
+	return append(errs, im.validator.Check(entry, time.Now())...), nil
+}
+
+type readingKey struct {
+	time      int64
+	pollutant string
+	latitude  float64
+	longitude float64
+	stationID string
+}
+
+// Import stores a batch of checked readings. Duplicates inside the batch and
+// readings already in the database are skipped. The stats count the readings
+// inserted before a failure.
+func (im *Importer) Import(ctx context.Context, entries []Pollution) (ImportStats, error) {
+	var stats ImportStats
+
+	seen := make(map[readingKey]bool, len(entries))
+	unique := make([]Pollution, 0, len(entries))
+	for _, e := range entries {
+		if e.Time.Before(im.MinTime) {
+			stats.Expired++
+			continue
+		}
+
+		key := readingKey{e.Time.UnixNano(), e.Pollutant, e.Latitude, e.Longitude, e.StationID}
+		if seen[key] {
+			stats.Duplicates++
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, e)
+	}
+
+	sort.SliceStable(unique, func(i, j int) bool { return unique[i].Time.Before(unique[j].Time) })
+
+	if !im.Detect {
+		inserted, err := im.repo.ImportPollutionBatch(ctx, unique)
+		if err != nil {
+			return stats, fmt.Errorf("failed to import pollution batch - %s", err.Error())
+		}
+
+		stats.Duplicates += len(unique) - len(inserted)
+		for _, e := range inserted {
+			im.inserted(&stats, e)
+		}
+		return stats, nil
+	}
+
+	// Stored readings are dropped first, so only inserted readings are checked
+	fresh, err := im.repo.GetNewReadings(ctx, unique)
+	if err != nil {
+		return stats, fmt.Errorf("failed to look up stored readings - %s", err.Error())
+	}
+	stats.Duplicates += len(unique) - len(fresh)
+
+	for i := range fresh {
+		if err := im.service.detectAnomaly(ctx, &fresh[i]); err != nil {
+			return stats, err
+		}
+
+		if err := im.repo.InsertPollution(ctx, fresh[i]); err != nil {
+			return stats, fmt.Errorf("failed to insert pollution entry - %s", err.Error())
+		}
+		im.inserted(&stats, fresh[i])
+	}
+
+	return stats, nil
+}
+
+// inserted counts an inserted reading and widens the imported time range
+func (im *Importer) inserted(stats *ImportStats, e Pollution) {
+	stats.Inserted++
+	stats.Anomalies += len(e.Anomalies)
+
+	if im.from.IsZero() || e.Time.Before(im.from) {
+		im.from = e.Time
+	}
+	if e.Time.After(im.to) {
+		im.to = e.Time
+	}
+}
+
+// RefreshRollups materializes the rollups over the readings inserted so far,
+// the refresh policies only cover recent readings
+func (im *Importer) RefreshRollups(ctx context.Context) error {
+	if im.from.IsZero() {
+		return nil
+	}
+
+	return im.repo.RefreshRollups(ctx, im.from, im.to)
+}
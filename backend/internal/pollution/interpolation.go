@@ -0,0 +1,393 @@
+package pollution
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	InterpolationIDW     = "idw"
+	InterpolationKriging = "kriging"
+)
+
+var InterpolationMethods = []string{InterpolationIDW, InterpolationKriging}
+
+// Sample is the latest value of a pollutant reported by a sensor
+type Sample struct {
+	Latitude  float64   `json:"latitude"`
+	Longitude float64   `json:"longitude"`
+	Value     float64   `json:"value"`
+	Time      time.Time `json:"time"`
+}
+
+// Estimate is the interpolated concentration at a location. Variance is the
+// kriging variance, IDW does not provide one.
+type Estimate struct {
+	Latitude  float64  `json:"latitude"`
+	Longitude float64  `json:"longitude"`
+	Value     float64  `json:"value"`
+	Variance  *float64 `json:"variance,omitempty"`
+	Samples   int      `json:"samples"`
+	Unit      string   `json:"unit"`
+}
+
+// Surface is a grid of estimates over a rect, Values[row][col] is the estimate
+// at the centre of the cell, nil where there were not enough samples around it.
+// Row 0 is the southern edge and column 0 the western one.
+type Surface struct {
+	LatFrom   float64      `json:"lat_from"`
+	LatTo     float64      `json:"lat_to"`
+	LongFrom  float64      `json:"long_from"`
+	LongTo    float64      `json:"long_to"`
+	Rows      int          `json:"rows"`
+	Cols      int          `json:"cols"`
+	CellLat   float64      `json:"cell_lat"`
+	CellLon   float64      `json:"cell_lon"`
+	Values    [][]*float64 `json:"values"`
+	Unit      string       `json:"unit"`
+	Samples   int          `json:"samples"`
+	Method    string       `json:"method"`
+	Pollutant string       `json:"pollutant"`
+}
+
+// Interpolator estimates the value at a location from the samples around it
+type Interpolator struct {
+	Method string
+	// RadiusKm limits the samples used for an estimate to those this close
+	RadiusKm float64
+	// Neighbors is the maximum number of closest samples used for an estimate
+	Neighbors int
+	// Power is the distance exponent of IDW
+	Power float64
+
+	samples   []Sample
+	variogram variogram
+}
+
+var ErrNotEnoughSamples = errors.New("not enough samples for kriging")
+
+// minKrigingSamples is the number of samples needed to fit a variogram
+const minKrigingSamples = 3
+
+// Fit prepares the interpolator for the samples. Samples at the same location
+// are averaged, they would make the kriging system singular.
+func (in *Interpolator) Fit(samples []Sample) error {
+	in.samples = mergeColocated(samples)
+
+	if in.Method == InterpolationKriging {
+		if len(in.samples) < minKrigingSamples {
+			return ErrNotEnoughSamples
+		}
+		in.variogram = fitVariogram(in.samples)
+	}
+
+	return nil
+}
+
+// Estimate interpolates the value at the location, false if there is no
+// sample within the radius, or for kriging not enough of them.
+func (in *Interpolator) Estimate(latitude, longitude float64) (Estimate, bool) {
+	estimate := Estimate{Latitude: latitude, Longitude: longitude}
+
+	neighbors := in.neighbors(latitude, longitude)
+	estimate.Samples = len(neighbors)
+	if len(neighbors) == 0 {
+		return estimate, false
+	}
+
+	// A sample at the location is the estimate
+	if neighbors[0].distance < 1e-6 {
+		estimate.Value = neighbors[0].Value
+		if in.Method == InterpolationKriging {
+			variance := 0.0
+			estimate.Variance = &variance
+		}
+		return estimate, true
+	}
+
+	if in.Method == InterpolationKriging {
+		value, variance, ok := in.krige(neighbors)
+		if !ok {
+			return estimate, false
+		}
+		estimate.Value, estimate.Variance = value, &variance
+		return estimate, true
+	}
+
+	var sum, weights float64
+	for _, n := range neighbors {
+		w := 1 / math.Pow(n.distance, in.Power)
+		sum += w * n.Value
+		weights += w
+	}
+	estimate.Value = sum / weights
+
+	return estimate, true
+}
+
+type neighbor struct {
+	Sample
+	distance float64
+}
+
+// neighbors returns the closest samples within the radius, closest first
+func (in *Interpolator) neighbors(latitude, longitude float64) []neighbor {
+	var result []neighbor
+	for _, s := range in.samples {
+		d := haversineKm(latitude, longitude, s.Latitude, s.Longitude)
+		if d <= in.RadiusKm {
+			result = append(result, neighbor{s, d})
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].distance < result[j].distance })
+	if len(result) > in.Neighbors {
+		result = result[:in.Neighbors]
+	}
+
+	return result
+}
+
+// krige solves the ordinary kriging system of the neighbors and returns the
+// estimate with its variance
+func (in *Interpolator) krige(neighbors []neighbor) (float64, float64, bool) {
+	if len(neighbors) < minKrigingSamples {
+		return 0, 0, false
+	}
+
+	// [ γ(xi,xj) 1 ] [ w ]   [ γ(xi,x0) ]
+	// [ 1        0 ] [ μ ] = [ 1        ]
+	n := len(neighbors)
+	a := make([][]float64, n+1)
+	b := make([]float64, n+1)
+	for i := range neighbors {
+		a[i] = make([]float64, n+1)
+		for j := range neighbors {
+			d := haversineKm(neighbors[i].Latitude, neighbors[i].Longitude, neighbors[j].Latitude, neighbors[j].Longitude)
+			a[i][j] = in.variogram.at(d)
+		}
+		a[i][n] = 1
+		b[i] = in.variogram.at(neighbors[i].distance)
+	}
+	a[n] = make([]float64, n+1)
+	for j := 0; j < n; j++ {
+		a[n][j] = 1
+	}
+	b[n] = 1
+
+	x, ok := solve(a, append([]float64(nil), b...))
+	if !ok {
+		return 0, 0, false
+	}
+
+	var value, variance float64
+	for i := range neighbors {
+		value += x[i] * neighbors[i].Value
+		variance += x[i] * b[i]
+	}
+	variance += x[n]
+
+	return value, math.Max(0, variance), true
+}
+
+// variogram is an exponential variogram model,
+// γ(h) = nugget + sill * (1 - exp(-3h / range))
+type variogram struct {
+	nugget, sill, rangeKm float64
+}
+
+func (v variogram) at(h float64) float64 {
+	if h == 0 {
+		return 0
+	}
+	return v.nugget + v.sill*(1-math.Exp(-3*h/v.rangeKm))
+}
+
+// variogramLags is the number of distance classes of the empirical variogram
+const variogramLags = 12
+
+// fitVariogram fits an exponential model to the empirical semivariances of
+// the samples. For every candidate range the nugget and sill follow from a
+// linear least squares fit, the range with the smallest error wins.
+func fitVariogram(samples []Sample) variogram {
+	var maxDistance float64
+	type pair struct{ distance, semivariance float64 }
+	var pairs []pair
+	for i := range samples {
+		for j := i + 1; j < len(samples); j++ {
+			d := haversineKm(samples[i].Latitude, samples[i].Longitude, samples[j].Latitude, samples[j].Longitude)
+			diff := samples[i].Value - samples[j].Value
+			pairs = append(pairs, pair{d, diff * diff / 2})
+			maxDistance = math.Max(maxDistance, d)
+		}
+	}
+
+	// Only half of the largest distance is used, there are few pairs beyond it
+	cutoff := maxDistance / 2
+	if cutoff == 0 {
+		cutoff = maxDistance
+	}
+	lagWidth := cutoff / variogramLags
+
+	var lagDistance, lagSemivariance [variogramLags]float64
+	var lagCount [variogramLags]int
+	for _, p := range pairs {
+		lag := int(p.distance / lagWidth)
+		if lag >= variogramLags {
+			continue
+		}
+		lagDistance[lag] += p.distance
+		lagSemivariance[lag] += p.semivariance
+		lagCount[lag]++
+	}
+
+	var hs, gammas []float64
+	for lag := range lagCount {
+		if lagCount[lag] > 0 {
+			hs = append(hs, lagDistance[lag]/float64(lagCount[lag]))
+			gammas = append(gammas, lagSemivariance[lag]/float64(lagCount[lag]))
+		}
+	}
+
+	best := variogram{nugget: 0, sill: sampleVariance(samples), rangeKm: math.Max(cutoff, 1e-3)}
+	bestErr := math.Inf(1)
+	for step := 1; step <= 20; step++ {
+		rangeKm := maxDistance * float64(step) / 20
+		if rangeKm <= 0 {
+			break
+		}
+
+		// γ = nugget + sill * f(h) is linear in nugget and sill
+		var sf, sff, sg, sfg float64
+		n := float64(len(hs))
+		for i, h := range hs {
+			f := 1 - math.Exp(-3*h/rangeKm)
+			sf += f
+			sff += f * f
+			sg += gammas[i]
+			sfg += f * gammas[i]
+		}
+		det := n*sff - sf*sf
+		if det == 0 {
+			continue
+		}
+		sill := (n*sfg - sf*sg) / det
+		nugget := (sg - sill*sf) / n
+		if sill <= 0 {
+			continue
+		}
+		nugget = math.Max(0, nugget)
+
+		v := variogram{nugget: nugget, sill: sill, rangeKm: rangeKm}
+		var sse float64
+		for i, h := range hs {
+			e := v.at(h) - gammas[i]
+			sse += e * e
+		}
+		if sse < bestErr {
+			best, bestErr = v, sse
+		}
+	}
+
+	// A flat field has no spatial structure, keep the system solvable
+	if best.sill <= 0 {
+		best.sill = 1e-9
+	}
+
+	return best
+}
+
+func sampleVariance(samples []Sample) float64 {
+	var mean float64
+	for _, s := range samples {
+		mean += s.Value
+	}
+	mean /= float64(len(samples))
+
+	var variance float64
+	for _, s := range samples {
+		variance += (s.Value - mean) * (s.Value - mean)
+	}
+
+	return variance / float64(len(samples))
+}
+
+// solve solves a·x = b with Gaussian elimination and partial pivoting, false
+// if the system is singular. a and b are modified.
+func solve(a [][]float64, b []float64) ([]float64, bool) {
+	n := len(b)
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(a[row][col]) > math.Abs(a[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(a[pivot][col]) < 1e-12 {
+			return nil, false
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+		b[col], b[pivot] = b[pivot], b[col]
+
+		for row := col + 1; row < n; row++ {
+			factor := a[row][col] / a[col][col]
+			for k := col; k < n; k++ {
+				a[row][k] -= factor * a[col][k]
+			}
+			b[row] -= factor * b[col]
+		}
+	}
+
+	x := make([]float64, n)
+	for row := n - 1; row >= 0; row-- {
+		sum := b[row]
+		for k := row + 1; k < n; k++ {
+			sum -= a[row][k] * x[k]
+		}
+		x[row] = sum / a[row][row]
+	}
+
+	return x, true
+}
+
+// mergeColocated averages the samples reported at the same coordinates
+func mergeColocated(samples []Sample) []Sample {
+	type location struct{ latitude, longitude float64 }
+	index := make(map[location]int)
+	counts := make([]int, 0, len(samples))
+
+	var merged []Sample
+	for _, s := range samples {
+		loc := location{s.Latitude, s.Longitude}
+		i, ok := index[loc]
+		if !ok {
+			index[loc] = len(merged)
+			merged = append(merged, s)
+			counts = append(counts, 1)
+			continue
+		}
+
+		counts[i]++
+		merged[i].Value += (s.Value - merged[i].Value) / float64(counts[i])
+		if s.Time.After(merged[i].Time) {
+			merged[i].Time = s.Time
+		}
+	}
+
+	return merged
+}
+
+// haversineKm returns the great-circle distance between two coordinates
+func haversineKm(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadiusKm = 6371.0088
+	toRad := math.Pi / 180
+
+	dLat := (lat2 - lat1) * toRad
+	dLon := (lon2 - lon1) * toRad
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*toRad)*math.Cos(lat2*toRad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
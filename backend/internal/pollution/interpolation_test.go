@@ -0,0 +1,274 @@
+package pollution
+
+import (
+	"math"
+	"testing"
+)
+
+func almostEqual(a, b, tolerance float64) bool {
+	return math.Abs(a-b) <= tolerance
+}
+
+func TestSolve(t *testing.T) {
+	tests := []struct {
+		name string
+		a    [][]float64
+		b    []float64
+		want []float64
+		ok   bool
+	}{
+		{
+			name: "identity",
+			a:    [][]float64{{1, 0}, {0, 1}},
+			b:    []float64{3, 4},
+			want: []float64{3, 4},
+			ok:   true,
+		},
+		{
+			name: "needs pivoting",
+			a:    [][]float64{{0, 2, 1}, {1, 1, 0}, {2, 0, 3}},
+			b:    []float64{7, 3, 11},
+			want: []float64{1, 2, 3},
+			ok:   true,
+		},
+		{
+			name: "duplicate rows",
+			a:    [][]float64{{0, 1, 1}, {0, 1, 1}, {1, 1, 0}},
+			b:    []float64{1, 1, 1},
+			ok:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := solve(tt.a, tt.b)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			for i := range tt.want {
+				if !almostEqual(got[i], tt.want[i], 1e-9) {
+					t.Errorf("x = %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestFitVariogram(t *testing.T) {
+	tests := []struct {
+		name    string
+		samples []Sample
+	}{
+		{
+			name: "gradient",
+			samples: []Sample{
+				{Latitude: 0, Longitude: 0, Value: 10},
+				{Latitude: 0, Longitude: 0.1, Value: 20},
+				{Latitude: 0, Longitude: 0.2, Value: 30},
+				{Latitude: 0.1, Longitude: 0, Value: 15},
+				{Latitude: 0.1, Longitude: 0.1, Value: 25},
+				{Latitude: 0.1, Longitude: 0.2, Value: 35},
+			},
+		},
+		{
+			name: "flat field",
+			samples: []Sample{
+				{Latitude: 0, Longitude: 0, Value: 40},
+				{Latitude: 0, Longitude: 0.1, Value: 40},
+				{Latitude: 0.1, Longitude: 0, Value: 40},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := fitVariogram(tt.samples)
+			if v.sill <= 0 || v.nugget < 0 || v.rangeKm <= 0 {
+				t.Fatalf("variogram = %+v, want a positive sill and range", v)
+			}
+			if v.at(0) != 0 {
+				t.Errorf("γ(0) = %v, want 0", v.at(0))
+			}
+			if v.at(1) > v.at(10) {
+				t.Errorf("γ(1) = %v is above γ(10) = %v", v.at(1), v.at(10))
+			}
+		})
+	}
+}
+
+func TestInterpolatorEstimate(t *testing.T) {
+	// Along the equator the distance grows linearly with the longitude
+	tests := []struct {
+		name         string
+		interpolator Interpolator
+		samples      []Sample
+		lat, lon     float64
+		want         float64
+		wantVariance *float64
+		ok           bool
+	}{
+		{
+			name:         "idw on a sample",
+			interpolator: Interpolator{Method: InterpolationIDW, RadiusKm: 100, Neighbors: 10, Power: 2},
+			samples: []Sample{
+				{Latitude: 0, Longitude: 0, Value: 10},
+				{Latitude: 0, Longitude: 0.1, Value: 50},
+			},
+			lat: 0, lon: 0,
+			want: 10,
+			ok:   true,
+		},
+		{
+			name:         "idw equidistant samples weigh the same",
+			interpolator: Interpolator{Method: InterpolationIDW, RadiusKm: 100, Neighbors: 10, Power: 2},
+			samples: []Sample{
+				{Latitude: 0, Longitude: 0.1, Value: 10},
+				{Latitude: 0, Longitude: -0.1, Value: 30},
+			},
+			lat: 0, lon: 0,
+			want: 20,
+			ok:   true,
+		},
+		{
+			name:         "idw weighs by inverse distance",
+			interpolator: Interpolator{Method: InterpolationIDW, RadiusKm: 100, Neighbors: 10, Power: 1},
+			samples: []Sample{
+				{Latitude: 0, Longitude: 0.1, Value: 10},
+				{Latitude: 0, Longitude: -0.2, Value: 40},
+			},
+			lat: 0, lon: 0,
+			want: 20,
+			ok:   true,
+		},
+		{
+			name:         "idw without samples in the radius",
+			interpolator: Interpolator{Method: InterpolationIDW, RadiusKm: 1, Neighbors: 10, Power: 2},
+			samples: []Sample{
+				{Latitude: 0, Longitude: 1, Value: 10},
+			},
+			lat: 0, lon: 0,
+			ok: false,
+		},
+		{
+			name:         "kriging on a sample",
+			interpolator: Interpolator{Method: InterpolationKriging, RadiusKm: 100, Neighbors: 10},
+			samples: []Sample{
+				{Latitude: 0, Longitude: 0, Value: 10},
+				{Latitude: 0, Longitude: 0.1, Value: 20},
+				{Latitude: 0.1, Longitude: 0, Value: 30},
+				{Latitude: 0.1, Longitude: 0.1, Value: 25},
+			},
+			lat: 0.1, lon: 0,
+			want:         30,
+			wantVariance: new(float64),
+			ok:           true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := tt.interpolator
+			if err := in.Fit(tt.samples); err != nil {
+				t.Fatalf("Fit() error = %v", err)
+			}
+
+			got, ok := in.Estimate(tt.lat, tt.lon)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if !almostEqual(got.Value, tt.want, 1e-6) {
+				t.Errorf("value = %v, want %v", got.Value, tt.want)
+			}
+			if tt.wantVariance != nil && (got.Variance == nil || *got.Variance != *tt.wantVariance) {
+				t.Errorf("variance = %v, want %v", got.Variance, *tt.wantVariance)
+			}
+		})
+	}
+}
+
+func TestKrige(t *testing.T) {
+	samples := []Sample{
+		{Latitude: 0, Longitude: 0, Value: 10},
+		{Latitude: 0, Longitude: 0.1, Value: 20},
+		{Latitude: 0.1, Longitude: 0, Value: 30},
+		{Latitude: 0.1, Longitude: 0.1, Value: 25},
+	}
+	in := Interpolator{Method: InterpolationKriging, RadiusKm: 100, Neighbors: 10}
+	if err := in.Fit(samples); err != nil {
+		t.Fatalf("Fit() error = %v", err)
+	}
+
+	t.Run("reproduces a sample", func(t *testing.T) {
+		// Without the shortcut of Estimate the system itself must give the
+		// sample its full weight
+		value, variance, ok := in.krige(in.neighbors(0.1, 0))
+		if !ok {
+			t.Fatal("ok = false, want true")
+		}
+		if !almostEqual(value, 30, 1e-6) || !almostEqual(variance, 0, 1e-6) {
+			t.Errorf("value, variance = %v, %v, want 30, 0", value, variance)
+		}
+	})
+
+	t.Run("duplicate points", func(t *testing.T) {
+		neighbors := in.neighbors(0.05, 0.05)
+		neighbors = append(neighbors, neighbors[0])
+		if _, _, ok := in.krige(neighbors); ok {
+			t.Error("ok = true, want false for a singular system")
+		}
+	})
+}
+
+func TestExpandBounds(t *testing.T) {
+	tests := []struct {
+		name     string
+		bounds   Bounds
+		radiusKm float64
+		want     Bounds
+	}{
+		{
+			name:     "inside the date line",
+			bounds:   Bounds{LatFrom: -1, LatTo: 1, LongFrom: 10, LongTo: 20},
+			radiusKm: kmPerDegree,
+			want:     Bounds{LatFrom: -2, LatTo: 2, LongFrom: 8.9994, LongTo: 21.0006},
+		},
+		{
+			name:     "across the date line",
+			bounds:   Bounds{LatFrom: -1, LatTo: 1, LongFrom: 170, LongTo: -170},
+			radiusKm: kmPerDegree,
+			want:     Bounds{LatFrom: -2, LatTo: 2, LongFrom: 168.9994, LongTo: -168.9994},
+		},
+		{
+			name:     "expanded over the date line",
+			bounds:   Bounds{LatFrom: -1, LatTo: 1, LongFrom: 175, LongTo: 179.5},
+			radiusKm: kmPerDegree,
+			want:     Bounds{LatFrom: -2, LatTo: 2, LongFrom: 173.9994, LongTo: -179.4994},
+		},
+		{
+			name:     "around the globe",
+			bounds:   Bounds{LatFrom: -1, LatTo: 1, LongFrom: 170, LongTo: 160},
+			radiusKm: 10 * kmPerDegree,
+			want:     Bounds{LatFrom: -11, LatTo: 11, LongFrom: -180, LongTo: 180},
+		},
+		{
+			name:     "at a pole",
+			bounds:   Bounds{LatFrom: 89, LatTo: 89.5, LongFrom: 0, LongTo: 10},
+			radiusKm: kmPerDegree,
+			want:     Bounds{LatFrom: 88, LatTo: 90, LongFrom: -180, LongTo: 180},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := expandBounds(tt.bounds, tt.radiusKm)
+			if !almostEqual(got.LatFrom, tt.want.LatFrom, 1e-3) || !almostEqual(got.LatTo, tt.want.LatTo, 1e-3) ||
+				!almostEqual(got.LongFrom, tt.want.LongFrom, 1e-3) || !almostEqual(got.LongTo, tt.want.LongTo, 1e-3) {
+				t.Errorf("expandBounds() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
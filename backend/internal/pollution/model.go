@@ -9,16 +9,117 @@ type Pollution struct {
 	Value     float64   `json:"value"`
 	IsAnomaly bool      `json:"is_anomaly"`
 	Pollutant string    `json:"pollutant"`
+	// StationID references a registered station, the reading then takes the
+	// location of the station instead of Latitude and Longitude
+	StationID string `json:"station_id,omitempty"`
+	// Unit of Value, readings are converted to the canonical unit of their
+	// pollutant before they are stored. Empty means the canonical unit.
+	Unit string `json:"unit,omitempty"`
+	// Temperature in °C and Pressure in hPa the reading was taken at, used to
+	// convert ppb/ppm into µg/m³. Standard conditions are assumed if missing.
+	Temperature *float64 `json:"temperature,omitempty"`
+	Pressure    *float64 `json:"pressure,omitempty"`
+	// Anomalies holds the detectors that flagged the reading, set by the ingest service
+	Anomalies []Detection `json:"anomalies,omitempty"`
+	// ID of a stored reading, it only orders the pages of the lists
+	ID int64 `json:"-"`
 }
 
 type PollutionDensity struct {
 	Time      time.Time `json:"time"`
 	Pollutant string    `json:"pollutant"`
 	Density   float64   `json:"density"`
+	Unit      string    `json:"unit,omitempty"`
+	// AQI is the sub-index of Density, only set when the pollutant is known
+	AQI *float64 `json:"aqi,omitempty"`
 }
 
 type PollutionValueResponse struct {
 	Time      time.Time `json:"time"`
 	Value     float64   `json:"value"`
 	Pollutant string    `json:"pollutant"`
+	Unit      string    `json:"unit"`
+	// ID of the reading, it only orders the pages of the lists
+	ID int64 `json:"-"`
+}
+
+// Bounds is a lat/lon rect, LongFrom > LongTo crosses the antimeridian
+type Bounds struct {
+	LatFrom, LatTo   float64
+	LongFrom, LongTo float64
+}
+
+// GridCell aggregates the readings of a pollutant inside a cell of the grid,
+// Latitude and Longitude are the centre of the cell
+type GridCell struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Pollutant string  `json:"pollutant"`
+	Avg       float64 `json:"avg"`
+	Max       float64 `json:"max"`
+	Count     int64   `json:"count"`
+	Unit      string  `json:"unit"`
+}
+
+// AreaStatistics aggregates the readings of a pollutant inside an area for a time bucket
+type AreaStatistics struct {
+	Time      time.Time `json:"time"`
+	Pollutant string    `json:"pollutant"`
+	Avg       float64   `json:"avg"`
+	Min       float64   `json:"min"`
+	Max       float64   `json:"max"`
+	StdDev    float64   `json:"stddev"`
+	Count     int64     `json:"count"`
+	Unit      string    `json:"unit"`
+}
+
+// NearbyReading is a reading found by a radius search
+type NearbyReading struct {
+	Time       time.Time `json:"time"`
+	Latitude   float64   `json:"latitude"`
+	Longitude  float64   `json:"longitude"`
+	Value      float64   `json:"value"`
+	Unit       string    `json:"unit"`
+	Pollutant  string    `json:"pollutant"`
+	IsAnomaly  bool      `json:"is_anomaly"`
+	StationID  string    `json:"station_id,omitempty"`
+	DistanceKm float64   `json:"distance_km"`
+}
+
+// NearbySensor is a sensor found by a radius search with the latest value of
+// every pollutant it reported. Sensors are registered stations, or distinct
+// coordinates for readings sent without a station.
+type NearbySensor struct {
+	StationID  string                   `json:"station_id,omitempty"`
+	Latitude   float64                  `json:"latitude"`
+	Longitude  float64                  `json:"longitude"`
+	DistanceKm float64                  `json:"distance_km"`
+	Latest     []PollutionValueResponse `json:"latest"`
+}
+
+type NearbyStation struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Latitude   float64  `json:"latitude"`
+	Longitude  float64  `json:"longitude"`
+	Pollutants []string `json:"pollutants"`
+	Active     bool     `json:"active"`
+	DistanceKm float64  `json:"distance_km"`
+}
+
+// BatchMessageType marks ingest queue messages whose body is a JSON array of
+// Pollution entries instead of a single entry.
+const BatchMessageType = "pollution.batch"
+
+type BatchItemResult struct {
+	Index    int              `json:"index"`
+	Accepted bool             `json:"accepted"`
+	Error    string           `json:"error,omitempty"`
+	Fields   ValidationErrors `json:"fields,omitempty"`
+}
+
+type BatchReport struct {
+	Accepted int               `json:"accepted"`
+	Rejected int               `json:"rejected"`
+	Results  []BatchItemResult `json:"results"`
 }
@@ -0,0 +1,136 @@
+package pollution
+
+import (
+	"context"
+	"time"
+
+	"github.com/AkifSahn/pollution-tracker/internal/database"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	NearModeReadings = "readings"
+	NearModeLatest   = "latest"
+	NearModeStations = "stations"
+
+	// maxNearLimit caps the number of results of a radius search
+	maxNearLimit = 1000
+)
+
+// GetPollutionsNear
+//
+//	@Summary		Gets pollution values near a location
+//	@Description	Searches around a location within the radius, closest first, and returns the distance of every result.
+//	@Description	mode=readings returns the closest readings, mode=latest the latest value of every pollutant of the
+//	@Description	closest `limit` sensors, and mode=stations the closest registered stations.
+//	@Tags			pollutions
+//	@Produce		json
+//
+//	@Param			lat			query		float64				true	"Latitude"
+//	@Param			lon			query		float64				true	"Longitude"
+//	@Param			radius		query		float64				false	"Radius in km"	default(10)
+//	@Param			limit		query		int					false	"Maximum number of readings, sensors or stations"	default(50)
+//	@Param			mode		query		string				false	"readings, latest or stations"	default(readings)
+//	@Param			from		query		string				false	"Start time, defaults to 24 hours ago"
+//	@Param			to			query		string				false	"End time, defaults to now"
+//	@Param			tz			query		string				false	"IANA time zone of times without an offset and of the returned times"	default(UTC)
+//	@Param			pollutant	query		string				false	"Pollutant"
+//	@Param			unit		query		string				false	"Unit to return the values in: µg/m³, mg/m³, ppb, ppm"
+//
+//	@Failure		400			{object}	map[string]string	"Invalid params"
+//	@Failure		500			{object}	map[string]string	"Failed to fetch pollution entries from database"
+//	@Success		200			{object}	map[string]any		"NearbyReading, NearbySensor or NearbyStation list depending on mode"
+//	@Router			/api/pollutions/near [get]
+func GetPollutionsNear(c *fiber.Ctx) error {
+	var latitude, longitude float64
+	ok, msg := ParseLatLon(c.Query("lat"), c.Query("lon"), &latitude, &longitude)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	radius := c.QueryFloat("radius", 10)
+	if radius <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Radius must be positive!",
+		})
+	}
+
+	limit := c.QueryInt("limit", 50)
+	if limit <= 0 || limit > maxNearLimit {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Limit must be between 1 and 1000!",
+		})
+	}
+
+	fromStr := c.Query("from", "now-24h")
+	toStr := c.Query("to", "now")
+
+	loc, msg := ParseTimeZone(c.Query("tz"))
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	var from, to time.Time
+	ok, msg = ParseTimeRange(fromStr, toStr, loc, MaxRawSpan, &from, &to)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	pollutant := c.Query("pollutant")
+
+	unit, msg := ParseUnitParam(c.Query("unit"), pollutant)
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	repo := NewPollutionRepo(database.DBPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var data any
+	var err error
+	switch c.Query("mode", NearModeReadings) {
+	case NearModeReadings:
+		var readings []NearbyReading
+		readings, err = repo.GetNearestReadings(ctx, latitude, longitude, radius, from, to, pollutant, limit)
+		for i, r := range readings {
+			readings[i].Value, readings[i].Unit = ConvertValue(r.Pollutant, r.Value, unit)
+		}
+		data = readings
+	case NearModeLatest:
+		var sensors []NearbySensor
+		sensors, err = repo.GetNearestSensorsLatest(ctx, latitude, longitude, radius, from, to, pollutant, limit)
+		for _, sensor := range sensors {
+			for i, v := range sensor.Latest {
+				sensor.Latest[i].Value, sensor.Latest[i].Unit = ConvertValue(v.Pollutant, v.Value, unit)
+			}
+		}
+		data = sensors
+	case NearModeStations:
+		data, err = repo.GetNearestStations(ctx, latitude, longitude, radius, limit)
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Incorrect mode, expected one of readings, latest, stations",
+		})
+	}
+
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch nearby pollution entries from database: " + err.Error(),
+		})
+	}
+
+	InLocation(data, loc)
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"data": data,
+	})
+}
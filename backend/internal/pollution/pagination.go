@@ -0,0 +1,315 @@
+package pollution
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	OrderAsc  = "asc"
+	OrderDesc = "desc"
+
+	DefaultPageLimit = 1000
+	MaxPageLimit     = 10000
+)
+
+// Cursor is the position of the last row of a page, the values of the
+// columns the list is ordered by. ID is unique and breaks ties between rows
+// of the same time, Pollutant is only set for the lists ordered by it. Key is
+// the value of a text key column, like a station id or a region name.
+type Cursor struct {
+	Time      time.Time `json:"t"`
+	ID        int64     `json:"id"`
+	Pollutant string    `json:"p,omitempty"`
+	Key       string    `json:"k,omitempty"`
+}
+
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeCursor(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, err
+	}
+
+	return &cursor, nil
+}
+
+func (c Cursor) value(key SortKey) interface{} {
+	if key.Text {
+		return c.Key
+	}
+
+	switch key.Column {
+	case "id":
+		return c.ID
+	case "pollutant":
+		return c.Pollutant
+	}
+	return c.Time
+}
+
+// PollutionValueCursor is the cursor of lists of PollutionValueResponse,
+// ordered by pollutant, time and id
+func PollutionValueCursor(v PollutionValueResponse) Cursor {
+	return Cursor{Time: v.Time, ID: v.ID, Pollutant: v.Pollutant}
+}
+
+// SortKey is a column of the order of a list. The cursor value of Text
+// columns is Cursor.Key.
+type SortKey struct {
+	Column string
+	Desc   bool
+	Text   bool
+}
+
+func Asc(column string) SortKey {
+	return SortKey{Column: column}
+}
+
+func Desc(column string) SortKey {
+	return SortKey{Column: column, Desc: true}
+}
+
+// AscText is an ascending text key column
+func AscText(column string) SortKey {
+	return SortKey{Column: column, Text: true}
+}
+
+// Page is a keyset page of a list, the rows after Cursor in Order. An empty
+// Order keeps the default order of the list. Fields selects the JSON fields
+// of the items, empty returns all of them.
+type Page struct {
+	Limit  int
+	Order  string
+	Cursor *Cursor
+	Fields []string
+}
+
+// ParsePage reads the limit, order, cursor and fields query parameters of a
+// list of T, the fields must be JSON fields of T
+func ParsePage[T any](c *fiber.Ctx) (Page, string) {
+	page := Page{
+		Limit: c.QueryInt("limit", DefaultPageLimit),
+		Order: c.Query("order"),
+	}
+
+	if page.Limit <= 0 || page.Limit > MaxPageLimit {
+		return page, fmt.Sprintf("Limit must be between 1 and %d!", MaxPageLimit)
+	}
+	if page.Order != "" && page.Order != OrderAsc && page.Order != OrderDesc {
+		return page, "Incorrect order, expected asc or desc"
+	}
+
+	if s := c.Query("cursor"); s != "" {
+		cursor, err := DecodeCursor(s)
+		if err != nil {
+			return page, "Incorrect cursor!"
+		}
+		page.Cursor = cursor
+	}
+
+	if s := c.Query("fields"); s != "" {
+		known := jsonFields(reflect.TypeOf((*T)(nil)).Elem())
+		for _, field := range strings.Split(s, ",") {
+			if field = strings.TrimSpace(field); field == "" {
+				continue
+			}
+			if !known[field] {
+				return page, "Unknown field " + field
+			}
+			page.Fields = append(page.Fields, field)
+		}
+	}
+
+	return page, ""
+}
+
+// Sort returns the order of the list. Without an order parameter it is the
+// default order of the list, otherwise time in the requested direction and
+// the other columns of the default order, which must end in a unique one,
+// break the ties.
+func (p Page) Sort(defaults ...SortKey) []SortKey {
+	if p.Order == "" {
+		return defaults
+	}
+
+	desc := p.Order == OrderDesc
+	keys := []SortKey{{Column: "time", Desc: desc}}
+	for _, key := range defaults {
+		if key.Column != "time" {
+			keys = append(keys, SortKey{Column: key.Column, Desc: desc})
+		}
+	}
+
+	return keys
+}
+
+// SortBy returns the order of a list without a time column, the keys in the
+// requested direction or as they are without an order parameter
+func (p Page) SortBy(keys ...SortKey) []SortKey {
+	if p.Order == "" {
+		return keys
+	}
+
+	sorted := make([]SortKey, len(keys))
+	for i, key := range keys {
+		key.Desc = p.Order == OrderDesc
+		sorted[i] = key
+	}
+
+	return sorted
+}
+
+// After returns the condition selecting the rows after the cursor in the
+// order of keys, so ties continue where the last page stopped. The cursor
+// values are appended to args.
+func (p Page) After(args *[]interface{}, keys []SortKey) string {
+	if p.Cursor == nil {
+		return ""
+	}
+
+	var columns, params []string
+	sameDirection := true
+	for _, key := range keys {
+		*args = append(*args, p.Cursor.value(key))
+		columns = append(columns, key.Column)
+		params = append(params, fmt.Sprintf("$%d", len(*args)))
+		sameDirection = sameDirection && key.Desc == keys[0].Desc
+	}
+
+	// Columns in one direction are compared as a row, which can use an index
+	if sameDirection {
+		return fmt.Sprintf(" AND (%s) %s (%s)", strings.Join(columns, ", "), keys[0].op(), strings.Join(params, ", "))
+	}
+
+	// Otherwise a row is after the cursor if it is past it on a column and
+	// equal on all the columns before
+	var or []string
+	for i, key := range keys {
+		var and []string
+		for j := 0; j < i; j++ {
+			and = append(and, columns[j]+" = "+params[j])
+		}
+		and = append(and, columns[i]+" "+key.op()+" "+params[i])
+		or = append(or, "("+strings.Join(and, " AND ")+")")
+	}
+
+	return " AND (" + strings.Join(or, " OR ") + ")"
+}
+
+func (key SortKey) op() string {
+	if key.Desc {
+		return "<"
+	}
+	return ">"
+}
+
+// OrderBy returns the ORDER BY and LIMIT clauses of the page. One more row
+// than the limit is fetched to tell whether there is a next page.
+func (p Page) OrderBy(args *[]interface{}, keys []SortKey) string {
+	var columns []string
+	for _, key := range keys {
+		if key.Desc {
+			columns = append(columns, key.Column+" DESC")
+		} else {
+			columns = append(columns, key.Column+" ASC")
+		}
+	}
+
+	*args = append(*args, p.Limit+1)
+
+	return fmt.Sprintf(" ORDER BY %s LIMIT $%d", strings.Join(columns, ", "), len(*args))
+}
+
+// WritePage responds with a page of items. If there are more items than the
+// limit, the envelope has the cursor of the next page and a link to it.
+func WritePage[T any](c *fiber.Ctx, page Page, items []T, cursorOf func(T) Cursor) error {
+	response := fiber.Map{}
+
+	if len(items) > page.Limit {
+		items = items[:page.Limit]
+		next := cursorOf(items[len(items)-1]).Encode()
+
+		query, _ := url.ParseQuery(string(c.Request().URI().QueryString()))
+		query.Set("cursor", next)
+
+		response["next_cursor"] = next
+		response["next"] = c.Path() + "?" + query.Encode()
+	}
+
+	if len(page.Fields) == 0 {
+		response["data"] = items
+		return c.Status(fiber.StatusOK).JSON(response)
+	}
+
+	data, err := selectFields(items, page.Fields)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to select the fields " + err.Error(),
+		})
+	}
+	response["data"] = data
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// selectFields returns the items with only the given JSON fields, ParsePage
+// already checked that they exist
+func selectFields[T any](items []T, fields []string) ([]map[string]json.RawMessage, error) {
+	result := make([]map[string]json.RawMessage, 0, len(items))
+	for _, item := range items {
+		data, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+
+		var all map[string]json.RawMessage
+		if err := json.Unmarshal(data, &all); err != nil {
+			return nil, err
+		}
+
+		selected := make(map[string]json.RawMessage, len(fields))
+		for _, field := range fields {
+			if v, ok := all[field]; ok {
+				selected[field] = v
+			}
+		}
+		result = append(result, selected)
+	}
+
+	return result, nil
+}
+
+// jsonFields returns the JSON names of the fields of a struct type
+func jsonFields(t reflect.Type) map[string]bool {
+	fields := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" || !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = true
+	}
+
+	return fields
+}
@@ -2,24 +2,61 @@ package pollution
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type PollutionRepo interface {
-	GetPollutionValueByPosition(ctx context.Context, latitude, longitude float64, from, to time.Time) ([]PollutionValueResponse, error)
-	GetAnomaliesWithinTimeRange(ctx context.Context, from, to time.Time) ([]Pollution, error)
-	GetAllPolutionWithinTimeRange(ctx context.Context, from, to time.Time, pollutant string) ([]Pollution, error)
+	GetPollutionValueByPosition(ctx context.Context, latitude, longitude float64, from, to time.Time, page Page) ([]PollutionValueResponse, error)
+	GetAnomalies(ctx context.Context, filter AnomalyFilter, page Page) ([]Anomaly, error)
+	UpdateAnomalyStatus(ctx context.Context, id int64, status, by, note string) (Anomaly, error)
+	GetAllPolutionWithinTimeRange(ctx context.Context, from, to time.Time, pollutant string, page Page) ([]Pollution, error)
 
-	GetPollutionDensityOfRect(ctx context.Context, latFrom, latTo, longFrom, longTo float64, from, to time.Time, step time.Duration, pollutant string) ([]PollutionDensity, error)
+	GetPollutionDensityOfRect(ctx context.Context, latFrom, latTo, longFrom, longTo float64, from, to time.Time, step time.Duration, agg string, pollutants []string) ([]PollutionDensity, error)
+	RefreshRollups(ctx context.Context, from, to time.Time) error
 
 	GetDistinctPollutants(ctx context.Context) ([]string, error)
 
-	GetMeanAndStd(ctx context.Context, pollutant string, radius, latitude, longitude float64, from, to time.Time) (float64, float64, error)
+	GetPollutantAveragesNear(ctx context.Context, latitude, longitude, radius float64, from, to time.Time) (map[string]float64, error)
+	GetPollutantAveragesOfRect(ctx context.Context, latFrom, latTo, longFrom, longTo float64, from, to time.Time, pollutants []string) (map[string]float64, error)
+
+	GetValueStats(ctx context.Context, pollutant string, radius, latitude, longitude float64, from, to time.Time) (ValueStats, error)
+	GetHourOfDayStats(ctx context.Context, pollutant string, radius, latitude, longitude float64, hour int, from, to time.Time) (ValueStats, error)
+	GetPreviousValue(ctx context.Context, pollutant string, radius, latitude, longitude float64, from, to time.Time) (float64, bool, error)
+	GetValues(ctx context.Context, pollutant string, radius, latitude, longitude float64, from, to time.Time) ([]TimedValue, error)
 
 	InsertPollution(ctx context.Context, pollution Pollution) error
+	InsertPollutionBatch(ctx context.Context, pollutions []Pollution) error
+	GetNewReadings(ctx context.Context, pollutions []Pollution) ([]Pollution, error)
+	ImportPollutionBatch(ctx context.Context, pollutions []Pollution) ([]Pollution, error)
+
+	InsertRejectedReading(ctx context.Context, payload []byte, errs ValidationErrors) error
+
+	GetStationLocation(ctx context.Context, id string) (StationLocation, bool, error)
+
+	GetNearestReadings(ctx context.Context, latitude, longitude, radius float64, from, to time.Time, pollutant string, limit int) ([]NearbyReading, error)
+	GetNearestSensorsLatest(ctx context.Context, latitude, longitude, radius float64, from, to time.Time, pollutant string, limit int) ([]NearbySensor, error)
+	GetNearestStations(ctx context.Context, latitude, longitude, radius float64, limit int) ([]NearbyStation, error)
+
+	GetGridCells(ctx context.Context, cellSize float64, bounds *Bounds, from, to time.Time, pollutant string, limit int) ([]GridCell, error)
+	GetLatestSamples(ctx context.Context, bounds Bounds, from, to time.Time, pollutant string, limit int) ([]Sample, error)
+
+	GetAreaStatistics(ctx context.Context, geometry string, from, to time.Time, step time.Duration, pollutant string) ([]AreaStatistics, error)
+	GetPollutantAveragesOfArea(ctx context.Context, geometry string, from, to time.Time) (map[string]float64, error)
+	GetRegionBoundary(ctx context.Context, id int64) (string, bool, error)
+	RegionExists(ctx context.Context, name string) (bool, error)
+
+	GetAnomalyRules(ctx context.Context) ([]AnomalyRule, error)
+	GetAnomalyRule(ctx context.Context, pollutant, region string) (AnomalyRule, bool, error)
+	GetMatchingAnomalyRule(ctx context.Context, pollutant string, latitude, longitude float64) (AnomalyRule, bool, error)
+	SaveAnomalyRule(ctx context.Context, rule AnomalyRule, changedBy string) (AnomalyRule, error)
+	DeleteAnomalyRule(ctx context.Context, pollutant, region, changedBy string) (bool, error)
+	GetAnomalyRuleAudit(ctx context.Context, pollutant string, page Page) ([]AnomalyRuleAudit, error)
 }
 
 type PollutionRepoImpl struct {
@@ -32,14 +69,20 @@ func NewPollutionRepo(db *pgxpool.Pool) *PollutionRepoImpl {
 	}
 }
 
-func (repo *PollutionRepoImpl) GetPollutionValueByPosition(ctx context.Context, latitude, longitude float64, from, to time.Time) ([]PollutionValueResponse, error) {
+func (repo *PollutionRepoImpl) GetPollutionValueByPosition(ctx context.Context, latitude, longitude float64, from, to time.Time, page Page) ([]PollutionValueResponse, error) {
 	query := `
-    SELECT time, value, pollutant FROM air_pollution 
+    SELECT id, time, value, pollutant FROM air_pollution 
     WHERE latitude=$1 AND longitude=$2 
     AND time BETWEEN $3 AND $4 
-    ORDER BY pollutant, time DESC;
     `
-	rows, err := repo.DB.Query(ctx, query, latitude, longitude, from, to)
+	var args []interface{}
+	args = append(args, latitude, longitude, from, to)
+
+	keys := page.Sort(Asc("pollutant"), Desc("time"), Desc("id"))
+	query += page.After(&args, keys)
+	query += page.OrderBy(&args, keys)
+
+	rows, err := repo.DB.Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("Unable to query - %s", err.Error())
 	}
@@ -48,14 +91,11 @@ func (repo *PollutionRepoImpl) GetPollutionValueByPosition(ctx context.Context,
 	var pollutions []PollutionValueResponse
 	for rows.Next() {
 		var pollution PollutionValueResponse
-		var t time.Time
-		err = rows.Scan(&pollution.Time, &pollution.Value, &pollution.Pollutant)
+		err = rows.Scan(&pollution.ID, &pollution.Time, &pollution.Value, &pollution.Pollutant)
 		if err != nil {
 			return nil, fmt.Errorf("Unable to scan - %s", err.Error())
 		}
 
-		fmt.Println("time: ", t)
-
 		pollutions = append(pollutions, pollution)
 	}
 
@@ -66,38 +106,114 @@ func (repo *PollutionRepoImpl) GetPollutionValueByPosition(ctx context.Context,
 	return pollutions, nil
 }
 
-func (repo *PollutionRepoImpl) GetAnomaliesWithinTimeRange(ctx context.Context, from, to time.Time) ([]Pollution, error) {
+// anomalyColumns are the columns scanned by scanAnomaly, the station is taken
+// from the reading the anomaly refers to
+const anomalyColumns = `
+    id, reading_id, time, pollutant, latitude, longitude, value,
+    COALESCE((SELECT r.station_id FROM air_pollution r WHERE r.id = anomalies.reading_id AND r.time = anomalies.time), ''),
+    detector, score, baseline_mean, baseline_stddev, threshold, severity, reason,
+    status, detected_at, acknowledged_at, COALESCE(acknowledged_by, ''),
+    resolved_at, COALESCE(resolved_by, ''), COALESCE(note, '')
+`
+
+func scanAnomaly(row pgx.Row) (Anomaly, error) {
+	var a Anomaly
+	err := row.Scan(&a.ID, &a.ReadingID, &a.Time, &a.Pollutant, &a.Latitude, &a.Longitude, &a.Value, &a.StationID,
+		&a.Detector, &a.Score, &a.BaselineMean, &a.BaselineStdDev, &a.Threshold, &a.Severity, &a.Reason,
+		&a.Status, &a.DetectedAt, &a.AcknowledgedAt, &a.AcknowledgedBy,
+		&a.ResolvedAt, &a.ResolvedBy, &a.Note)
+	return a, err
+}
+
+func (repo *PollutionRepoImpl) GetAnomalies(ctx context.Context, filter AnomalyFilter, page Page) ([]Anomaly, error) {
 	query := `
-    SELECT latitude, longitude, value, is_anomaly, pollutant from air_pollution
-    WHERE time >= $1 AND time <= $2 AND is_anomaly=true;
+    SELECT ` + anomalyColumns + ` FROM anomalies
+    WHERE time >= $1 AND time <= $2
     `
-	rows, err := repo.DB.Query(ctx, query, from, to)
+	var args []interface{}
+	args = append(args, filter.From, filter.To)
+
+	if filter.Pollutant != "" {
+		args = append(args, filter.Pollutant)
+		query += fmt.Sprintf(" AND pollutant = $%d", len(args))
+	}
+	if len(filter.Status) > 0 {
+		args = append(args, filter.Status)
+		query += fmt.Sprintf(" AND status = ANY($%d)", len(args))
+	}
+	if len(filter.Severity) > 0 {
+		args = append(args, filter.Severity)
+		query += fmt.Sprintf(" AND severity = ANY($%d)", len(args))
+	}
+	if filter.Area != "" {
+		args = append(args, filter.Area)
+		query += fmt.Sprintf(" AND ST_Covers(ST_GeomFromGeoJSON($%d::text)::geography, ST_MakePoint(longitude, latitude)::geography)", len(args))
+	}
+	keys := page.Sort(Desc("time"), Asc("id"))
+	query += page.After(&args, keys)
+	query += page.OrderBy(&args, keys)
+
+	rows, err := repo.DB.Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("Unable to query - %s", err.Error())
 	}
 	defer rows.Close()
 
-	var pollutions []Pollution
+	var anomalies []Anomaly
 	for rows.Next() {
-		var pollution Pollution
-		err = rows.Scan(&pollution.Latitude, &pollution.Longitude,
-			&pollution.Value, &pollution.IsAnomaly, &pollution.Pollutant)
+		anomaly, err := scanAnomaly(rows)
 		if err != nil {
 			return nil, fmt.Errorf("Unable to scan - %s", err.Error())
 		}
-		pollutions = append(pollutions, pollution)
+		anomalies = append(anomalies, anomaly)
 	}
 
 	if rows.Err() != nil {
 		return nil, fmt.Errorf("rows Error: %v\n", rows.Err())
 	}
 
-	return pollutions, nil
+	return anomalies, nil
+}
+
+// UpdateAnomalyStatus moves an anomaly to the given status, recording who did
+// it. It returns ErrAnomalyNotFound or ErrInvalidTransition if the anomaly does
+// not exist or can not be moved to the status from its current one.
+func (repo *PollutionRepoImpl) UpdateAnomalyStatus(ctx context.Context, id int64, status, by, note string) (Anomaly, error) {
+	query := `
+    UPDATE anomalies SET
+        status = $2,
+        acknowledged_at = CASE WHEN $2 = 'acknowledged' THEN now() ELSE acknowledged_at END,
+        acknowledged_by = CASE WHEN $2 = 'acknowledged' THEN $3 ELSE acknowledged_by END,
+        resolved_at = CASE WHEN $2 = 'resolved' THEN now() ELSE resolved_at END,
+        resolved_by = CASE WHEN $2 = 'resolved' THEN $3 ELSE resolved_by END,
+        note = COALESCE(NULLIF($4, ''), note)
+    WHERE id = $1 AND status = ANY($5)
+    RETURNING ` + anomalyColumns + `;
+    `
+	anomaly, err := scanAnomaly(repo.DB.QueryRow(ctx, query, id, status, by, note, allowedFrom(status)))
+	if err == nil {
+		return anomaly, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return anomaly, fmt.Errorf("Failed to update anomaly - %s", err.Error())
+	}
+
+	// Nothing was updated, tell a missing anomaly apart from a wrong status
+	var exists bool
+	err = repo.DB.QueryRow(ctx, `SELECT EXISTS (SELECT FROM anomalies WHERE id = $1);`, id).Scan(&exists)
+	if err != nil {
+		return anomaly, fmt.Errorf("Unable to scan - %s", err.Error())
+	}
+	if !exists {
+		return anomaly, ErrAnomalyNotFound
+	}
+
+	return anomaly, ErrInvalidTransition
 }
 
-func (repo *PollutionRepoImpl) GetAllPolutionWithinTimeRange(ctx context.Context, from, to time.Time, pollutant string) ([]Pollution, error) {
+func (repo *PollutionRepoImpl) GetAllPolutionWithinTimeRange(ctx context.Context, from, to time.Time, pollutant string, page Page) ([]Pollution, error) {
 	query := `
-    SELECT latitude, longitude, value, is_anomaly, pollutant from air_pollution
+    SELECT id, time, latitude, longitude, value, is_anomaly, pollutant, COALESCE(station_id, '') from air_pollution
     WHERE time BETWEEN $1 AND $2
     `
 
@@ -108,6 +224,9 @@ func (repo *PollutionRepoImpl) GetAllPolutionWithinTimeRange(ctx context.Context
 		query += " AND pollutant = $3"
 		args = append(args, pollutant)
 	}
+	keys := page.Sort(Desc("time"), Desc("id"))
+	query += page.After(&args, keys)
+	query += page.OrderBy(&args, keys)
 
 	rows, err := repo.DB.Query(ctx, query, args...)
 	if err != nil {
@@ -118,8 +237,8 @@ func (repo *PollutionRepoImpl) GetAllPolutionWithinTimeRange(ctx context.Context
 	var pollutions []Pollution
 	for rows.Next() {
 		var pollution Pollution
-		err = rows.Scan(&pollution.Latitude, &pollution.Longitude,
-			&pollution.Value, &pollution.IsAnomaly, &pollution.Pollutant)
+		err = rows.Scan(&pollution.ID, &pollution.Time, &pollution.Latitude, &pollution.Longitude,
+			&pollution.Value, &pollution.IsAnomaly, &pollution.Pollutant, &pollution.StationID)
 		if err != nil {
 			return nil, fmt.Errorf("Unable to scan - %s", err.Error())
 		}
@@ -134,23 +253,29 @@ func (repo *PollutionRepoImpl) GetAllPolutionWithinTimeRange(ctx context.Context
 
 }
 
-func (repo *PollutionRepoImpl) GetPollutionDensityOfRect(ctx context.Context, latFrom, latTo, longFrom, longTo float64, from, to time.Time, step time.Duration, pollutant string) ([]PollutionDensity, error) {
+// GetPollutionDensityOfRect computes the aggregate of the readings inside the
+// rect per pollutant and time bucket of step, ordered by pollutant and time.
+// No pollutants selects all of them. Long ranges over large rects are read
+// from the coarsest rollup that fits the step, the rect then selects the
+// rollup cells by their center.
+func (repo *PollutionRepoImpl) GetPollutionDensityOfRect(ctx context.Context, latFrom, latTo, longFrom, longTo float64, from, to time.Time, step time.Duration, agg string, pollutants []string) ([]PollutionDensity, error) {
+	src, from := pickSource(from, to, step, agg, RectExtent(latFrom, latTo, longFrom, longTo))
 	query := `
-    SELECT time_bucket($1, time) AS bucket, AVG(value) FROM air_pollution
+    SELECT time_bucket($1, ` + src.time + `) AS period, pollutant, ` + src.aggregate(agg) + ` FROM ` + src.table + `
     WHERE latitude BETWEEN $2 AND $3 
-        AND longitude BETWEEN $4 AND $5 
-        AND time BETWEEN $6 AND $7 
+        AND ` + longitudeRange("$4", "$5") + `
+        AND ` + src.timeRange("$6", "$7") + `
     `
 	var args []interface{}
 	args = append(args, step, latFrom, latTo, longFrom, longTo, from, to)
 
-	if pollutant != "" {
-		query += " AND pollutant = $8"
-		args = append(args, pollutant)
+	if len(pollutants) > 0 {
+		query += " AND pollutant = ANY($8)"
+		args = append(args, pollutants)
 	}
 	query += `
-        GROUP BY bucket 
-        ORDER BY bucket
+        GROUP BY pollutant, period 
+        ORDER BY pollutant, period
     `
 
 	rows, err := repo.DB.Query(ctx, query, args...)
@@ -163,16 +288,226 @@ func (repo *PollutionRepoImpl) GetPollutionDensityOfRect(ctx context.Context, la
 	var result []PollutionDensity
 	for rows.Next() {
 		var density PollutionDensity
-		density.Pollutant = pollutant
-		err := rows.Scan(&density.Time, &density.Density)
+		err := rows.Scan(&density.Time, &density.Pollutant, &density.Density)
 		if err != nil {
 			return nil, fmt.Errorf("Unable to scan - %s", err.Error())
 		}
 		result = append(result, density)
 	}
 
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows Error: %v\n", rows.Err())
+	}
+
+	return result, nil
+
+}
+
+// longitudeRange builds the longitude condition of a rect. A rect whose west
+// edge is east of its east edge crosses the antimeridian, it covers the
+// longitudes from the west edge to 180 and from -180 to the east edge.
+func longitudeRange(west, east string) string {
+	return fmt.Sprintf(`(CASE WHEN %[1]s::float8 <= %[2]s::float8
+            THEN longitude BETWEEN %[1]s AND %[2]s
+            ELSE longitude >= %[1]s OR longitude <= %[2]s END)`, west, east)
+}
+
+// GetGridCells aggregates the readings per pollutant into square cells of
+// cellSize degrees aligned to 0,0. A nil bounds covers the whole world. At most
+// limit cells are returned.
+func (repo *PollutionRepoImpl) GetGridCells(ctx context.Context, cellSize float64, bounds *Bounds, from, to time.Time, pollutant string, limit int) ([]GridCell, error) {
+	query := `
+    SELECT (floor(latitude / $1) + 0.5) * $1 AS lat, (floor(longitude / $1) + 0.5) * $1 AS lon,
+        pollutant, AVG(value), MAX(value), COUNT(*)
+    FROM air_pollution
+    WHERE time BETWEEN $2 AND $3
+    `
+	var args []interface{}
+	args = append(args, cellSize, from, to)
+
+	if bounds != nil {
+		args = append(args, bounds.LatFrom, bounds.LatTo, bounds.LongFrom, bounds.LongTo)
+		query += " AND latitude BETWEEN $4 AND $5 AND " + longitudeRange("$6", "$7")
+	}
+	if pollutant != "" {
+		args = append(args, pollutant)
+		query += fmt.Sprintf(" AND pollutant = $%d", len(args))
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(`
+        GROUP BY lat, lon, pollutant
+        LIMIT $%d
+    `, len(args))
+
+	rows, err := repo.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var cells []GridCell
+	for rows.Next() {
+		var cell GridCell
+		err := rows.Scan(&cell.Latitude, &cell.Longitude, &cell.Pollutant, &cell.Avg, &cell.Max, &cell.Count)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to scan - %s", err.Error())
+		}
+		cells = append(cells, cell)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows Error: %v\n", rows.Err())
+	}
+
+	return cells, nil
+}
+
+// GetLatestSamples returns the latest value of the pollutant of every sensor
+// inside the bounds that reported in the time range. At most limit samples
+// are returned.
+func (repo *PollutionRepoImpl) GetLatestSamples(ctx context.Context, bounds Bounds, from, to time.Time, pollutant string, limit int) ([]Sample, error) {
+	query := `
+    SELECT latitude, longitude, value, time FROM (
+        SELECT DISTINCT ON (station_id, latitude, longitude)
+            latitude, longitude, value, time
+        FROM air_pollution
+        WHERE time BETWEEN $1 AND $2
+          AND pollutant = $3
+          AND latitude BETWEEN $4 AND $5
+          AND ` + longitudeRange("$6", "$7") + `
+        ORDER BY station_id, latitude, longitude, time DESC
+    ) AS latest
+    LIMIT $8;
+    `
+	rows, err := repo.DB.Query(ctx, query, from, to, pollutant,
+		bounds.LatFrom, bounds.LatTo, bounds.LongFrom, bounds.LongTo, limit)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var samples []Sample
+	for rows.Next() {
+		var sample Sample
+		err := rows.Scan(&sample.Latitude, &sample.Longitude, &sample.Value, &sample.Time)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to scan - %s", err.Error())
+		}
+		samples = append(samples, sample)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows Error: %v\n", rows.Err())
+	}
+
+	return samples, nil
+}
+
+// GetAreaStatistics aggregates the readings inside a GeoJSON Polygon or
+// MultiPolygon per time bucket and pollutant. The area is compared as a
+// geography, so polygons crossing the antimeridian work as expected. Long
+// ranges are read from a rollup like GetPollutionDensityOfRect.
+func (repo *PollutionRepoImpl) GetAreaStatistics(ctx context.Context, geometry string, from, to time.Time, step time.Duration, pollutant string) ([]AreaStatistics, error) {
+	src, from := pickSource(from, to, step, AggAvg, AreaExtent(geometry))
+	query := `
+    SELECT time_bucket($1, ` + src.time + `) AS period, pollutant,
+        ` + src.avg + `, ` + src.min + `, ` + src.max + `, ` + src.stddev + `, ` + src.count + `
+    FROM ` + src.table + `
+    WHERE ` + src.timeRange("$2", "$3") + `
+      AND ST_Intersects(` + src.geog + `, ST_GeomFromGeoJSON($4::text)::geography)
+    `
+	var args []interface{}
+	args = append(args, step, from, to, geometry)
+
+	if pollutant != "" {
+		query += " AND pollutant = $5"
+		args = append(args, pollutant)
+	}
+	query += `
+        GROUP BY period, pollutant
+        ORDER BY period, pollutant
+    `
+
+	rows, err := repo.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var result []AreaStatistics
+	for rows.Next() {
+		var stats AreaStatistics
+		err := rows.Scan(&stats.Time, &stats.Pollutant, &stats.Avg, &stats.Min, &stats.Max, &stats.StdDev, &stats.Count)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to scan - %s", err.Error())
+		}
+		result = append(result, stats)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows Error: %v\n", rows.Err())
+	}
+
 	return result, nil
+}
+
+// GetPollutantAveragesOfArea returns the average value of every pollutant
+// measured inside a GeoJSON Polygon or MultiPolygon in the time range
+func (repo *PollutionRepoImpl) GetPollutantAveragesOfArea(ctx context.Context, geometry string, from, to time.Time) (map[string]float64, error) {
+	query := `
+        SELECT pollutant, AVG(value)
+        FROM air_pollution
+        WHERE time BETWEEN $1 AND $2
+          AND ST_Intersects(geog, ST_GeomFromGeoJSON($3::text)::geography)
+        GROUP BY pollutant;
+    `
+	rows, err := repo.DB.Query(ctx, query, from, to, geometry)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	averages := make(map[string]float64)
+	for rows.Next() {
+		var pollutant string
+		var avg float64
+		if err := rows.Scan(&pollutant, &avg); err != nil {
+			return nil, fmt.Errorf("Unable to scan - %s", err.Error())
+		}
+		averages[pollutant] = avg
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows Error: %v\n", rows.Err())
+	}
+
+	return averages, nil
+}
+
+// GetRegionBoundary returns the boundary of a saved region as GeoJSON, the
+// boolean is false if there is no region with the id
+func (repo *PollutionRepoImpl) GetRegionBoundary(ctx context.Context, id int64) (string, bool, error) {
+	var boundary string
+	err := repo.DB.QueryRow(ctx, `SELECT ST_AsGeoJSON(boundary) FROM regions WHERE id = $1;`, id).Scan(&boundary)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("Unable to scan - %s", err.Error())
+	}
+
+	return boundary, true, nil
+}
+
+// RegionExists reports whether there is a saved region with the name
+func (repo *PollutionRepoImpl) RegionExists(ctx context.Context, name string) (bool, error) {
+	var exists bool
+	err := repo.DB.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM regions WHERE name = $1);`, name).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("Unable to scan - %s", err.Error())
+	}
 
+	return exists, nil
 }
 
 func (repo *PollutionRepoImpl) GetDistinctPollutants(ctx context.Context) ([]string, error) {
@@ -197,9 +532,80 @@ func (repo *PollutionRepoImpl) GetDistinctPollutants(ctx context.Context) ([]str
 	return result, nil
 }
 
-func (repo *PollutionRepoImpl) GetMeanAndStd(ctx context.Context, pollutant string, radius, latitude, longitude float64, from, to time.Time) (float64, float64, error) {
+// GetPollutantAveragesNear returns the average value of every pollutant
+// measured within the radius in the time range
+func (repo *PollutionRepoImpl) GetPollutantAveragesNear(ctx context.Context, latitude, longitude, radius float64, from, to time.Time) (map[string]float64, error) {
+	query := `
+        SELECT pollutant, AVG(value)
+        FROM air_pollution
+        WHERE time BETWEEN $1 AND $2
+          AND ST_DWithin(
+              geog,
+              ST_MakePoint($3,$4)::geography,
+              $5*1000
+        )
+        GROUP BY pollutant;
+    `
+	rows, err := repo.DB.Query(ctx, query, from, to, longitude, latitude, radius)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to query - %s", err.Error())
+	}
+
+	return scanPollutantAverages(rows)
+}
+
+// GetPollutantAveragesOfRect returns the average value of every pollutant
+// measured inside the rect in the time range
+func (repo *PollutionRepoImpl) GetPollutantAveragesOfRect(ctx context.Context, latFrom, latTo, longFrom, longTo float64, from, to time.Time, pollutants []string) (map[string]float64, error) {
+	src, from := pickSource(from, to, 0, AggAvg, RectExtent(latFrom, latTo, longFrom, longTo))
+	query := `
+    SELECT pollutant, ` + src.avg + ` FROM ` + src.table + `
+    WHERE latitude BETWEEN $1 AND $2
+        AND ` + longitudeRange("$3", "$4") + `
+        AND ` + src.timeRange("$5", "$6") + `
+    `
+	var args []interface{}
+	args = append(args, latFrom, latTo, longFrom, longTo, from, to)
+
+	if len(pollutants) > 0 {
+		query += " AND pollutant = ANY($7)"
+		args = append(args, pollutants)
+	}
+	query += " GROUP BY pollutant"
+
+	rows, err := repo.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to query - %s", err.Error())
+	}
+
+	return scanPollutantAverages(rows)
+}
+
+func scanPollutantAverages(rows pgx.Rows) (map[string]float64, error) {
+	defer rows.Close()
+
+	averages := make(map[string]float64)
+	for rows.Next() {
+		var pollutant string
+		var avg float64
+		if err := rows.Scan(&pollutant, &avg); err != nil {
+			return nil, fmt.Errorf("Unable to scan - %s", err.Error())
+		}
+		averages[pollutant] = avg
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows Error: %v\n", rows.Err())
+	}
+
+	return averages, nil
+}
+
+// GetValueStats returns the number, mean and population standard deviation of
+// the values within the radius
+func (repo *PollutionRepoImpl) GetValueStats(ctx context.Context, pollutant string, radius, latitude, longitude float64, from, to time.Time) (ValueStats, error) {
 	query := `
-        SELECT COALESCE(AVG(value), 0), COALESCE(STDDEV_POP(value), 0)
+        SELECT COUNT(*), COALESCE(AVG(value), 0), COALESCE(STDDEV_POP(value), 0)
         FROM air_pollution
         WHERE pollutant = $1
           AND time BETWEEN $2 AND $3 
@@ -210,25 +616,788 @@ func (repo *PollutionRepoImpl) GetMeanAndStd(ctx context.Context, pollutant stri
         );
     `
 	row := repo.DB.QueryRow(ctx, query, pollutant, from, to, longitude, latitude, radius)
-	var mean, stddev float64
-	if err := row.Scan(&mean, &stddev); err != nil {
-		return 0, 0, fmt.Errorf("Unable to scan %s", err.Error())
+	var stats ValueStats
+	if err := row.Scan(&stats.Count, &stats.Mean, &stats.StdDev); err != nil {
+		return stats, fmt.Errorf("Unable to scan %s", err.Error())
+	}
+	return stats, nil
+}
+
+// GetHourOfDayStats is GetValueStats of the values taken at the given hour of
+// the day in UTC
+func (repo *PollutionRepoImpl) GetHourOfDayStats(ctx context.Context, pollutant string, radius, latitude, longitude float64, hour int, from, to time.Time) (ValueStats, error) {
+	query := `
+        SELECT COUNT(*), COALESCE(AVG(value), 0), COALESCE(STDDEV_POP(value), 0)
+        FROM air_pollution
+        WHERE pollutant = $1
+          AND time BETWEEN $2 AND $3
+          AND EXTRACT(HOUR FROM time AT TIME ZONE 'UTC') = $7
+          AND ST_DWithin(
+              geog,
+              ST_MakePoint($4,$5)::geography,
+              $6*1000
+        );
+    `
+	row := repo.DB.QueryRow(ctx, query, pollutant, from, to, longitude, latitude, radius, hour)
+	var stats ValueStats
+	if err := row.Scan(&stats.Count, &stats.Mean, &stats.StdDev); err != nil {
+		return stats, fmt.Errorf("Unable to scan %s", err.Error())
+	}
+	return stats, nil
+}
+
+// GetPreviousValue returns the latest value before `to` within the radius,
+// the boolean is false if there is no reading in the time range.
+func (repo *PollutionRepoImpl) GetPreviousValue(ctx context.Context, pollutant string, radius, latitude, longitude float64, from, to time.Time) (float64, bool, error) {
+	query := `
+        SELECT value
+        FROM air_pollution
+        WHERE pollutant = $1
+          AND time >= $2 AND time < $3
+          AND ST_DWithin(
+              geog,
+              ST_MakePoint($4,$5)::geography,
+              $6*1000
+        )
+        ORDER BY time DESC
+        LIMIT 1;
+    `
+	var value float64
+	err := repo.DB.QueryRow(ctx, query, pollutant, from, to, longitude, latitude, radius).Scan(&value)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("Unable to scan %s", err.Error())
+	}
+	return value, true, nil
+}
+
+// GetValues returns the values within the radius ordered by time, oldest first
+func (repo *PollutionRepoImpl) GetValues(ctx context.Context, pollutant string, radius, latitude, longitude float64, from, to time.Time) ([]TimedValue, error) {
+	query := `
+        SELECT time, value
+        FROM air_pollution
+        WHERE pollutant = $1
+          AND time >= $2 AND time < $3
+          AND ST_DWithin(
+              geog,
+              ST_MakePoint($4,$5)::geography,
+              $6*1000
+        )
+        ORDER BY time;
+    `
+	rows, err := repo.DB.Query(ctx, query, pollutant, from, to, longitude, latitude, radius)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var values []TimedValue
+	for rows.Next() {
+		var v TimedValue
+		if err := rows.Scan(&v.Time, &v.Value); err != nil {
+			return nil, fmt.Errorf("Unable to scan - %s", err.Error())
+		}
+		values = append(values, v)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows Error: %v\n", rows.Err())
 	}
-	return mean, stddev, nil
+
+	return values, nil
 }
 
 func (repo *PollutionRepoImpl) InsertPollution(ctx context.Context, pollution Pollution) error {
 	query := `
     INSERT INTO air_pollution 
-    (time, pollutant, value, is_anomaly, latitude, longitude) 
-    VALUES ($1,$2,$3,$4,$5,$6);
+    (time, pollutant, value, is_anomaly, anomaly_reasons, latitude, longitude, station_id) 
+    VALUES ($1,$2,$3,$4,$5,$6,$7,NULLIF($8, ''))
+    RETURNING id;
     `
-	_, err := repo.DB.Exec(ctx, query,
+	tx, err := repo.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("Unable to begin transaction - %s", err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, query,
 		pollution.Time, pollution.Pollutant, pollution.Value,
-		pollution.IsAnomaly, pollution.Latitude, pollution.Longitude)
+		pollution.IsAnomaly, pollution.Anomalies, pollution.Latitude, pollution.Longitude, pollution.StationID).Scan(&pollution.ID)
 	if err != nil {
 		return fmt.Errorf("Failed to insert into database - %s", err.Error())
 	}
 
+	if err = insertAnomalies(ctx, tx, []Pollution{pollution}); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("Unable to commit transaction - %s", err.Error())
+	}
+
 	return nil
 }
+
+// InsertPollutionBatch stores the readings and their anomalies with a single
+// COPY in one transaction
+func (repo *PollutionRepoImpl) InsertPollutionBatch(ctx context.Context, pollutions []Pollution) error {
+	tx, err := repo.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("Unable to begin transaction - %s", err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	if err = reserveReadingIDs(ctx, tx, pollutions); err != nil {
+		return err
+	}
+
+	_, err = tx.CopyFrom(ctx,
+		pgx.Identifier{"air_pollution"},
+		[]string{"id", "time", "pollutant", "value", "is_anomaly", "anomaly_reasons", "latitude", "longitude", "station_id"},
+		pgx.CopyFromSlice(len(pollutions), func(i int) ([]any, error) {
+			p := pollutions[i]
+			var stationID *string
+			if p.StationID != "" {
+				stationID = &p.StationID
+			}
+			return []any{p.ID, p.Time, p.Pollutant, p.Value, p.IsAnomaly, p.Anomalies, p.Latitude, p.Longitude, stationID}, nil
+		}),
+	)
+	if err != nil {
+		return fmt.Errorf("Failed to copy into database - %s", err.Error())
+	}
+
+	if err = insertAnomalies(ctx, tx, pollutions); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("Unable to commit transaction - %s", err.Error())
+	}
+
+	return nil
+}
+
+// GetNewReadings returns the readings that are not stored yet, a reading is
+// identified by its time, pollutant, position and station
+func (repo *PollutionRepoImpl) GetNewReadings(ctx context.Context, pollutions []Pollution) ([]Pollution, error) {
+	times := make([]time.Time, len(pollutions))
+	pollutants := make([]string, len(pollutions))
+	latitudes := make([]float64, len(pollutions))
+	longitudes := make([]float64, len(pollutions))
+	stationIDs := make([]string, len(pollutions))
+	for i, p := range pollutions {
+		times[i], pollutants[i], latitudes[i], longitudes[i], stationIDs[i] = p.Time, p.Pollutant, p.Latitude, p.Longitude, p.StationID
+	}
+
+	query := `
+    SELECT r.idx - 1
+    FROM unnest($1::timestamptz[], $2::text[], $3::float8[], $4::float8[], $5::text[])
+        WITH ORDINALITY AS r(time, pollutant, latitude, longitude, station_id, idx)
+    WHERE NOT EXISTS (
+        SELECT FROM air_pollution a
+        WHERE a.time = r.time AND a.pollutant = r.pollutant
+          AND a.latitude = r.latitude AND a.longitude = r.longitude
+          AND a.station_id IS NOT DISTINCT FROM NULLIF(r.station_id, '')
+    )
+    ORDER BY r.idx
+    `
+	rows, err := repo.DB.Query(ctx, query, times, pollutants, latitudes, longitudes, stationIDs)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var fresh []Pollution
+	for rows.Next() {
+		var idx int
+		if err := rows.Scan(&idx); err != nil {
+			return nil, fmt.Errorf("Unable to scan - %s", err.Error())
+		}
+		fresh = append(fresh, pollutions[idx])
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows Error: %v\n", rows.Err())
+	}
+
+	return fresh, nil
+}
+
+// ImportPollutionBatch copies the readings into a staging table, drops the
+// ones that are already stored and inserts the rest with their anomalies. It
+// returns the inserted readings.
+func (repo *PollutionRepoImpl) ImportPollutionBatch(ctx context.Context, pollutions []Pollution) ([]Pollution, error) {
+	tx, err := repo.DB.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to begin transaction - %s", err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	if err = reserveReadingIDs(ctx, tx, pollutions); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(ctx, `
+    CREATE TEMP TABLE import_staging (
+        idx              INTEGER           NOT NULL,
+        id               BIGINT            NOT NULL,
+        time             TIMESTAMPTZ       NOT NULL,
+        pollutant        TEXT              NOT NULL,
+        value            DOUBLE PRECISION  NOT NULL,
+        is_anomaly       BOOLEAN           NOT NULL,
+        anomaly_reasons  JSONB,
+        latitude         DOUBLE PRECISION  NOT NULL,
+        longitude        DOUBLE PRECISION  NOT NULL,
+        station_id       TEXT
+    ) ON COMMIT DROP;
+    `)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to create staging table - %s", err.Error())
+	}
+
+	_, err = tx.CopyFrom(ctx,
+		pgx.Identifier{"import_staging"},
+		[]string{"idx", "id", "time", "pollutant", "value", "is_anomaly", "anomaly_reasons", "latitude", "longitude", "station_id"},
+		pgx.CopyFromSlice(len(pollutions), func(i int) ([]any, error) {
+			p := pollutions[i]
+			var stationID *string
+			if p.StationID != "" {
+				stationID = &p.StationID
+			}
+			return []any{i, p.ID, p.Time, p.Pollutant, p.Value, p.IsAnomaly, p.Anomalies, p.Latitude, p.Longitude, stationID}, nil
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to copy into staging table - %s", err.Error())
+	}
+
+	rows, err := tx.Query(ctx, `
+    DELETE FROM import_staging s
+    WHERE EXISTS (
+        SELECT FROM air_pollution a
+        WHERE a.time = s.time AND a.pollutant = s.pollutant
+          AND a.latitude = s.latitude AND a.longitude = s.longitude
+          AND a.station_id IS NOT DISTINCT FROM s.station_id
+    )
+    RETURNING idx;
+    `)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to query - %s", err.Error())
+	}
+
+	duplicates := make(map[int]bool)
+	for rows.Next() {
+		var idx int
+		if err := rows.Scan(&idx); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("Unable to scan - %s", err.Error())
+		}
+		duplicates[idx] = true
+	}
+	rows.Close()
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows Error: %v\n", rows.Err())
+	}
+
+	_, err = tx.Exec(ctx, `
+    INSERT INTO air_pollution (id, time, pollutant, value, is_anomaly, anomaly_reasons, latitude, longitude, station_id)
+    SELECT id, time, pollutant, value, is_anomaly, anomaly_reasons, latitude, longitude, station_id
+    FROM import_staging;
+    `)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to insert from staging table - %s", err.Error())
+	}
+
+	inserted := make([]Pollution, 0, len(pollutions)-len(duplicates))
+	for i, p := range pollutions {
+		if !duplicates[i] {
+			inserted = append(inserted, p)
+		}
+	}
+
+	if err = insertAnomalies(ctx, tx, inserted); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("Unable to commit transaction - %s", err.Error())
+	}
+
+	return inserted, nil
+}
+
+// reserveReadingIDs takes an id for each reading from the sequence of
+// air_pollution, so the readings can be copied together with the anomalies
+// that refer to them
+func reserveReadingIDs(ctx context.Context, tx pgx.Tx, pollutions []Pollution) error {
+	rows, err := tx.Query(ctx, `SELECT nextval('air_pollution_id_seq') FROM generate_series(1, $1);`, len(pollutions))
+	if err != nil {
+		return fmt.Errorf("Unable to query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	for i := 0; rows.Next(); i++ {
+		if err := rows.Scan(&pollutions[i].ID); err != nil {
+			return fmt.Errorf("Unable to scan - %s", err.Error())
+		}
+	}
+
+	if rows.Err() != nil {
+		return fmt.Errorf("rows Error: %v\n", rows.Err())
+	}
+
+	return nil
+}
+
+// insertAnomalies stores a row in the anomalies table for every detection of
+// the readings, the readings must have their ids
+func insertAnomalies(ctx context.Context, tx pgx.Tx, pollutions []Pollution) error {
+	var rows [][]any
+	for _, p := range pollutions {
+		for _, d := range p.Anomalies {
+			rows = append(rows, []any{p.ID, p.Time, p.Pollutant, p.Latitude, p.Longitude, p.Value,
+				d.Detector, d.Score, d.Mean, d.StdDev, d.Threshold, d.Severity(), d.Reason})
+		}
+	}
+
+	if len(rows) == 0 {
+		return nil
+	}
+
+	_, err := tx.CopyFrom(ctx,
+		pgx.Identifier{"anomalies"},
+		[]string{"reading_id", "time", "pollutant", "latitude", "longitude", "value",
+			"detector", "score", "baseline_mean", "baseline_stddev", "threshold", "severity", "reason"},
+		pgx.CopyFromRows(rows),
+	)
+	if err != nil {
+		return fmt.Errorf("Failed to copy anomalies into database - %s", err.Error())
+	}
+
+	return nil
+}
+
+func (repo *PollutionRepoImpl) InsertRejectedReading(ctx context.Context, payload []byte, errs ValidationErrors) error {
+	query := `
+    INSERT INTO rejected_readings (payload, errors)
+    VALUES ($1,$2);
+    `
+	errsJson, err := json.Marshal(errs)
+	if err != nil {
+		return fmt.Errorf("Failed to marshal validation errors - %s", err.Error())
+	}
+
+	_, err = repo.DB.Exec(ctx, query, string(payload), errsJson)
+	if err != nil {
+		return fmt.Errorf("Failed to insert into database - %s", err.Error())
+	}
+
+	return nil
+}
+
+// GetStationLocation looks up a registered station, the boolean is false if
+// there is no station with the id
+func (repo *PollutionRepoImpl) GetStationLocation(ctx context.Context, id string) (StationLocation, bool, error) {
+	query := `SELECT latitude, longitude, pollutants, active FROM stations WHERE id = $1;`
+
+	var station StationLocation
+	err := repo.DB.QueryRow(ctx, query, id).Scan(&station.Latitude, &station.Longitude, &station.Pollutants, &station.Active)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return station, false, nil
+	}
+	if err != nil {
+		return station, false, fmt.Errorf("Unable to scan - %s", err.Error())
+	}
+
+	return station, true, nil
+}
+
+// GetNearestReadings returns the readings within the radius closest to the
+// point, the newest first among readings at the same distance
+func (repo *PollutionRepoImpl) GetNearestReadings(ctx context.Context, latitude, longitude, radius float64, from, to time.Time, pollutant string, limit int) ([]NearbyReading, error) {
+	query := `
+    SELECT time, latitude, longitude, value, pollutant, is_anomaly, COALESCE(station_id, ''),
+        ST_Distance(geog, ST_MakePoint($3,$4)::geography) / 1000 AS distance
+    FROM air_pollution
+    WHERE time BETWEEN $1 AND $2
+      AND ST_DWithin(geog, ST_MakePoint($3,$4)::geography, $5*1000)
+    `
+	var args []interface{}
+	args = append(args, from, to, longitude, latitude, radius, limit)
+
+	if pollutant != "" {
+		query += " AND pollutant = $7"
+		args = append(args, pollutant)
+	}
+	query += " ORDER BY distance, time DESC LIMIT $6"
+
+	rows, err := repo.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var readings []NearbyReading
+	for rows.Next() {
+		var r NearbyReading
+		err := rows.Scan(&r.Time, &r.Latitude, &r.Longitude, &r.Value, &r.Pollutant, &r.IsAnomaly, &r.StationID, &r.DistanceKm)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to scan - %s", err.Error())
+		}
+		readings = append(readings, r)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows Error: %v\n", rows.Err())
+	}
+
+	return readings, nil
+}
+
+// GetNearestSensorsLatest returns the `limit` sensors closest to the point
+// that reported within the radius and time range, with the latest value of
+// every pollutant they reported in it
+func (repo *PollutionRepoImpl) GetNearestSensorsLatest(ctx context.Context, latitude, longitude, radius float64, from, to time.Time, pollutant string, limit int) ([]NearbySensor, error) {
+	filter := ""
+	var args []interface{}
+	args = append(args, from, to, longitude, latitude, radius, limit)
+	if pollutant != "" {
+		filter = " AND pollutant = $7"
+		args = append(args, pollutant)
+	}
+
+	query := `
+    WITH latest AS (
+        SELECT DISTINCT ON (station_id, latitude, longitude, pollutant)
+            station_id, latitude, longitude, pollutant, value, time,
+            ST_Distance(geog, ST_MakePoint($3,$4)::geography) / 1000 AS distance
+        FROM air_pollution
+        WHERE time BETWEEN $1 AND $2
+          AND ST_DWithin(geog, ST_MakePoint($3,$4)::geography, $5*1000)` + filter + `
+        ORDER BY station_id, latitude, longitude, pollutant, time DESC
+    ), nearest AS (
+        SELECT station_id, latitude, longitude, MIN(distance) AS distance
+        FROM latest
+        GROUP BY station_id, latitude, longitude
+        ORDER BY distance
+        LIMIT $6
+    )
+    SELECT COALESCE(n.station_id, ''), n.latitude, n.longitude, n.distance, l.pollutant, l.value, l.time
+    FROM nearest n
+    JOIN latest l ON l.station_id IS NOT DISTINCT FROM n.station_id
+        AND l.latitude = n.latitude AND l.longitude = n.longitude
+    ORDER BY n.distance, n.station_id, n.latitude, n.longitude, l.pollutant;
+    `
+	rows, err := repo.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var sensors []NearbySensor
+	for rows.Next() {
+		var sensor NearbySensor
+		var value PollutionValueResponse
+		err := rows.Scan(&sensor.StationID, &sensor.Latitude, &sensor.Longitude, &sensor.DistanceKm,
+			&value.Pollutant, &value.Value, &value.Time)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to scan - %s", err.Error())
+		}
+
+		// Rows of the same sensor are adjacent
+		last := len(sensors) - 1
+		if last < 0 || sensors[last].StationID != sensor.StationID ||
+			sensors[last].Latitude != sensor.Latitude || sensors[last].Longitude != sensor.Longitude {
+			sensors = append(sensors, sensor)
+			last++
+		}
+		sensors[last].Latest = append(sensors[last].Latest, value)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows Error: %v\n", rows.Err())
+	}
+
+	return sensors, nil
+}
+
+// GetNearestStations returns the registered stations within the radius closest to the point
+func (repo *PollutionRepoImpl) GetNearestStations(ctx context.Context, latitude, longitude, radius float64, limit int) ([]NearbyStation, error) {
+	query := `
+    SELECT id, name, latitude, longitude, pollutants, active,
+        ST_Distance(geog, ST_MakePoint($1,$2)::geography) / 1000 AS distance
+    FROM stations
+    WHERE ST_DWithin(geog, ST_MakePoint($1,$2)::geography, $3*1000)
+    ORDER BY distance
+    LIMIT $4;
+    `
+	rows, err := repo.DB.Query(ctx, query, longitude, latitude, radius, limit)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var stations []NearbyStation
+	for rows.Next() {
+		var s NearbyStation
+		err := rows.Scan(&s.ID, &s.Name, &s.Latitude, &s.Longitude, &s.Pollutants, &s.Active, &s.DistanceKm)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to scan - %s", err.Error())
+		}
+		stations = append(stations, s)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows Error: %v\n", rows.Err())
+	}
+
+	return stations, nil
+}
+
+const anomalyRuleColumns = `
+    pollutant, region, ST_AsGeoJSON(boundary), detectors, radius_km,
+    window_minutes, zscore_threshold, static_threshold,
+    rate_window_minutes, max_increase_percent,
+    ewma_window_minutes, ewma_alpha, ewma_threshold,
+    seasonal_days, seasonal_threshold, updated_at, updated_by
+`
+
+func scanAnomalyRule(row pgx.Row) (AnomalyRule, error) {
+	var rule AnomalyRule
+	var boundary *string
+	err := row.Scan(&rule.Pollutant, &rule.Region, &boundary, &rule.Detectors, &rule.RadiusKm,
+		&rule.WindowMinutes, &rule.ZScoreThreshold, &rule.StaticThreshold,
+		&rule.RateWindowMinutes, &rule.MaxIncreasePercent,
+		&rule.EWMAWindowMinutes, &rule.EWMAAlpha, &rule.EWMAThreshold,
+		&rule.SeasonalDays, &rule.SeasonalThreshold, &rule.UpdatedAt, &rule.UpdatedBy)
+	if err != nil {
+		return rule, err
+	}
+
+	if boundary != nil {
+		rule.Boundary = json.RawMessage(*boundary)
+	}
+
+	return rule, nil
+}
+
+func (repo *PollutionRepoImpl) GetAnomalyRules(ctx context.Context) ([]AnomalyRule, error) {
+	query := `SELECT ` + anomalyRuleColumns + ` FROM anomaly_rules ORDER BY pollutant, region;`
+
+	rows, err := repo.DB.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var rules []AnomalyRule
+	for rows.Next() {
+		rule, err := scanAnomalyRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to scan - %s", err.Error())
+		}
+		rules = append(rules, rule)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows Error: %v\n", rows.Err())
+	}
+
+	return rules, nil
+}
+
+func (repo *PollutionRepoImpl) GetAnomalyRule(ctx context.Context, pollutant, region string) (AnomalyRule, bool, error) {
+	query := `SELECT ` + anomalyRuleColumns + ` FROM anomaly_rules WHERE pollutant = $1 AND region = $2;`
+
+	rule, err := scanAnomalyRule(repo.DB.QueryRow(ctx, query, pollutant, region))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return rule, false, nil
+	}
+	if err != nil {
+		return rule, false, fmt.Errorf("Unable to scan - %s", err.Error())
+	}
+
+	return rule, true, nil
+}
+
+// anomalyRuleBoundary is the area a rule applies in, its own boundary or the
+// boundary of the saved region with the same name
+const anomalyRuleBoundary = `COALESCE(boundary, (SELECT r.boundary FROM regions r WHERE r.name = anomaly_rules.region))`
+
+// GetMatchingAnomalyRule returns the rule that applies to a reading: the
+// smallest region containing the point, or the global rule of the pollutant.
+func (repo *PollutionRepoImpl) GetMatchingAnomalyRule(ctx context.Context, pollutant string, latitude, longitude float64) (AnomalyRule, bool, error) {
+	query := `
+    SELECT ` + anomalyRuleColumns + ` FROM anomaly_rules
+    WHERE pollutant = $1
+      AND (region = '' OR ST_Covers(` + anomalyRuleBoundary + `, ST_MakePoint($2,$3)::geography))
+    ORDER BY region = '', ST_Area(` + anomalyRuleBoundary + `)
+    LIMIT 1;
+    `
+	rule, err := scanAnomalyRule(repo.DB.QueryRow(ctx, query, pollutant, longitude, latitude))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return rule, false, nil
+	}
+	if err != nil {
+		return rule, false, fmt.Errorf("Unable to scan - %s", err.Error())
+	}
+
+	return rule, true, nil
+}
+
+// SaveAnomalyRule creates or replaces the rule of the pollutant and region and
+// records the change in the audit table.
+func (repo *PollutionRepoImpl) SaveAnomalyRule(ctx context.Context, rule AnomalyRule, changedBy string) (AnomalyRule, error) {
+	tx, err := repo.DB.Begin(ctx)
+	if err != nil {
+		return rule, fmt.Errorf("Unable to begin transaction - %s", err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	selectQuery := `SELECT ` + anomalyRuleColumns + ` FROM anomaly_rules WHERE pollutant = $1 AND region = $2 FOR UPDATE;`
+	old, err := scanAnomalyRule(tx.QueryRow(ctx, selectQuery, rule.Pollutant, rule.Region))
+	exists := err == nil
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return rule, fmt.Errorf("Unable to scan - %s", err.Error())
+	}
+
+	var boundary *string
+	if len(rule.Boundary) > 0 {
+		b := string(rule.Boundary)
+		boundary = &b
+	}
+
+	upsertQuery := `
+    INSERT INTO anomaly_rules (
+        pollutant, region, boundary, detectors, radius_km,
+        window_minutes, zscore_threshold, static_threshold,
+        rate_window_minutes, max_increase_percent,
+        ewma_window_minutes, ewma_alpha, ewma_threshold,
+        seasonal_days, seasonal_threshold, updated_at, updated_by
+    ) VALUES ($1,$2,ST_GeomFromGeoJSON($3::text)::geography,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,now(),$16)
+    ON CONFLICT (pollutant, region) DO UPDATE SET
+        boundary = EXCLUDED.boundary,
+        detectors = EXCLUDED.detectors,
+        radius_km = EXCLUDED.radius_km,
+        window_minutes = EXCLUDED.window_minutes,
+        zscore_threshold = EXCLUDED.zscore_threshold,
+        static_threshold = EXCLUDED.static_threshold,
+        rate_window_minutes = EXCLUDED.rate_window_minutes,
+        max_increase_percent = EXCLUDED.max_increase_percent,
+        ewma_window_minutes = EXCLUDED.ewma_window_minutes,
+        ewma_alpha = EXCLUDED.ewma_alpha,
+        ewma_threshold = EXCLUDED.ewma_threshold,
+        seasonal_days = EXCLUDED.seasonal_days,
+        seasonal_threshold = EXCLUDED.seasonal_threshold,
+        updated_at = EXCLUDED.updated_at,
+        updated_by = EXCLUDED.updated_by
+    RETURNING ` + anomalyRuleColumns + `;
+    `
+	saved, err := scanAnomalyRule(tx.QueryRow(ctx, upsertQuery,
+		rule.Pollutant, rule.Region, boundary, rule.Detectors, rule.RadiusKm,
+		rule.WindowMinutes, rule.ZScoreThreshold, rule.StaticThreshold,
+		rule.RateWindowMinutes, rule.MaxIncreasePercent,
+		rule.EWMAWindowMinutes, rule.EWMAAlpha, rule.EWMAThreshold,
+		rule.SeasonalDays, rule.SeasonalThreshold, changedBy))
+	if err != nil {
+		return rule, fmt.Errorf("Failed to save anomaly rule - %s", err.Error())
+	}
+
+	action := "create"
+	var oldRule *AnomalyRule
+	if exists {
+		action = "update"
+		oldRule = &old
+	}
+
+	if err = insertAnomalyRuleAudit(ctx, tx, changedBy, action, rule.Pollutant, rule.Region, oldRule, &saved); err != nil {
+		return rule, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return rule, fmt.Errorf("Unable to commit transaction - %s", err.Error())
+	}
+
+	return saved, nil
+}
+
+func (repo *PollutionRepoImpl) DeleteAnomalyRule(ctx context.Context, pollutant, region, changedBy string) (bool, error) {
+	tx, err := repo.DB.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("Unable to begin transaction - %s", err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	query := `DELETE FROM anomaly_rules WHERE pollutant = $1 AND region = $2 RETURNING ` + anomalyRuleColumns + `;`
+	old, err := scanAnomalyRule(tx.QueryRow(ctx, query, pollutant, region))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("Failed to delete anomaly rule - %s", err.Error())
+	}
+
+	if err = insertAnomalyRuleAudit(ctx, tx, changedBy, "delete", pollutant, region, &old, nil); err != nil {
+		return false, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("Unable to commit transaction - %s", err.Error())
+	}
+
+	return true, nil
+}
+
+func insertAnomalyRuleAudit(ctx context.Context, tx pgx.Tx, changedBy, action, pollutant, region string, oldRule, newRule *AnomalyRule) error {
+	query := `
+    INSERT INTO anomaly_rule_audit (changed_by, action, pollutant, region, old_rule, new_rule)
+    VALUES ($1,$2,$3,$4,$5,$6);
+    `
+	_, err := tx.Exec(ctx, query, changedBy, action, pollutant, region, oldRule, newRule)
+	if err != nil {
+		return fmt.Errorf("Failed to insert audit entry - %s", err.Error())
+	}
+
+	return nil
+}
+
+func (repo *PollutionRepoImpl) GetAnomalyRuleAudit(ctx context.Context, pollutant string, page Page) ([]AnomalyRuleAudit, error) {
+	query := `
+    SELECT id, changed_at, changed_by, action, pollutant, region, old_rule, new_rule
+    FROM anomaly_rule_audit WHERE true
+    `
+	var args []interface{}
+	if pollutant != "" {
+		args = append(args, pollutant)
+		query += fmt.Sprintf(" AND pollutant = $%d", len(args))
+	}
+	keys := page.SortBy(Desc("changed_at"), Desc("id"))
+	query += page.After(&args, keys)
+	query += page.OrderBy(&args, keys)
+
+	rows, err := repo.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var entries []AnomalyRuleAudit
+	for rows.Next() {
+		var entry AnomalyRuleAudit
+		err := rows.Scan(&entry.ID, &entry.ChangedAt, &entry.ChangedBy, &entry.Action,
+			&entry.Pollutant, &entry.Region, &entry.OldRule, &entry.NewRule)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to scan - %s", err.Error())
+		}
+		entries = append(entries, entry)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows Error: %v\n", rows.Err())
+	}
+
+	return entries, nil
+}
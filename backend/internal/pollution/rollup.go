@@ -0,0 +1,181 @@
+package pollution
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Rollup is a continuous aggregate of air_pollution with buckets of Width
+// per pollutant and location cell
+type Rollup struct {
+	View  string
+	Width time.Duration
+}
+
+// Rollups are ordered from the coarsest to the finest
+var Rollups = []Rollup{
+	{View: "air_pollution_1d", Width: 24 * time.Hour},
+	{View: "air_pollution_1h", Width: time.Hour},
+	{View: "air_pollution_1m", Width: time.Minute},
+}
+
+// RollupCellSize is the size of the location cells of the rollups in degrees,
+// a rollup row is positioned at the center of its cell
+const RollupCellSize = 0.01
+
+// minRollupBuckets is how many rollup buckets a time range must span for the
+// rollup to be used. The buckets at the ends of the range are counted as a
+// whole, so a short range is read from the raw readings.
+const minRollupBuckets = 24
+
+// minRollupCells is how many rollup cells the shorter side of a rect or area
+// must span for a rollup to be used. Rollup rows are matched by the center of
+// their cell, which moves the edges by up to half a cell, so smaller rects and
+// areas are read from the raw readings.
+const minRollupCells = 10
+
+// RectExtent returns the shorter side of a rect in degrees
+func RectExtent(latFrom, latTo, longFrom, longTo float64) float64 {
+	width := longTo - longFrom
+	if width < 0 {
+		// The rect crosses the antimeridian
+		width += 360
+	}
+
+	return min(math.Abs(latTo-latFrom), width)
+}
+
+// PickRollup returns the coarsest rollup whose buckets fit evenly into the
+// step, false if the range should be read from the raw readings. A zero step
+// aggregates the whole range at once.
+func PickRollup(from, to time.Time, step time.Duration) (Rollup, bool) {
+	for _, r := range Rollups {
+		if (step == 0 || step%r.Width == 0) && to.Sub(from) >= minRollupBuckets*r.Width {
+			return r, true
+		}
+	}
+
+	return Rollup{}, false
+}
+
+// aggregateSource is the table aggregate queries read from, with the SQL
+// expressions of the aggregates over it
+type aggregateSource struct {
+	table  string
+	time   string
+	avg    string
+	min    string
+	max    string
+	stddev string
+	count  string
+	// p50 and p95 are empty if the source can not compute percentiles
+	p50 string
+	p95 string
+	// geog is the position of a row as a geography
+	geog string
+}
+
+var rawSource = aggregateSource{
+	table:  "air_pollution",
+	time:   "time",
+	avg:    "AVG(value)",
+	min:    "MIN(value)",
+	max:    "MAX(value)",
+	stddev: "COALESCE(STDDEV_POP(value), 0)",
+	count:  "COUNT(*)",
+	p50:    "percentile_cont(0.5) WITHIN GROUP (ORDER BY value)",
+	p95:    "percentile_cont(0.95) WITHIN GROUP (ORDER BY value)",
+	geog:   "geog",
+}
+
+// aggregate returns the SQL of the aggregate, empty if the source can not
+// compute it
+func (src aggregateSource) aggregate(agg string) string {
+	switch agg {
+	case AggAvg:
+		return src.avg
+	case AggMin:
+		return src.min
+	case AggMax:
+		return src.max
+	case AggP50:
+		return src.p50
+	case AggP95:
+		return src.p95
+	case AggCount:
+		// COUNT is a bigint, the aggregates are scanned as floats
+		return src.count + "::float8"
+	case AggStdDev:
+		return src.stddev
+	}
+
+	return ""
+}
+
+// timeRange returns the condition selecting the rows between the from and to
+// parameters. Rollup rows are selected by the start of their bucket, only the
+// buckets starting before to are counted.
+func (src aggregateSource) timeRange(from, to string) string {
+	if src.table == rawSource.table {
+		return src.time + " BETWEEN " + from + " AND " + to
+	}
+
+	return src.time + " >= " + from + " AND " + src.time + " < " + to
+}
+
+func rollupSource(r Rollup) aggregateSource {
+	return aggregateSource{
+		table: r.View,
+		time:  "bucket",
+		avg:   "SUM(sum_value) / SUM(count)",
+		min:   "MIN(min_value)",
+		max:   "MAX(max_value)",
+		// Population variance from the sums, clamped against rounding errors
+		stddev: "sqrt(GREATEST(SUM(sum_squares) / SUM(count) - (SUM(sum_value) / SUM(count)) ^ 2, 0))",
+		count:  "SUM(count)::bigint",
+		geog:   "ST_SetSRID(ST_MakePoint(longitude, latitude), 4326)::geography",
+	}
+}
+
+// pickSource returns the source to compute the aggregate over the range in
+// buckets of step from, and the start of the range in it. extent is the
+// shorter side of the rect or area in degrees, see minRollupCells. Reading a
+// rollup widens the start of the range to the start of the bucket it falls
+// in, and the last bucket starting before to is counted as a whole.
+// Percentiles can not be combined from rollup rows, they are always read from
+// the raw readings.
+func pickSource(from, to time.Time, step time.Duration, agg string, extent float64) (aggregateSource, time.Time) {
+	r, ok := PickRollup(from, to, step)
+	if !ok || rollupSource(r).aggregate(agg) == "" || extent < minRollupCells*RollupCellSize {
+		return rawSource, from
+	}
+
+	return rollupSource(r), from.Truncate(r.Width)
+}
+
+// UsesRollup reports whether pickSource reads the aggregate from a rollup
+func UsesRollup(from, to time.Time, step time.Duration, agg string, extent float64) bool {
+	src, _ := pickSource(from, to, step, agg, extent)
+	return src.table != rawSource.table
+}
+
+// RefreshRollups materializes the rollups over the time range, for readings
+// older than the refresh windows of the rollup policies
+func (repo *PollutionRepoImpl) RefreshRollups(ctx context.Context, from, to time.Time) error {
+	// Finest first, the coarser rollups are built on it
+	for i := len(Rollups) - 1; i >= 0; i-- {
+		r := Rollups[i]
+		// The window must cover whole buckets
+		start := from.Truncate(r.Width)
+		end := to.Truncate(r.Width).Add(r.Width)
+
+		_, err := repo.DB.Exec(ctx, `CALL refresh_continuous_aggregate($1::text::regclass, $2::timestamptz, $3::timestamptz)`, r.View, start, end)
+		if err != nil {
+			return fmt.Errorf("failed to refresh %s - %s", r.View, err.Error())
+		}
+	}
+
+	return nil
+}
@@ -3,9 +3,10 @@ package pollution
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
-	"math"
+	"sort"
 	"time"
 
 	"github.com/AkifSahn/pollution-tracker/internal/notification"
@@ -21,83 +22,144 @@ func NewPollutionService(repo PollutionRepo) *PollutionService {
 	return &PollutionService{repo: repo}
 }
 
-var anomalyThresholds map[string]float64 = map[string]float64{
-	"PM2.5": 150,
-	"PM10":  180,
-	"NO2":   150,
-	"SO2":   100,
-	"O3":    200,
-}
-
 func (s *PollutionService) ProcessAndInsertPollutionEntry(ctx context.Context, entry Pollution) error {
-	fromTime := entry.Time.Add(-24 * time.Hour)
-	// Get mean and stddev for pollution values for 25 km radius
-	mean, stddev, err := s.repo.GetMeanAndStd(ctx, entry.Pollutant, 25, entry.Latitude, entry.Longitude, fromTime, entry.Time)
-	if err != nil {
-		return fmt.Errorf("failed to get mean and std: %s", err.Error())
+	if err := s.detectAnomaly(ctx, &entry); err != nil {
+		return err
 	}
 
-	var zscore float64
-	if stddev > 0 {
-		zscore = (entry.Value - mean) / stddev
-	} else {
-		zscore = 0
+	if err := s.repo.InsertPollution(ctx, entry); err != nil {
+		return fmt.Errorf("failed to insert pollution entry - %s", err.Error())
 	}
 
-	/*
-	   These threshold values correspond to extreme situations and
-	   might not correspond to real-life thresholds.
-	   These exists for testing purposes.
-	   These thresholds are also used in the `auto-test.sh` script
-
-	   "PM2.5" anomaly_min=150;
-	   "PM10"  anomaly_min=180;
-	   "NO2"   anomaly_min=150;
-	   "SO2"   anomaly_min=100;
-	   "O3"    anomaly_min=200;
-	*/
-
-	if math.Abs(zscore) > 2 || entry.Value >= anomalyThresholds[entry.Pollutant] {
-		entry.IsAnomaly = true
-	} else {
-		entry.IsAnomaly = false
+	if entry.IsAnomaly {
+		publishAnomalyNotification(entry)
 	}
 
-	fmt.Printf("Val: %f, Mean: %f, Std: %f, Z: %f", entry.Value, mean, stddev, zscore)
+	return nil
+}
 
-	if err := s.repo.InsertPollution(ctx, entry); err != nil {
-		return fmt.Errorf("failed to insert pollution entry - %s", err.Error())
+// ProcessAndInsertPollutionBatch runs anomaly detection for the entries in
+// time order, comparing every entry with the stored readings and the earlier
+// entries of the batch, then inserts the whole batch with a single COPY. A
+// failing detector fails the batch so it is retried like a single entry.
+// skipStored drops the entries that are already stored, which an earlier
+// attempt of the batch may have inserted.
+func (s *PollutionService) ProcessAndInsertPollutionBatch(ctx context.Context, entries []Pollution, skipStored bool) error {
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.Before(entries[j].Time) })
+
+	if skipStored {
+		var err error
+		if entries, err = s.repo.GetNewReadings(ctx, entries); err != nil {
+			return fmt.Errorf("failed to look up stored entries - %s", err.Error())
+		}
 	}
 
-	if entry.IsAnomaly {
-		notification := notification.Notification{
-			Type:      1,
-			Message:   "Anomaly detected!",
-			Latitude:  entry.Latitude,
-			Longitude: entry.Longitude,
-			Value:     entry.Value,
-			Pollutant: entry.Pollutant,
+	history := &batchHistory{PollutionRepo: s.repo, pending: make([]Pollution, 0, len(entries))}
+	for i := range entries {
+		entry := &entries[i]
+		if err := s.detectAnomalyIn(ctx, history, entry); err != nil {
+			return fmt.Errorf("anomaly detection of the %s entry at %s failed - %s",
+				entry.Pollutant, entry.Time.Format(time.RFC3339), err.Error())
 		}
+		history.pending = append(history.pending, *entry)
+	}
 
-		msg, err := json.Marshal(notification)
-		if err != nil {
-			log.Printf("Failed to marshal anomaly notification - %s", err.Error())
-		} else {
-			err = rabbitmq.AmqpCh.Publish(
-				"",
-				"notification_queue",
-				false,
-				false,
-				amqp.Publishing{
-					ContentType: "application/json",
-					Body:        msg,
-				},
-			)
-			if err != nil {
-				log.Printf("Failed to publish anomaly notification - %s", err.Error())
-			}
+	if err := s.repo.InsertPollutionBatch(ctx, entries); err != nil {
+		return fmt.Errorf("failed to insert pollution batch - %s", err.Error())
+	}
+
+	for _, entry := range entries {
+		if entry.IsAnomaly {
+			publishAnomalyNotification(entry)
 		}
 	}
 
 	return nil
 }
+
+// RejectReading stores a reading that failed validation so it can be
+// inspected later instead of being dropped.
+func (s *PollutionService) RejectReading(ctx context.Context, payload []byte, errs ValidationErrors) error {
+	if err := s.repo.InsertRejectedReading(ctx, payload, errs); err != nil {
+		return fmt.Errorf("failed to store rejected reading - %s", err.Error())
+	}
+
+	return nil
+}
+
+// detectAnomaly runs the detectors of the rule matching the entry and keeps
+// the ones that triggered on the entry. Rules are read on every call, so rule
+// changes take effect without a restart. A failing detector does not stop the
+// others, the entry keeps the detections that succeeded and the failures are
+// returned.
+func (s *PollutionService) detectAnomaly(ctx context.Context, entry *Pollution) error {
+	return s.detectAnomalyIn(ctx, s.repo, entry)
+}
+
+// detectAnomalyIn is detectAnomaly with the detectors reading the history of
+// the entry from history
+func (s *PollutionService) detectAnomalyIn(ctx context.Context, history PollutionRepo, entry *Pollution) error {
+	rule, found, err := s.repo.GetMatchingAnomalyRule(ctx, entry.Pollutant, entry.Latitude, entry.Longitude)
+	if err != nil {
+		return fmt.Errorf("failed to get anomaly rule - %s", err.Error())
+	}
+	if !found {
+		rule = DefaultAnomalyRule(entry.Pollutant)
+	}
+
+	var errs []error
+	entry.Anomalies = nil
+	for _, detector := range rule.BuildDetectors(history) {
+		detection, err := detector.Detect(ctx, *entry)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s detector failed - %s", detector.Name(), err.Error()))
+			continue
+		}
+
+		if detection.Triggered {
+			entry.Anomalies = append(entry.Anomalies, detection)
+		}
+	}
+
+	entry.IsAnomaly = len(entry.Anomalies) > 0
+
+	return errors.Join(errs...)
+}
+
+func publishAnomalyNotification(entry Pollution) {
+	var reasons []notification.Reason
+	for _, detection := range entry.Anomalies {
+		reasons = append(reasons, notification.Reason{
+			Detector: detection.Detector,
+			Score:    detection.Score,
+			Severity: detection.Severity(),
+			Reason:   detection.Reason,
+		})
+	}
+
+	notification := notification.Notification{
+		Type:      1,
+		Message:   "Anomaly detected!",
+		Latitude:  entry.Latitude,
+		Longitude: entry.Longitude,
+		Value:     entry.Value,
+		Pollutant: entry.Pollutant,
+		Reasons:   reasons,
+	}
+
+	msg, err := json.Marshal(notification)
+	if err != nil {
+		log.Printf("Failed to marshal anomaly notification - %s", err.Error())
+		return
+	}
+
+	err = rabbitmq.Publish(context.Background(), "", "notification_queue",
+		amqp.Publishing{
+			ContentType: "application/json",
+			Body:        msg,
+		},
+	)
+	if err != nil {
+		log.Printf("Failed to publish anomaly notification - %s", err.Error())
+	}
+}
@@ -0,0 +1,64 @@
+package pollution
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// StationLocation is the part of a registered station needed to accept its
+// readings. Stations are managed by the station package.
+type StationLocation struct {
+	Latitude   float64
+	Longitude  float64
+	Pollutants []string
+	Active     bool
+}
+
+// stationResolver gives readings that reference a station the fixed location
+// of the station. Lookups are cached for the lifetime of the resolver, which
+// is a single request.
+type stationResolver struct {
+	repo  PollutionRepo
+	cache map[string]*StationLocation
+}
+
+func newStationResolver(repo PollutionRepo) *stationResolver {
+	return &stationResolver{repo: repo, cache: make(map[string]*StationLocation)}
+}
+
+// resolve sets the coordinates of the entry from its station. Unknown or
+// inactive stations and pollutants the station does not measure are returned
+// as field errors, the error is only set if the lookup itself failed.
+func (r *stationResolver) resolve(ctx context.Context, entry *Pollution) (ValidationErrors, error) {
+	if entry.StationID == "" {
+		return nil, nil
+	}
+
+	station, cached := r.cache[entry.StationID]
+	if !cached {
+		location, found, err := r.repo.GetStationLocation(ctx, entry.StationID)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			station = &location
+		}
+		r.cache[entry.StationID] = station
+	}
+
+	if station == nil {
+		return ValidationErrors{{"station_id", fmt.Sprintf("unknown station %q", entry.StationID)}}, nil
+	}
+	if !station.Active {
+		return ValidationErrors{{"station_id", fmt.Sprintf("station %q is not active", entry.StationID)}}, nil
+	}
+	if !slices.Contains(station.Pollutants, entry.Pollutant) {
+		return ValidationErrors{{"pollutant", fmt.Sprintf("%q is not measured by station %q", entry.Pollutant, entry.StationID)}}, nil
+	}
+
+	entry.Latitude = station.Latitude
+	entry.Longitude = station.Longitude
+
+	return nil, nil
+}
@@ -0,0 +1,261 @@
+package pollution
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// MaxRawSpan is the longest time range of the endpoints that scan the
+	// raw readings in one query
+	MaxRawSpan = 31 * 24 * time.Hour
+	// MaxRollupSpan is the longest time range of the endpoints that read the
+	// rollups
+	MaxRollupSpan = 5 * 366 * 24 * time.Hour
+	// MaxEstimateSpan is the longest time range the latest samples of an
+	// estimate are looked up in
+	MaxEstimateSpan = 7 * 24 * time.Hour
+)
+
+// localLayouts are the layouts without a zone, they are read in the location
+// of the tz parameter
+var localLayouts = []string{
+	TimeFormat,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// zonedLayouts carry their own offset, the location only formats them
+var zonedLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05Z07:00",
+}
+
+// epochMillisFrom is the smallest epoch read as milliseconds, as seconds it
+// would be in the year 5138
+const epochMillisFrom = 100_000_000_000
+
+// timeFormatsHelp lists the accepted formats in the error messages
+const timeFormatsHelp = "expected RFC 3339, 2006-01-02 15:04:05, epoch seconds or milliseconds, or now-24h"
+
+// ParseTime parses a time parameter. It accepts RFC 3339, the layouts without
+// a zone read in loc, Unix epochs in seconds or milliseconds and times relative
+// to now like now, now-24h or now+1h30m, where d and w are days and weeks.
+func ParseTime(str string, loc *time.Location, now time.Time) (time.Time, error) {
+	str = strings.TrimSpace(str)
+	if str == "" {
+		return time.Time{}, fmt.Errorf("empty time")
+	}
+
+	if rest, ok := strings.CutPrefix(str, "now"); ok {
+		t, err := parseRelative(rest, now)
+		return t.In(loc), err
+	}
+
+	if epoch, err := strconv.ParseInt(str, 10, 64); err == nil {
+		if epoch >= epochMillisFrom || epoch <= -epochMillisFrom {
+			return time.UnixMilli(epoch).In(loc), nil
+		}
+		return time.Unix(epoch, 0).In(loc), nil
+	}
+
+	// A + in the query string is decoded as a space, which breaks the offset.
+	// The local layouts get the original, "2006-01-02 15:04" looks the same.
+	zoned := restoreOffsetSign(str)
+	for _, layout := range zonedLayouts {
+		if t, err := time.Parse(layout, zoned); err == nil {
+			return t.In(loc), nil
+		}
+	}
+
+	for _, layout := range localLayouts {
+		if t, err := time.ParseInLocation(layout, str, loc); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("incorrect time %q", str)
+}
+
+// parseRelative parses what follows now in a relative time, nothing or a
+// sign and a duration
+func parseRelative(str string, now time.Time) (time.Time, error) {
+	if str == "" {
+		return now, nil
+	}
+
+	sign := time.Duration(1)
+	switch str[0] {
+	case '-':
+		sign = -1
+	case '+', ' ':
+		// A + in the query string is decoded as a space
+	default:
+		return time.Time{}, fmt.Errorf("incorrect relative time %q", "now"+str)
+	}
+
+	str = str[1:]
+	if str == "" {
+		return time.Time{}, fmt.Errorf("relative time has no duration")
+	}
+
+	d, err := parseDuration(str)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return now.Add(sign * d), nil
+}
+
+// parseDuration is time.ParseDuration with leading days and weeks, 1w2d12h
+func parseDuration(str string) (time.Duration, error) {
+	var total time.Duration
+	for _, unit := range []struct {
+		suffix string
+		size   time.Duration
+	}{{"w", 7 * 24 * time.Hour}, {"d", 24 * time.Hour}} {
+		n, rest, ok := strings.Cut(str, unit.suffix)
+		if !ok {
+			continue
+		}
+		count, err := strconv.Atoi(n)
+		if err != nil || count < 0 {
+			return 0, fmt.Errorf("incorrect duration %q", str)
+		}
+		total += time.Duration(count) * unit.size
+		str = rest
+	}
+
+	if str == "" {
+		return total, nil
+	}
+
+	d, err := time.ParseDuration(str)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("incorrect duration %q", str)
+	}
+
+	return total + d, nil
+}
+
+// restoreOffsetSign turns a trailing " hh:mm" offset back into "+hh:mm"
+func restoreOffsetSign(str string) string {
+	if len(str) < 7 || str[len(str)-6] != ' ' || str[len(str)-3] != ':' {
+		return str
+	}
+	if _, err := time.Parse("15:04", str[len(str)-5:]); err != nil {
+		return str
+	}
+
+	return str[:len(str)-6] + "+" + str[len(str)-5:]
+}
+
+// ParseTimeZone parses the tz parameter, an IANA zone name like
+// Europe/Istanbul. An empty one is UTC.
+func ParseTimeZone(str string) (*time.Location, string) {
+	if str == "" {
+		return time.UTC, ""
+	}
+
+	loc, err := time.LoadLocation(str)
+	if err != nil {
+		return nil, "Incorrect tz, expected an IANA time zone like Europe/Istanbul"
+	}
+
+	return loc, ""
+}
+
+// ParseTimeRange parses the from and to parameters in loc, see ParseTime for
+// the formats. Relative times are relative to the same now. The message is set
+// if a time is incorrect, from is not before to or the range is longer than
+// maxSpan, a zero maxSpan does not limit the range.
+func ParseTimeRange(fromStr, toStr string, loc *time.Location, maxSpan time.Duration, from, to *time.Time) (bool, string) {
+	now := time.Now()
+
+	var err error
+	*from, err = ParseTime(fromStr, loc, now)
+	if err != nil {
+		return false, "Incorrect from, " + timeFormatsHelp
+	}
+
+	*to, err = ParseTime(toStr, loc, now)
+	if err != nil {
+		return false, "Incorrect to, " + timeFormatsHelp
+	}
+
+	if !from.Before(*to) {
+		return false, "from must be before to"
+	}
+
+	if maxSpan > 0 && to.Sub(*from) > maxSpan {
+		return false, "Time range is too long, at most " + formatSpan(maxSpan) + " is allowed"
+	}
+
+	return true, ""
+}
+
+// checkRawSpan returns a message if the range is read from the raw readings
+// but longer than MaxRawSpan. Endpoints that usually read a rollup allow
+// longer ranges, which would scan years of readings without one.
+func checkRawSpan(from, to time.Time, step time.Duration, agg string, extent float64) string {
+	if to.Sub(from) <= MaxRawSpan || UsesRollup(from, to, step, agg, extent) {
+		return ""
+	}
+
+	return "Time range is too long, at most " + formatSpan(MaxRawSpan) +
+		" is allowed for percentiles, steps that are not whole minutes and small areas"
+}
+
+// formatSpan formats a duration in days if it is a whole number of them
+func formatSpan(d time.Duration) string {
+	day := 24 * time.Hour
+	if d >= day && d%day == 0 {
+		return strconv.Itoa(int(d/day)) + " days"
+	}
+	return d.String()
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// InLocation sets the location of the times inside v so they are formatted in
+// the tz of the request. v is a pointer or a slice, the exported fields of
+// structs and the elements of slices and maps are walked.
+func InLocation(v any, loc *time.Location) {
+	inLocation(reflect.ValueOf(v), loc)
+}
+
+func inLocation(v reflect.Value, loc *time.Location) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			inLocation(v.Elem(), loc)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			inLocation(v.Index(i), loc)
+		}
+	case reflect.Map:
+		// Map values are not addressable, only pointers in them are updated
+		iter := v.MapRange()
+		for iter.Next() {
+			inLocation(iter.Value(), loc)
+		}
+	case reflect.Struct:
+		if v.Type() == timeType {
+			if v.CanSet() {
+				v.Set(reflect.ValueOf(v.Interface().(time.Time).In(loc)))
+			}
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				inLocation(v.Field(i), loc)
+			}
+		}
+	}
+}
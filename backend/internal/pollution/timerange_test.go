@@ -0,0 +1,98 @@
+package pollution
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTime(t *testing.T) {
+	istanbul := time.FixedZone("Europe/Istanbul", 3*60*60)
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		str     string
+		loc     *time.Location
+		want    time.Time
+		wantErr bool
+	}{
+		{"RFC 3339", "2024-03-01T10:00:00Z", time.UTC, time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC), false},
+		{"RFC 3339 with an offset", "2024-03-01T10:00:00+03:00", time.UTC, time.Date(2024, 3, 1, 7, 0, 0, 0, time.UTC), false},
+		{"offset sign decoded as a space", "2024-03-01T10:00:00 03:00", time.UTC, time.Date(2024, 3, 1, 7, 0, 0, 0, time.UTC), false},
+		{"zoned with a space", "2024-03-01 10:00:00Z", time.UTC, time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC), false},
+		{"local with seconds", "2024-03-01 10:00:00", istanbul, time.Date(2024, 3, 1, 10, 0, 0, 0, istanbul), false},
+		{"local with T", "2024-03-01T10:00:00", istanbul, time.Date(2024, 3, 1, 10, 0, 0, 0, istanbul), false},
+		{"local without seconds", "2024-03-01 10:00", istanbul, time.Date(2024, 3, 1, 10, 0, 0, 0, istanbul), false},
+		{"local with T without seconds", "2024-03-01T10:00", istanbul, time.Date(2024, 3, 1, 10, 0, 0, 0, istanbul), false},
+		{"date", "2024-03-01", istanbul, time.Date(2024, 3, 1, 0, 0, 0, 0, istanbul), false},
+		{"epoch seconds", "1709287200", time.UTC, time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC), false},
+		{"epoch milliseconds", "1709287200000", time.UTC, time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC), false},
+		{"negative epoch seconds", "-86400", time.UTC, time.Date(1969, 12, 31, 0, 0, 0, 0, time.UTC), false},
+		{"now", "now", time.UTC, now, false},
+		{"now minus hours", "now-24h", time.UTC, now.Add(-24 * time.Hour), false},
+		{"now plus minutes", "now+1h30m", time.UTC, now.Add(90 * time.Minute), false},
+		{"now plus decoded as a space", "now 1h", time.UTC, now.Add(time.Hour), false},
+		{"now minus days", "now-7d", time.UTC, now.Add(-7 * 24 * time.Hour), false},
+		{"now minus weeks, days and hours", "now-1w2d12h", time.UTC, now.Add(-(9*24 + 12) * time.Hour), false},
+		{"surrounding spaces", "  now-1h  ", time.UTC, now.Add(-time.Hour), false},
+		{"empty", "", time.UTC, time.Time{}, true},
+		{"unknown layout", "01/03/2024", time.UTC, time.Time{}, true},
+		{"now without a duration", "now-", time.UTC, time.Time{}, true},
+		{"now with a bad sign", "now*1h", time.UTC, time.Time{}, true},
+		{"now with a bad duration", "now-1x", time.UTC, time.Time{}, true},
+		{"now with negative days", "now--1d", time.UTC, time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTime(tt.str, tt.loc, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTime(%q) error = %v, wantErr %v", tt.str, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseTime(%q) = %v, want %v", tt.str, got, tt.want)
+			}
+			if got.Location() != tt.loc {
+				t.Errorf("ParseTime(%q) location = %v, want %v", tt.str, got.Location(), tt.loc)
+			}
+		})
+	}
+}
+
+func TestParseTimeRange(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		maxSpan  time.Duration
+		wantMsg  string
+	}{
+		{"relative", "now-24h", "now", MaxRawSpan, ""},
+		{"absolute", "2024-03-01", "2024-03-02", MaxRawSpan, ""},
+		{"exactly the max span", "2024-03-01", "2024-04-01", MaxRawSpan, ""},
+		{"longer than the max span", "2024-03-01", "2024-04-01T00:00:01", MaxRawSpan, "Time range is too long, at most 31 days is allowed"},
+		{"weeks over the max span", "now-5w", "now", MaxRawSpan, "Time range is too long"},
+		{"no max span", "2000-01-01", "2024-01-01", 0, ""},
+		{"rollup span", "now-6w", "now", MaxRollupSpan, ""},
+		{"from equals to", "2024-03-01", "2024-03-01", MaxRawSpan, "from must be before to"},
+		{"from after to", "now", "now-1h", MaxRawSpan, "from must be before to"},
+		{"incorrect from", "yesterday", "now", MaxRawSpan, "Incorrect from"},
+		{"incorrect to", "now-1h", "tomorrow", MaxRawSpan, "Incorrect to"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var from, to time.Time
+			ok, msg := ParseTimeRange(tt.from, tt.to, time.UTC, tt.maxSpan, &from, &to)
+			if ok != (tt.wantMsg == "") {
+				t.Fatalf("ParseTimeRange() = %v, %q", ok, msg)
+			}
+			if !strings.HasPrefix(msg, tt.wantMsg) {
+				t.Errorf("ParseTimeRange() message = %q, want %q", msg, tt.wantMsg)
+			}
+		})
+	}
+}
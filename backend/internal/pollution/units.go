@@ -0,0 +1,224 @@
+package pollution
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	UnitMicrogramsPerCubicMeter = "µg/m³"
+	UnitMilligramsPerCubicMeter = "mg/m³"
+	UnitPartsPerBillion         = "ppb"
+	UnitPartsPerMillion         = "ppm"
+)
+
+// Units lists the supported units in their canonical spelling
+var Units = []string{UnitMicrogramsPerCubicMeter, UnitMilligramsPerCubicMeter, UnitPartsPerBillion, UnitPartsPerMillion}
+
+// unitAliases maps the spellings sensors commonly use to the canonical ones
+var unitAliases = map[string]string{
+	"µg/m³": UnitMicrogramsPerCubicMeter,
+	"µg/m3": UnitMicrogramsPerCubicMeter,
+	"μg/m³": UnitMicrogramsPerCubicMeter, // greek mu
+	"μg/m3": UnitMicrogramsPerCubicMeter,
+	"ug/m³": UnitMicrogramsPerCubicMeter,
+	"ug/m3": UnitMicrogramsPerCubicMeter,
+	"mg/m³": UnitMilligramsPerCubicMeter,
+	"mg/m3": UnitMilligramsPerCubicMeter,
+	"ppb":   UnitPartsPerBillion,
+	"ppm":   UnitPartsPerMillion,
+}
+
+// ParseUnit returns the canonical spelling of a unit, false if it is not supported
+func ParseUnit(unit string) (string, bool) {
+	canonical, ok := unitAliases[strings.ToLower(strings.TrimSpace(unit))]
+	return canonical, ok
+}
+
+// Conditions are the air temperature and pressure a gas concentration was
+// measured at, they decide the volume of a mole of gas for ppb <-> µg/m³.
+type Conditions struct {
+	TemperatureC float64
+	PressureHPa  float64
+}
+
+// StandardConditions are 25 °C and 1 atm, the reference of the US EPA and
+// the conditions used when a reading does not report its own.
+var StandardConditions = Conditions{TemperatureC: 25, PressureHPa: 1013.25}
+
+// molarVolume returns the volume in litres of one mole of an ideal gas
+func (c Conditions) molarVolume() float64 {
+	const gasConstant = 8.314462618 // J/(mol·K)
+	return gasConstant * (c.TemperatureC + 273.15) / (c.PressureHPa * 100) * 1000
+}
+
+// molecularWeights in g/mol of the gases that can be converted to and from
+// mixing ratios. Particulate matter has no molecular weight, so it can only be
+// reported in mass concentrations.
+var molecularWeights = map[string]float64{
+	"NO2": 46.0055,
+	"SO2": 64.066,
+	"O3":  47.9982,
+	"CO":  28.0101,
+}
+
+// ConvertUnit converts a concentration of the pollutant between two canonical
+// units. Mixing ratios are converted to mass concentrations with the ideal gas
+// law at the given conditions.
+func ConvertUnit(pollutant string, value float64, from, to string, cond Conditions) (float64, error) {
+	if from == to {
+		return value, nil
+	}
+
+	ugm3, err := toMicrograms(pollutant, value, from, cond)
+	if err != nil {
+		return 0, err
+	}
+
+	return fromMicrograms(pollutant, ugm3, to, cond)
+}
+
+func toMicrograms(pollutant string, value float64, unit string, cond Conditions) (float64, error) {
+	switch unit {
+	case UnitMicrogramsPerCubicMeter:
+		return value, nil
+	case UnitMilligramsPerCubicMeter:
+		return value * 1000, nil
+	}
+
+	mw, ok := molecularWeights[pollutant]
+	if !ok {
+		return 0, fmt.Errorf("%s can not be converted from %s", pollutant, unit)
+	}
+
+	switch unit {
+	case UnitPartsPerBillion:
+		return value * mw / cond.molarVolume(), nil
+	case UnitPartsPerMillion:
+		return value * 1000 * mw / cond.molarVolume(), nil
+	}
+
+	return 0, fmt.Errorf("unknown unit %q", unit)
+}
+
+func fromMicrograms(pollutant string, ugm3 float64, unit string, cond Conditions) (float64, error) {
+	switch unit {
+	case UnitMicrogramsPerCubicMeter:
+		return ugm3, nil
+	case UnitMilligramsPerCubicMeter:
+		return ugm3 / 1000, nil
+	}
+
+	mw, ok := molecularWeights[pollutant]
+	if !ok {
+		return 0, fmt.Errorf("%s can not be converted to %s", pollutant, unit)
+	}
+
+	switch unit {
+	case UnitPartsPerBillion:
+		return ugm3 * cond.molarVolume() / mw, nil
+	case UnitPartsPerMillion:
+		return ugm3 * cond.molarVolume() / mw / 1000, nil
+	}
+
+	return 0, fmt.Errorf("unknown unit %q", unit)
+}
+
+// CanonicalUnit returns the unit values of the pollutant are stored in
+func CanonicalUnit(pollutant string) string {
+	return KnownPollutants[pollutant].Unit
+}
+
+// FromCanonical converts a stored value of the pollutant into the given unit
+// at standard conditions. An empty unit leaves the value as it is.
+func FromCanonical(pollutant string, value float64, unit string) (float64, error) {
+	if unit == "" {
+		return value, nil
+	}
+	return ConvertUnit(pollutant, value, CanonicalUnit(pollutant), unit, StandardConditions)
+}
+
+// Normalize converts the value of the reading into the canonical unit of its
+// pollutant, using the temperature and pressure of the reading if it reports
+// them. A reading without a unit is taken to be in the canonical unit already.
+func (v *Validator) Normalize(entry *Pollution) ValidationErrors {
+	spec, known := v.Pollutants[entry.Pollutant]
+	if entry.Unit == "" || !known {
+		// Unknown pollutants are reported by Validate
+		if known {
+			entry.Unit = spec.Unit
+		}
+		return nil
+	}
+
+	unit, ok := ParseUnit(entry.Unit)
+	if !ok {
+		return ValidationErrors{{"unit", fmt.Sprintf("unknown unit %q, expected one of %s", entry.Unit, strings.Join(Units, ", "))}}
+	}
+
+	cond := StandardConditions
+	if entry.Temperature != nil {
+		cond.TemperatureC = *entry.Temperature
+	}
+	if entry.Pressure != nil {
+		cond.PressureHPa = *entry.Pressure
+	}
+
+	var errs ValidationErrors
+	if cond.TemperatureC <= -273.15 {
+		errs = append(errs, FieldError{"temperature", "must be above absolute zero"})
+	}
+	if cond.PressureHPa <= 0 {
+		errs = append(errs, FieldError{"pressure", "must be positive"})
+	}
+	if errs != nil {
+		return errs
+	}
+
+	value, err := ConvertUnit(entry.Pollutant, entry.Value, unit, spec.Unit, cond)
+	if err != nil {
+		return ValidationErrors{{"unit", err.Error()}}
+	}
+
+	entry.Value = value
+	entry.Unit = spec.Unit
+
+	return nil
+}
+
+// ParseUnitParam reads the unit query parameter of the read endpoints. When
+// the request is about a single pollutant the unit must be one it can be
+// converted to, otherwise rows that can not be converted keep their unit.
+func ParseUnitParam(str, pollutant string) (string, string) {
+	if str == "" {
+		return "", ""
+	}
+
+	unit, ok := ParseUnit(str)
+	if !ok {
+		return "", fmt.Sprintf("Incorrect unit, expected one of %s", strings.Join(Units, ", "))
+	}
+
+	if pollutant != "" {
+		if _, known := KnownPollutants[pollutant]; known {
+			if _, err := FromCanonical(pollutant, 0, unit); err != nil {
+				return "", err.Error()
+			}
+		}
+	}
+
+	return unit, ""
+}
+
+// ConvertValue converts a stored value into the requested unit, falling back
+// to the canonical unit if the pollutant can not be expressed in it. It returns
+// the value and the unit it is in.
+func ConvertValue(pollutant string, value float64, unit string) (float64, string) {
+	if unit != "" {
+		if converted, err := FromCanonical(pollutant, value, unit); err == nil {
+			return converted, unit
+		}
+	}
+
+	return value, CanonicalUnit(pollutant)
+}
@@ -0,0 +1,69 @@
+package pollution
+
+import (
+	"math"
+	"sort"
+	"testing"
+)
+
+func TestConvertValue(t *testing.T) {
+	tests := []struct {
+		name      string
+		pollutant string
+		value     float64
+		unit      string
+		want      float64
+		wantUnit  string
+	}{
+		{"no unit", "NO2", 40, "", 40, UnitMicrogramsPerCubicMeter},
+		{"canonical unit", "PM10", 40, UnitMicrogramsPerCubicMeter, 40, UnitMicrogramsPerCubicMeter},
+		{"mass to mass", "PM2.5", 1500, UnitMilligramsPerCubicMeter, 1.5, UnitMilligramsPerCubicMeter},
+		{"NO2 to ppb", "NO2", 46.0055, UnitPartsPerBillion, 24.4654, UnitPartsPerBillion},
+		{"CO to ppm", "CO", 1.14567, UnitPartsPerMillion, 1, UnitPartsPerMillion},
+		{"particulate matter keeps its unit", "PM2.5", 35, UnitPartsPerBillion, 35, UnitMicrogramsPerCubicMeter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, unit := ConvertValue(tt.pollutant, tt.value, tt.unit)
+			if unit != tt.wantUnit || math.Abs(got-tt.want) > 1e-3 {
+				t.Errorf("ConvertValue() = %v %s, want %v %s", got, unit, tt.want, tt.wantUnit)
+			}
+		})
+	}
+}
+
+func TestConvertValueRoundTrip(t *testing.T) {
+	var pollutants []string
+	for pollutant := range KnownPollutants {
+		pollutants = append(pollutants, pollutant)
+	}
+	sort.Strings(pollutants)
+
+	for _, pollutant := range pollutants {
+		for _, unit := range Units {
+			t.Run(pollutant+" "+unit, func(t *testing.T) {
+				const value = 123.456
+				converted, got := ConvertValue(pollutant, value, unit)
+
+				back, err := ConvertUnit(pollutant, converted, got, CanonicalUnit(pollutant), StandardConditions)
+				if err != nil {
+					t.Fatalf("ConvertUnit() error = %v", err)
+				}
+				if math.Abs(back-value) > 1e-9*value {
+					t.Errorf("%v -> %v %s -> %v, want %v back", value, converted, got, back, value)
+				}
+
+				// Only gases can be expressed in mixing ratios
+				wantUnit := unit
+				_, gas := molecularWeights[pollutant]
+				if !gas && (unit == UnitPartsPerBillion || unit == UnitPartsPerMillion) {
+					wantUnit = CanonicalUnit(pollutant)
+				}
+				if got != wantUnit {
+					t.Errorf("unit = %s, want %s", got, wantUnit)
+				}
+			})
+		}
+	}
+}
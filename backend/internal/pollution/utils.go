@@ -1,26 +1,19 @@
 package pollution
 
 import (
+	"slices"
 	"strconv"
-	"time"
+	"strings"
 )
 
 var TimeFormat string = "2006-01-02 15:04:05"
 
-func ParseTimeRange(fromStr, toStr string, from, to *time.Time) (bool, string) {
-	var err error
-	*from, err = time.Parse(TimeFormat, fromStr)
-	if err != nil {
-		return false, "Incorrect time format!"
-	}
-
-	*to, err = time.Parse(TimeFormat, toStr)
-	if err != nil {
-		return false, "Incorrect time format!"
-	}
-
-	return true, ""
-}
+const (
+	// MaxBatchSize is the maximum number of entries accepted in one batch request
+	MaxBatchSize = 5000
+	// batchChunkSize is the number of entries published in a single queue message
+	batchChunkSize = 500
+)
 
 func ParseLatLon(latStr, lonStr string, lat, lon *float64) (bool, string) {
 	var err error
@@ -36,3 +29,21 @@ func ParseLatLon(latStr, lonStr string, lat, lon *float64) (bool, string) {
 
 	return true, ""
 }
+
+// ParseList splits a comma separated query parameter and checks every item
+// against the allowed values. An empty string gives an empty list.
+func ParseList(str string, allowed []string) ([]string, bool) {
+	if str == "" {
+		return nil, true
+	}
+
+	items := strings.Split(str, ",")
+	for i, item := range items {
+		items[i] = strings.TrimSpace(item)
+		if !slices.Contains(allowed, items[i]) {
+			return nil, false
+		}
+	}
+
+	return items, true
+}
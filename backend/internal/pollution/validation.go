@@ -0,0 +1,110 @@
+package pollution
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PollutantSpec describes a known pollutant, the unit its values are expected
+// in and the range of values that is physically plausible for a sensor reading.
+type PollutantSpec struct {
+	Name string  `json:"name"`
+	Unit string  `json:"unit"`
+	Min  float64 `json:"min"`
+	Max  float64 `json:"max"`
+}
+
+// KnownPollutants is the registry of pollutants accepted by the system.
+// The ranges are deliberately wide, they only reject values no real sensor
+// could report, not values that are merely unusual (those are anomalies).
+var KnownPollutants = map[string]PollutantSpec{
+	"PM2.5": {Name: "PM2.5", Unit: "µg/m³", Min: 0, Max: 1000},
+	"PM10":  {Name: "PM10", Unit: "µg/m³", Min: 0, Max: 2000},
+	"NO2":   {Name: "NO2", Unit: "µg/m³", Min: 0, Max: 4000},
+	"SO2":   {Name: "SO2", Unit: "µg/m³", Min: 0, Max: 8000},
+	"O3":    {Name: "O3", Unit: "µg/m³", Min: 0, Max: 1500},
+	"CO":    {Name: "CO", Unit: "mg/m³", Min: 0, Max: 200},
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors is the list of field level problems found in a reading.
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Field + ": " + fe.Message
+	}
+	return strings.Join(msgs, "; ")
+}
+
+type Validator struct {
+	Pollutants map[string]PollutantSpec
+	// MaxFutureSkew is how far ahead of the server clock a reading may be
+	MaxFutureSkew time.Duration
+	// MaxAge is how old a reading may be, zero disables the check
+	MaxAge time.Duration
+}
+
+var DefaultValidator = &Validator{
+	Pollutants:    KnownPollutants,
+	MaxFutureSkew: 5 * time.Minute,
+	MaxAge:        7 * 24 * time.Hour,
+}
+
+// Validate checks the reading against coordinate bounds, the pollutant
+// registry and the timestamp skew limits. It returns nil if the reading is valid.
+func (v *Validator) Validate(entry Pollution, now time.Time) ValidationErrors {
+	var errs ValidationErrors
+
+	if entry.Latitude < -90 || entry.Latitude > 90 {
+		errs = append(errs, FieldError{"latitude", "must be between -90 and 90"})
+	}
+
+	if entry.Longitude < -180 || entry.Longitude > 180 {
+		errs = append(errs, FieldError{"longitude", "must be between -180 and 180"})
+	}
+
+	if entry.Pollutant == "" {
+		errs = append(errs, FieldError{"pollutant", "is required"})
+	} else if spec, ok := v.Pollutants[entry.Pollutant]; !ok {
+		errs = append(errs, FieldError{"pollutant", fmt.Sprintf("unknown pollutant %q", entry.Pollutant)})
+	} else if entry.Value < spec.Min || entry.Value > spec.Max {
+		errs = append(errs, FieldError{"value", fmt.Sprintf("must be between %g and %g %s", spec.Min, spec.Max, spec.Unit)})
+	}
+
+	if entry.Time.IsZero() {
+		errs = append(errs, FieldError{"time", "is required"})
+	} else if entry.Time.After(now.Add(v.MaxFutureSkew)) {
+		errs = append(errs, FieldError{"time", fmt.Sprintf("must not be more than %s in the future", v.MaxFutureSkew)})
+	} else if v.MaxAge > 0 && entry.Time.Before(now.Add(-v.MaxAge)) {
+		errs = append(errs, FieldError{"time", fmt.Sprintf("must not be older than %s", v.MaxAge)})
+	}
+
+	return errs
+}
+
+// Check normalizes the unit of the reading and validates it. Range errors of
+// a value whose unit could not be converted are left out, they would be
+// misleading.
+func (v *Validator) Check(entry *Pollution, now time.Time) ValidationErrors {
+	unitErrs := v.Normalize(entry)
+
+	errs := v.Validate(*entry, now)
+	if unitErrs == nil {
+		return errs
+	}
+
+	for _, e := range errs {
+		if e.Field != "value" {
+			unitErrs = append(unitErrs, e)
+		}
+	}
+
+	return unitErrs
+}
@@ -0,0 +1,167 @@
+package rabbitmq
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// openChannel opens a confirm-mode channel on the current connection
+func openChannel(ctx context.Context) (*amqp.Channel, error) {
+	conn, err := connection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open a channel: %s", err.Error())
+	}
+
+	if err = ch.Confirm(false); err != nil {
+		ch.Close()
+		return nil, fmt.Errorf("Failed to put the channel into confirm mode: %s", err.Error())
+	}
+
+	return ch, nil
+}
+
+func acquirePublisher(ctx context.Context) (*amqp.Channel, error) {
+	for {
+		select {
+		case ch := <-manager.publishers:
+			if !ch.IsClosed() {
+				return ch, nil
+			}
+		default:
+			return openChannel(ctx)
+		}
+	}
+}
+
+func releasePublisher(ch *amqp.Channel) {
+	if ch.IsClosed() {
+		return
+	}
+
+	select {
+	case manager.publishers <- ch:
+	default:
+		// Pool is full
+		ch.Close()
+	}
+}
+
+// Publish sends a persistent message on a pooled channel and waits until the
+// broker confirms it. It fails if the broker is unreachable, nacks the message
+// or does not answer within the confirm timeout.
+func Publish(ctx context.Context, exchange, key string, msg amqp.Publishing) error {
+	if manager.cfg == nil {
+		return ErrNotConnected
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, manager.cfg.PublishConfirmTimeout)
+	defer cancel()
+
+	ch, err := acquirePublisher(ctx)
+	if err != nil {
+		return err
+	}
+	defer releasePublisher(ch)
+
+	return PublishOn(ctx, ch, exchange, key, msg)
+}
+
+// PublishOn is Publish for a channel obtained from WithChannel
+func PublishOn(ctx context.Context, ch *amqp.Channel, exchange, key string, msg amqp.Publishing) error {
+	ctx, cancel := context.WithTimeout(ctx, manager.cfg.PublishConfirmTimeout)
+	defer cancel()
+
+	msg.DeliveryMode = amqp.Persistent
+	confirmation, err := ch.PublishWithDeferredConfirmWithContext(ctx, exchange, key, false, false, msg)
+	if err != nil {
+		return err
+	}
+
+	ok, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return ErrPublishTimeout
+	}
+	if !ok {
+		return ErrPublishNacked
+	}
+
+	return nil
+}
+
+// WithChannel runs fn on a dedicated confirm-mode channel which is closed
+// afterwards, any message fetched but not acked by fn is requeued.
+func WithChannel(ctx context.Context, fn func(ch *amqp.Channel) error) error {
+	ch, err := openChannel(ctx)
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+
+	return fn(ch)
+}
+
+// Consume registers handler for the given queue. The consumer gets its own
+// channel and is re-registered whenever the channel or the connection drops.
+// Deliveries have to be acknowledged by the handler.
+func Consume(queue string, prefetch int, handler func(d amqp.Delivery)) {
+	go func() {
+		delay := minReconnectDelay
+		for {
+			deliveries, ch, err := subscribe(queue, prefetch)
+			if err == ErrNotConnected {
+				return
+			}
+			if err != nil {
+				log.Printf("Failed to register a consumer for %s, retrying in %s - %s", queue, delay, err.Error())
+				time.Sleep(delay)
+				delay = min(delay*2, maxReconnectDelay)
+				continue
+			}
+			delay = minReconnectDelay
+
+			for d := range deliveries {
+				handler(d)
+			}
+			ch.Close()
+
+			log.Printf("Consumer channel for %s closed, re-registering", queue)
+		}
+	}()
+}
+
+func subscribe(queue string, prefetch int) (<-chan amqp.Delivery, *amqp.Channel, error) {
+	ch, err := openChannel(context.Background())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err = ch.Qos(prefetch, 0, false); err != nil {
+		ch.Close()
+		return nil, nil, fmt.Errorf("Failed to set QoS: %s", err.Error())
+	}
+
+	deliveries, err := ch.Consume(
+		queue, // queue
+		"",    // consumer
+		false, // auto-ack
+		false, // exclusive
+		false, // no-local
+		false, // no-wait
+		nil,   // args
+	)
+	if err != nil {
+		ch.Close()
+		return nil, nil, err
+	}
+
+	return deliveries, ch, nil
+}
@@ -1,45 +1,328 @@
 package rabbitmq
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"sync"
+	"time"
 
 	"github.com/AkifSahn/pollution-tracker/config"
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
-var AmqpConn *amqp.Connection
-var AmqpCh *amqp.Channel
+const (
+	// DeadLetterExchange receives ingest messages that could not be processed
+	DeadLetterExchange = "ingest_dlx"
+	// DeadLetterQueue holds the poison messages routed through DeadLetterExchange
+	DeadLetterQueue = "ingest_dead_letter"
 
-func Connect(cfg *config.Config) *amqp.Channel {
-	if AmqpCh == nil {
-		connStr := fmt.Sprintf("amqp://%s:%s@%s:%s/", cfg.AmqpUser, cfg.AmqpPassword, cfg.AmqpHost, cfg.AmqpPort)
-		conn, err := amqp.Dial(connStr)
+	// Headers set on ingest messages that failed to be processed
+	RetryCountHeader = "x-retry-count"
+	LastErrorHeader  = "x-last-error"
+	FailedAtHeader   = "x-failed-at"
+)
+
+const (
+	minReconnectDelay = 1 * time.Second
+	maxReconnectDelay = 30 * time.Second
+)
+
+var (
+	ErrPublishNacked  = errors.New("broker rejected the message")
+	ErrPublishTimeout = errors.New("timed out waiting for the broker to confirm the message")
+	ErrNotConnected   = errors.New("not connected to RabbitMQ")
+)
+
+// manager owns the single AMQP connection. It reconnects when the connection
+// drops, re-declares the topology and hands out channels; nothing outside of
+// this package touches the connection directly.
+var manager struct {
+	mu      sync.Mutex
+	cfg     *config.Config
+	conn    *amqp.Connection
+	ready   chan struct{} // closed while conn is usable
+	closing bool
+
+	// publishers is a pool of confirm-mode channels used by Publish
+	publishers chan *amqp.Channel
+}
+
+// Connect dials RabbitMQ, declares the queues and starts watching the
+// connection. It fails hard if the broker can not be reached on startup.
+func Connect(cfg *config.Config) {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+
+	if manager.cfg != nil {
+		return
+	}
+
+	manager.cfg = cfg
+	manager.ready = make(chan struct{})
+	manager.publishers = make(chan *amqp.Channel, cfg.AmqpChannelPoolSize)
+
+	conn, err := dial(cfg)
+	if err != nil {
+		log.Fatalf("Failed to connect RabbitMQ: %s", err.Error())
+	}
+
+	manager.conn = conn
+	close(manager.ready)
+
+	go watch(conn)
+}
+
+// Close closes the connection and stops reconnecting
+func Close() {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+
+	manager.closing = true
+	if manager.conn != nil {
+		manager.conn.Close()
+	}
+}
+
+func dial(cfg *config.Config) (*amqp.Connection, error) {
+	connStr := fmt.Sprintf("amqp://%s:%s@%s:%s/", cfg.AmqpUser, cfg.AmqpPassword, cfg.AmqpHost, cfg.AmqpPort)
+	conn, err := amqp.Dial(connStr)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = declareQueues(conn, cfg); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// watch waits for the connection to close and reconnects with an exponential backoff
+func watch(conn *amqp.Connection) {
+	for {
+		reason, ok := <-conn.NotifyClose(make(chan *amqp.Error, 1))
+
+		manager.mu.Lock()
+		if manager.closing {
+			manager.mu.Unlock()
+			return
+		}
+		manager.ready = make(chan struct{})
+		manager.mu.Unlock()
+
+		if ok && reason != nil {
+			log.Printf("RabbitMQ connection closed, reconnecting - %s", reason.Error())
+		} else {
+			log.Printf("RabbitMQ connection closed, reconnecting")
+		}
+
+		conn = reconnect()
+		if conn == nil {
+			return
+		}
+	}
+}
+
+func reconnect() *amqp.Connection {
+	delay := minReconnectDelay
+	for {
+		time.Sleep(delay)
+
+		manager.mu.Lock()
+		if manager.closing {
+			manager.mu.Unlock()
+			return nil
+		}
+		cfg := manager.cfg
+		manager.mu.Unlock()
+
+		conn, err := dial(cfg)
 		if err != nil {
-			log.Fatalf("Failed to connect RabbitMQ: %s", err.Error())
+			log.Printf("Failed to reconnect RabbitMQ, retrying in %s - %s", delay, err.Error())
+			delay = min(delay*2, maxReconnectDelay)
+			continue
 		}
 
-		ch, err := conn.Channel()
+		manager.mu.Lock()
+		manager.conn = conn
+		drainPublishers()
+		close(manager.ready)
+		manager.mu.Unlock()
+
+		log.Printf("Reconnected to RabbitMQ")
+		return conn
+	}
+}
+
+// drainPublishers drops the pooled channels of a dead connection
+func drainPublishers() {
+	for {
+		select {
+		case ch := <-manager.publishers:
+			ch.Close()
+		default:
+			return
+		}
+	}
+}
+
+// connection returns the current connection, waiting for a reconnect if
+// needed until the context is done.
+func connection(ctx context.Context) (*amqp.Connection, error) {
+	for {
+		manager.mu.Lock()
+		ready := manager.ready
+		conn := manager.conn
+		closing := manager.closing
+		manager.mu.Unlock()
+
+		if closing || ready == nil {
+			return nil, ErrNotConnected
+		}
+
+		select {
+		case <-ready:
+			if !conn.IsClosed() {
+				return conn, nil
+			}
+			// The connection just dropped, wait for watch to notice it
+			select {
+			case <-time.After(100 * time.Millisecond):
+			case <-ctx.Done():
+				return nil, ErrNotConnected
+			}
+		case <-ctx.Done():
+			return nil, ErrNotConnected
+		}
+	}
+}
+
+// RetryQueue returns the name of the delay queue used for the given retry
+// attempt. The name carries the delay, the TTL of a queue can not be changed
+// once it is declared so a new INGEST_RETRY_DELAY gets new queues.
+func RetryQueue(cfg *config.Config, attempt int) string {
+	return fmt.Sprintf("ingest_retry_%s", retryDelay(cfg, attempt))
+}
+
+// retryDelay doubles the retry delay on every attempt
+func retryDelay(cfg *config.Config, attempt int) time.Duration {
+	return cfg.IngestRetryDelay << (attempt - 1)
+}
+
+// withChannel runs fn on a new channel. A failed declaration closes the
+// channel, so every step that may fail gets its own.
+func withChannel(conn *amqp.Connection, fn func(ch *amqp.Channel) error) error {
+	ch, err := conn.Channel()
+	if err != nil {
+		return fmt.Errorf("Failed to open a channel: %s", err.Error())
+	}
+	defer ch.Close()
+
+	return fn(ch)
+}
+
+// declareQueue declares a durable queue. A queue declared by an older version
+// as not durable or with other arguments can not be redeclared, it is replaced
+// if it is empty and has no consumers. Otherwise it has to be drained first, see the upgrade
+// notes in the README.
+func declareQueue(conn *amqp.Connection, name string, args amqp.Table) error {
+	declare := func(ch *amqp.Channel) error {
+		_, err := ch.QueueDeclare(name, true, false, false, false, args)
+		return err
+	}
+
+	err := withChannel(conn, declare)
+	var amqpErr *amqp.Error
+	if !errors.As(err, &amqpErr) || amqpErr.Code != amqp.PreconditionFailed {
 		if err != nil {
-			log.Fatalf("Failed to open a channel: %s", err.Error())
+			return fmt.Errorf("Failed to declare a queue: %s", err.Error())
 		}
+		return nil
+	}
 
-		AmqpConn = conn
-		AmqpCh = ch
+	err = withChannel(conn, func(ch *amqp.Channel) error {
+		_, err := ch.QueueDelete(name, true, true, false)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("Queue %s exists with other arguments and is not empty or still consumed, drain it before upgrading: %s", name, err.Error())
 	}
+	log.Printf("Replaced queue %s, it was declared with other arguments", name)
 
-	return AmqpCh
+	if err = withChannel(conn, declare); err != nil {
+		return fmt.Errorf("Failed to declare a queue: %s", err.Error())
+	}
+	return nil
 }
 
-func DeclareQueues() {
-	// We need two queues
-	_, err := AmqpCh.QueueDeclare("ingest_queue", false, false, false, false, nil)
+func declareQueues(conn *amqp.Connection, cfg *config.Config) error {
+	// Messages rejected from the ingest queue end up in the dead-letter queue
+	err := withChannel(conn, func(ch *amqp.Channel) error {
+		return ch.ExchangeDeclare(DeadLetterExchange, "direct", true, false, false, false, nil)
+	})
 	if err != nil {
-		log.Fatalf("Failed to declare a queue: %s", err.Error())
+		return fmt.Errorf("Failed to declare an exchange: %s", err.Error())
+	}
+
+	if err = declareQueue(conn, DeadLetterQueue, nil); err != nil {
+		return err
 	}
 
-	_, err = AmqpCh.QueueDeclare("notification_queue", false, false, false, false, nil)
+	err = withChannel(conn, func(ch *amqp.Channel) error {
+		return ch.QueueBind(DeadLetterQueue, "ingest_queue", DeadLetterExchange, false, nil)
+	})
 	if err != nil {
-		log.Fatalf("Failed to declare a queue: %s", err.Error())
+		return fmt.Errorf("Failed to bind a queue: %s", err.Error())
+	}
+
+	err = declareQueue(conn, "ingest_queue", amqp.Table{
+		"x-dead-letter-exchange": DeadLetterExchange,
+	})
+	if err != nil {
+		return err
+	}
+
+	// One delay queue per attempt, messages wait there for their TTL and are
+	// then dead-lettered back into the ingest queue
+	for attempt := 1; attempt <= cfg.IngestMaxRetries; attempt++ {
+		err = declareQueue(conn, RetryQueue(cfg, attempt), amqp.Table{
+			"x-message-ttl":             retryDelay(cfg, attempt).Milliseconds(),
+			"x-dead-letter-exchange":    "",
+			"x-dead-letter-routing-key": "ingest_queue",
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if err = declareQueue(conn, "notification_queue", nil); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RetryCount reads how many times a message has already been retried
+func RetryCount(headers amqp.Table) int {
+	switch v := headers[RetryCountHeader].(type) {
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case int:
+		return v
+	}
+	return 0
+}
+
+// FailureHeaders builds the headers attached to a message that failed to be processed
+func FailureHeaders(retryCount int, cause error) amqp.Table {
+	return amqp.Table{
+		RetryCountHeader: int32(retryCount),
+		LastErrorHeader:  cause.Error(),
+		FailedAtHeader:   time.Now().UTC().Format(time.RFC3339),
 	}
 }
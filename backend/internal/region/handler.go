@@ -0,0 +1,446 @@
+package region
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/AkifSahn/pollution-tracker/internal/database"
+	"github.com/AkifSahn/pollution-tracker/internal/pollution"
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxImportFeatures caps the number of features of a single import
+const maxImportFeatures = 1000
+
+func SetupRoutes(app *fiber.App) {
+
+	api := app.Group("/api")
+
+	api.Get("regions", GetRegions)
+	api.Post("regions", PostRegion)
+	api.Post("regions/import", ImportRegions)
+
+	api.Get("regions/:id", GetRegion)
+	api.Put("regions/:id", PutRegion)
+	api.Delete("regions/:id", DeleteRegion)
+
+	api.Get("regions/:id/aqi", GetRegionAQI)
+	api.Get("regions/:id/timeseries", GetRegionTimeSeries)
+	api.Get("regions/:id/anomalies", GetRegionAnomalies)
+}
+
+// GetRegions
+//
+//	@Summary		Lists regions
+//	@Description	Lists the saved regions without their boundaries, a page at a time ordered by name
+//	@Tags			regions
+//	@Produce		json
+//
+//	@Param			limit	query		int					false	"Maximum number of regions, 1-10000"	default(1000)
+//	@Param			order	query		string				false	"Order by name: asc or desc"	default(asc)
+//	@Param			cursor	query		string				false	"next_cursor of the previous page"
+//	@Param			fields	query		string				false	"Comma separated fields to return, e.g. id,name"
+//
+//	@Failure		400		{object}	map[string]string	"Invalid params"
+//	@Failure		500		{object}	map[string]string	"Failed to fetch regions from database"
+//	@Success		200		{object}	map[string][]Region	"Regions"
+//	@Router			/api/regions [get]
+func GetRegions(c *fiber.Ctx) error {
+	page, msg := pollution.ParsePage[Region](c)
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	repo := NewRegionRepo(database.DBPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	regions, err := repo.GetRegions(ctx, page)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch regions from database: " + err.Error(),
+		})
+	}
+
+	return pollution.WritePage(c, page, regions, func(r Region) pollution.Cursor {
+		return pollution.Cursor{Key: r.Name}
+	})
+}
+
+// GetRegion
+//
+//	@Summary		Gets a region
+//	@Description	Gets a saved region with its boundary
+//	@Tags			regions
+//	@Produce		json
+//
+//	@Param			id	path		int					true	"Region id"
+//
+//	@Failure		400	{object}	map[string]string	"Invalid id"
+//	@Failure		404	{object}	map[string]string	"Region not found"
+//	@Failure		500	{object}	map[string]string	"Failed to fetch region from database"
+//	@Success		200	{object}	map[string]Region	"Region"
+//	@Router			/api/regions/{id} [get]
+func GetRegion(c *fiber.Ctx) error {
+	id, ok := parseID(c)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Incorrect region id!",
+		})
+	}
+
+	repo := NewRegionRepo(database.DBPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	region, err := repo.GetRegion(ctx, id)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"data": region,
+	})
+}
+
+// PostRegion
+//
+//	@Summary		Saves a region
+//	@Description	Saves a named region. The boundary is a GeoJSON Polygon, MultiPolygon or a Feature wrapping one.
+//	@Tags			regions
+//	@Accept			json
+//	@Produce		json
+//
+//	@Param			request	body		Region				true	"Region"
+//
+//	@Failure		400		{object}	map[string]string	"Failed to parse request body"
+//	@Failure		409		{object}	map[string]string	"Region already exists"
+//	@Failure		422		{object}	map[string]any		"Invalid region, with field level errors"
+//	@Failure		500		{object}	map[string]string	"Failed to save region"
+//	@Success		201		{object}	map[string]Region	"Saved region"
+//	@Router			/api/regions [post]
+func PostRegion(c *fiber.Ctx) error {
+	var region Region
+	if err := json.Unmarshal(c.Body(), &region); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Failed to parse request body - " + err.Error(),
+		})
+	}
+
+	if errs := region.Validate(); errs != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error":  "Invalid region",
+			"fields": errs,
+		})
+	}
+
+	repo := NewRegionRepo(database.DBPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	created, err := repo.CreateRegion(ctx, region)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"data": created,
+	})
+}
+
+// ImportRegions
+//
+//	@Summary		Imports regions from GeoJSON
+//	@Description	Saves every feature of a GeoJSON FeatureCollection as a region, named by the name_property property
+//	@Description	of the feature. Regions that already exist are replaced. Either all features are imported or none.
+//	@Tags			regions
+//	@Accept			json
+//	@Produce		json
+//
+//	@Param			name_property	query		string					false	"Feature property holding the region name"	default(name)
+//	@Param			request			body		object					true	"GeoJSON FeatureCollection"
+//
+//	@Failure		400				{object}	map[string]string		"Failed to parse request body"
+//	@Failure		422				{object}	map[string]any			"Invalid features, with field level errors"
+//	@Failure		500				{object}	map[string]string		"Failed to import regions"
+//	@Success		200				{object}	map[string]ImportResult	"Number of created and updated regions"
+//	@Router			/api/regions/import [post]
+func ImportRegions(c *fiber.Ctx) error {
+	var collection featureCollection
+	if err := json.Unmarshal(c.Body(), &collection); err != nil || collection.Type != "FeatureCollection" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Body must be a GeoJSON FeatureCollection",
+		})
+	}
+
+	if len(collection.Features) == 0 || len(collection.Features) > maxImportFeatures {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("FeatureCollection must have between 1 and %d features", maxImportFeatures),
+		})
+	}
+
+	regions := regionsOf(collection, c.Query("name_property", "name"))
+
+	var errs pollution.ValidationErrors
+	seen := make(map[string]bool)
+	for i := range regions {
+		for _, fe := range regions[i].Validate() {
+			errs = append(errs, pollution.FieldError{Field: fmt.Sprintf("features[%d].%s", i, fe.Field), Message: fe.Message})
+		}
+		if seen[regions[i].Name] {
+			errs = append(errs, pollution.FieldError{Field: fmt.Sprintf("features[%d].name", i), Message: "is used by an earlier feature"})
+		}
+		seen[regions[i].Name] = true
+	}
+	if errs != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error":  "Invalid features",
+			"fields": errs,
+		})
+	}
+
+	repo := NewRegionRepo(database.DBPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	result, err := repo.ImportRegions(ctx, regions)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to import regions: " + err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"data": result,
+	})
+}
+
+// PutRegion
+//
+//	@Summary		Updates a region
+//	@Description	Updates a saved region, fields missing from the body keep their current value.
+//	@Description	A region can not be renamed while anomaly rules use its boundary.
+//	@Tags			regions
+//	@Accept			json
+//	@Produce		json
+//
+//	@Param			id		path		int					true	"Region id"
+//	@Param			request	body		Region				true	"Region"
+//
+//	@Failure		400		{object}	map[string]string	"Failed to parse request body"
+//	@Failure		404		{object}	map[string]string	"Region not found"
+//	@Failure		409		{object}	map[string]string	"Region name is taken or used by anomaly rules"
+//	@Failure		422		{object}	map[string]any		"Invalid region, with field level errors"
+//	@Failure		500		{object}	map[string]string	"Failed to save region"
+//	@Success		200		{object}	map[string]Region	"Updated region"
+//	@Router			/api/regions/{id} [put]
+func PutRegion(c *fiber.Ctx) error {
+	id, ok := parseID(c)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Incorrect region id!",
+		})
+	}
+
+	repo := NewRegionRepo(database.DBPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	region, err := repo.GetRegion(ctx, id)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+
+	if err := json.Unmarshal(c.Body(), &region); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Failed to parse request body - " + err.Error(),
+		})
+	}
+
+	// The path identifies the region, not the body
+	region.ID = id
+
+	if errs := region.Validate(); errs != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error":  "Invalid region",
+			"fields": errs,
+		})
+	}
+
+	updated, err := repo.UpdateRegion(ctx, region)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"data": updated,
+	})
+}
+
+// DeleteRegion
+//
+//	@Summary		Deletes a region
+//	@Description	Deletes a saved region. A region can not be deleted while anomaly rules use its boundary.
+//	@Tags			regions
+//	@Produce		json
+//
+//	@Param			id	path		int					true	"Region id"
+//
+//	@Failure		400	{object}	map[string]string	"Invalid id"
+//	@Failure		404	{object}	map[string]string	"Region not found"
+//	@Failure		409	{object}	map[string]string	"Region is used by anomaly rules"
+//	@Failure		500	{object}	map[string]string	"Failed to delete region"
+//	@Success		200	{object}	map[string]string	"Region deleted"
+//	@Router			/api/regions/{id} [delete]
+func DeleteRegion(c *fiber.Ctx) error {
+	id, ok := parseID(c)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Incorrect region id!",
+		})
+	}
+
+	repo := NewRegionRepo(database.DBPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := repo.DeleteRegion(ctx, id); err != nil {
+		return errorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Region deleted",
+	})
+}
+
+// GetRegionAQI
+//
+//	@Summary		Gets the AQI of a region
+//	@Description	Computes the air quality index from the readings inside the region.
+//	@Description	By default every pollutant is averaged over the averaging period the scale defines for it, ending at `to`.
+//	@Description	If `from` is given, every pollutant is averaged over the whole range instead.
+//	@Tags			regions
+//	@Produce		json
+//
+//	@Param			id		path		int								true	"Region id"
+//	@Param			from	query		string							false	"Start time, overrides the averaging periods of the scale"
+//	@Param			to		query		string							false	"End time, defaults to now"
+//	@Param			tz		query		string							false	"IANA time zone of times without an offset"	default(UTC)
+//	@Param			scale	query		string							false	"AQI scale: epa, caqi, daqi"	default(epa)
+//
+//	@Failure		400		{object}	map[string]string				"Invalid params"
+//	@Failure		404		{object}	map[string]string				"Region not found or no readings to compute the AQI from"
+//	@Failure		500		{object}	map[string]string				"Failed to fetch readings from database"
+//	@Success		200		{object}	map[string]pollution.AQIResult	"AQI"
+//	@Router			/api/regions/{id}/aqi [get]
+func GetRegionAQI(c *fiber.Ctx) error {
+	return withBoundary(c, pollution.WriteAreaAQI)
+}
+
+// GetRegionTimeSeries
+//
+//	@Summary		Gets the time series of a region
+//	@Description	Aggregates the readings inside the region per time bucket and pollutant
+//	@Tags			regions
+//	@Produce		json
+//
+//	@Param			id			path		int								true	"Region id"
+//	@Param			from		query		string							false	"Start time, defaults to 24 hours ago"
+//	@Param			to			query		string							false	"End time, defaults to now"
+//	@Param			tz			query		string							false	"IANA time zone of times without an offset and of the returned times"	default(UTC)
+//	@Param			step		query		string							false	"Bucket size as a duration, e.g. 15m, 1h, or auto"	default(1h)
+//	@Param			points		query		int								false	"Number of buckets an auto step aims for, 1-10000"	default(200)
+//	@Param			pollutant	query		string							false	"Pollutant"
+//	@Param			unit		query		string							false	"Unit to return the values in: µg/m³, mg/m³, ppb, ppm"
+//
+//	@Failure		400			{object}	map[string]string				"Invalid params"
+//	@Failure		404			{object}	map[string]string				"Region not found"
+//	@Failure		500			{object}	map[string]string				"Failed to fetch statistics from database"
+//	@Success		200			{object}	[]pollution.AreaStatistics		"Statistics per bucket and pollutant"
+//	@Router			/api/regions/{id}/timeseries [get]
+func GetRegionTimeSeries(c *fiber.Ctx) error {
+	return withBoundary(c, pollution.WriteAreaStatistics)
+}
+
+// GetRegionAnomalies
+//
+//	@Summary		Gets the anomalies of a region
+//	@Description	Gets the anomalies inside the region for a time range, newest first, a page at a time
+//	@Tags			regions
+//	@Produce		json
+//
+//	@Param			id			path		int									true	"Region id"
+//	@Param			from		query		string								true	"Start time"
+//	@Param			to			query		string								true	"End time"
+//	@Param			tz			query		string								false	"IANA time zone of times without an offset and of the returned times"	default(UTC)
+//	@Param			pollutant	query		string								false	"Pollutant"
+//	@Param			status		query		string								false	"Comma separated statuses: open, acknowledged, resolved"
+//	@Param			severity	query		string								false	"Comma separated severities: low, medium, high, critical"
+//	@Param			unit		query		string								false	"Unit to return the values in: µg/m³, mg/m³, ppb, ppm"
+//	@Param			limit		query		int									false	"Maximum number of anomalies, 1-10000"	default(1000)
+//	@Param			order		query		string								false	"Order by time: asc or desc"	default(desc)
+//	@Param			cursor		query		string								false	"next_cursor of the previous page"
+//	@Param			fields		query		string								false	"Comma separated fields to return, e.g. time,value"
+//
+//	@Failure		400			{object}	map[string]string					"Invalid params"
+//	@Failure		404			{object}	map[string]string					"Region not found"
+//	@Failure		500			{object}	map[string]string					"Failed to fetch anomalies from database"
+//	@Success		200			{object}	map[string][]pollution.Anomaly		"Anomalies"
+//	@Router			/api/regions/{id}/anomalies [get]
+func GetRegionAnomalies(c *fiber.Ctx) error {
+	return withBoundary(c, pollution.WriteAreaAnomalies)
+}
+
+func parseID(c *fiber.Ctx) (int64, bool) {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	return id, err == nil
+}
+
+// withBoundary looks up the region in the path and passes its boundary to the
+// area handler of the pollution package
+func withBoundary(c *fiber.Ctx, handler func(c *fiber.Ctx, geometry string) error) error {
+	id, ok := parseID(c)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Incorrect region id!",
+		})
+	}
+
+	repo := NewRegionRepo(database.DBPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	region, err := repo.GetRegion(ctx, id)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+
+	return handler(c, string(region.Boundary))
+}
+
+func errorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Region not found",
+		})
+	case errors.Is(err, ErrAlreadyExists):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Region already exists",
+		})
+	case errors.Is(err, ErrInUse):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Region is used by anomaly rules without a boundary of their own",
+		})
+	}
+
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": "Failed to access regions in database: " + err.Error(),
+	})
+}
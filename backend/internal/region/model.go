@@ -0,0 +1,78 @@
+package region
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/AkifSahn/pollution-tracker/internal/pollution"
+)
+
+// Region is a named area like a city or an industrial zone. Anomaly rules
+// with the name of a region and no boundary of their own apply inside it.
+type Region struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Boundary is a GeoJSON Polygon or MultiPolygon, left out of region lists
+	Boundary  json.RawMessage `json:"boundary,omitempty" swaggertype:"object"`
+	AreaKm2   float64         `json:"area_km2"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// ImportResult reports what happened to the features of an import
+type ImportResult struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+}
+
+const maxNameLength = 128
+
+// Validate returns the problems of the region as field errors. A Feature
+// boundary is replaced with its geometry.
+func (r *Region) Validate() pollution.ValidationErrors {
+	var errs pollution.ValidationErrors
+
+	if r.Name == "" {
+		errs = append(errs, pollution.FieldError{Field: "name", Message: "is required"})
+	} else if len(r.Name) > maxNameLength {
+		errs = append(errs, pollution.FieldError{Field: "name", Message: "must be at most 128 characters"})
+	}
+
+	if len(r.Boundary) == 0 {
+		errs = append(errs, pollution.FieldError{Field: "boundary", Message: "is required"})
+	} else if geometry, err := pollution.ParseAreaGeometry(r.Boundary); err != nil {
+		errs = append(errs, pollution.FieldError{Field: "boundary", Message: err.Error()})
+	} else {
+		r.Boundary = geometry
+	}
+
+	return errs
+}
+
+type featureCollection struct {
+	Type     string    `json:"type"`
+	Features []feature `json:"features"`
+}
+
+type feature struct {
+	Properties map[string]any  `json:"properties"`
+	Geometry   json.RawMessage `json:"geometry"`
+}
+
+// regionsOf turns the features of a GeoJSON FeatureCollection into regions,
+// named by their nameProperty property
+func regionsOf(collection featureCollection, nameProperty string) []Region {
+	regions := make([]Region, len(collection.Features))
+	for i, f := range collection.Features {
+		regions[i].Boundary = f.Geometry
+		if name, ok := f.Properties[nameProperty].(string); ok {
+			regions[i].Name = name
+		}
+		if description, ok := f.Properties["description"].(string); ok {
+			regions[i].Description = description
+		}
+	}
+
+	return regions
+}
@@ -0,0 +1,249 @@
+package region
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/AkifSahn/pollution-tracker/internal/pollution"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrNotFound      = errors.New("region not found")
+	ErrAlreadyExists = errors.New("region already exists")
+	// ErrInUse is returned when a region can not be renamed or deleted because
+	// anomaly rules without a boundary of their own refer to it by name
+	ErrInUse = errors.New("region is used by anomaly rules")
+)
+
+type RegionRepo interface {
+	GetRegions(ctx context.Context, page pollution.Page) ([]Region, error)
+	GetRegion(ctx context.Context, id int64) (Region, error)
+	CreateRegion(ctx context.Context, region Region) (Region, error)
+	UpdateRegion(ctx context.Context, region Region) (Region, error)
+	DeleteRegion(ctx context.Context, id int64) error
+	ImportRegions(ctx context.Context, regions []Region) (ImportResult, error)
+}
+
+type RegionRepoImpl struct {
+	DB *pgxpool.Pool
+}
+
+func NewRegionRepo(db *pgxpool.Pool) *RegionRepoImpl {
+	return &RegionRepoImpl{
+		DB: db,
+	}
+}
+
+const regionColumns = `
+    id, name, description, ST_AsGeoJSON(boundary), ST_Area(boundary) / 1e6, created_at, updated_at
+`
+
+func scanRegion(row pgx.Row) (Region, error) {
+	var r Region
+	var boundary string
+	err := row.Scan(&r.ID, &r.Name, &r.Description, &boundary, &r.AreaKm2, &r.CreatedAt, &r.UpdatedAt)
+	r.Boundary = []byte(boundary)
+	return r, err
+}
+
+// GetRegions lists the regions without their boundaries, which can be large
+func (repo *RegionRepoImpl) GetRegions(ctx context.Context, page pollution.Page) ([]Region, error) {
+	query := `
+    SELECT id, name, description, ST_Area(boundary) / 1e6, created_at, updated_at
+    FROM regions WHERE true
+    `
+	var args []interface{}
+	keys := page.SortBy(pollution.AscText("name"))
+	query += page.After(&args, keys)
+	query += page.OrderBy(&args, keys)
+
+	rows, err := repo.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var regions []Region
+	for rows.Next() {
+		var r Region
+		err := rows.Scan(&r.ID, &r.Name, &r.Description, &r.AreaKm2, &r.CreatedAt, &r.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to scan - %s", err.Error())
+		}
+		regions = append(regions, r)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows Error: %v\n", rows.Err())
+	}
+
+	return regions, nil
+}
+
+func (repo *RegionRepoImpl) GetRegion(ctx context.Context, id int64) (Region, error) {
+	query := `SELECT ` + regionColumns + ` FROM regions WHERE id = $1;`
+
+	region, err := scanRegion(repo.DB.QueryRow(ctx, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return region, ErrNotFound
+	}
+	if err != nil {
+		return region, fmt.Errorf("Unable to scan - %s", err.Error())
+	}
+
+	return region, nil
+}
+
+func (repo *RegionRepoImpl) CreateRegion(ctx context.Context, r Region) (Region, error) {
+	query := `
+    INSERT INTO regions (name, description, boundary)
+    VALUES ($1, $2, ST_GeomFromGeoJSON($3::text)::geography)
+    RETURNING ` + regionColumns + `;
+    `
+	created, err := scanRegion(repo.DB.QueryRow(ctx, query, r.Name, r.Description, string(r.Boundary)))
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return created, ErrAlreadyExists
+	}
+	if err != nil {
+		return created, fmt.Errorf("Failed to insert into database - %s", err.Error())
+	}
+
+	return created, nil
+}
+
+// UpdateRegion replaces the region. Renaming a region that anomaly rules
+// depend on returns ErrInUse, the rules would silently stop applying.
+func (repo *RegionRepoImpl) UpdateRegion(ctx context.Context, r Region) (Region, error) {
+	tx, err := repo.DB.Begin(ctx)
+	if err != nil {
+		return r, fmt.Errorf("Unable to begin transaction - %s", err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	var name string
+	err = tx.QueryRow(ctx, `SELECT name FROM regions WHERE id = $1 FOR UPDATE;`, r.ID).Scan(&name)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return r, ErrNotFound
+	}
+	if err != nil {
+		return r, fmt.Errorf("Unable to scan - %s", err.Error())
+	}
+
+	if name != r.Name {
+		if err := checkNotInUse(ctx, tx, name); err != nil {
+			return r, err
+		}
+	}
+
+	query := `
+    UPDATE regions SET
+        name = $2, description = $3, boundary = ST_GeomFromGeoJSON($4::text)::geography,
+        updated_at = now()
+    WHERE id = $1
+    RETURNING ` + regionColumns + `;
+    `
+	updated, err := scanRegion(tx.QueryRow(ctx, query, r.ID, r.Name, r.Description, string(r.Boundary)))
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return updated, ErrAlreadyExists
+	}
+	if err != nil {
+		return updated, fmt.Errorf("Failed to update region - %s", err.Error())
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return updated, fmt.Errorf("Unable to commit transaction - %s", err.Error())
+	}
+
+	return updated, nil
+}
+
+// DeleteRegion removes the region, unless anomaly rules depend on it
+func (repo *RegionRepoImpl) DeleteRegion(ctx context.Context, id int64) error {
+	tx, err := repo.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("Unable to begin transaction - %s", err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	var name string
+	err = tx.QueryRow(ctx, `DELETE FROM regions WHERE id = $1 RETURNING name;`, id).Scan(&name)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to delete region - %s", err.Error())
+	}
+
+	if err := checkNotInUse(ctx, tx, name); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("Unable to commit transaction - %s", err.Error())
+	}
+
+	return nil
+}
+
+// ImportRegions creates the regions, replacing the boundary and description
+// of the ones whose name already exists, all or nothing
+func (repo *RegionRepoImpl) ImportRegions(ctx context.Context, regions []Region) (ImportResult, error) {
+	var result ImportResult
+
+	tx, err := repo.DB.Begin(ctx)
+	if err != nil {
+		return result, fmt.Errorf("Unable to begin transaction - %s", err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+    INSERT INTO regions (name, description, boundary)
+    VALUES ($1, $2, ST_GeomFromGeoJSON($3::text)::geography)
+    ON CONFLICT (name) DO UPDATE SET
+        description = EXCLUDED.description,
+        boundary = EXCLUDED.boundary,
+        updated_at = now()
+    RETURNING xmax = 0;
+    `
+	for _, r := range regions {
+		var inserted bool
+		err := tx.QueryRow(ctx, query, r.Name, r.Description, string(r.Boundary)).Scan(&inserted)
+		if err != nil {
+			return result, fmt.Errorf("Failed to import region %q - %s", r.Name, err.Error())
+		}
+
+		if inserted {
+			result.Created++
+		} else {
+			result.Updated++
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return result, fmt.Errorf("Unable to commit transaction - %s", err.Error())
+	}
+
+	return result, nil
+}
+
+func checkNotInUse(ctx context.Context, tx pgx.Tx, name string) error {
+	var used bool
+	query := `SELECT EXISTS (SELECT 1 FROM anomaly_rules WHERE region = $1 AND boundary IS NULL);`
+	if err := tx.QueryRow(ctx, query, name).Scan(&used); err != nil {
+		return fmt.Errorf("Unable to scan - %s", err.Error())
+	}
+
+	if used {
+		return ErrInUse
+	}
+
+	return nil
+}
@@ -0,0 +1,329 @@
+package station
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/AkifSahn/pollution-tracker/internal/database"
+	"github.com/AkifSahn/pollution-tracker/internal/pollution"
+	"github.com/gofiber/fiber/v2"
+)
+
+func SetupRoutes(app *fiber.App) {
+
+	api := app.Group("/api")
+
+	api.Get("stations", GetStations)
+	api.Post("stations", PostStation)
+
+	api.Get("stations/:id", GetStation)
+	api.Put("stations/:id", PutStation)
+	api.Delete("stations/:id", DeleteStation)
+	api.Get("stations/:id/readings", GetStationReadings)
+}
+
+// GetStations
+//
+//	@Summary		Lists stations
+//	@Description	Lists the registered stations, a page at a time ordered by id
+//	@Tags			stations
+//	@Produce		json
+//
+//	@Param			active	query		bool					false	"Only list active stations"
+//	@Param			limit	query		int						false	"Maximum number of stations, 1-10000"	default(1000)
+//	@Param			order	query		string					false	"Order by id: asc or desc"	default(asc)
+//	@Param			cursor	query		string					false	"next_cursor of the previous page"
+//	@Param			fields	query		string					false	"Comma separated fields to return, e.g. id,name"
+//
+//	@Failure		400		{object}	map[string]string		"Invalid params"
+//	@Failure		500		{object}	map[string]string		"Failed to fetch stations from database"
+//	@Success		200		{object}	map[string][]Station	"Stations"
+//	@Router			/api/stations [get]
+func GetStations(c *fiber.Ctx) error {
+	page, msg := pollution.ParsePage[Station](c)
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	repo := NewStationRepo(database.DBPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	stations, err := repo.GetStations(ctx, c.QueryBool("active"), page)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch stations from database: " + err.Error(),
+		})
+	}
+
+	return pollution.WritePage(c, page, stations, func(s Station) pollution.Cursor {
+		return pollution.Cursor{Key: s.ID}
+	})
+}
+
+// GetStation
+//
+//	@Summary		Gets a station
+//	@Description	Gets a registered station by its id
+//	@Tags			stations
+//	@Produce		json
+//
+//	@Param			id	path		string				true	"Station id"
+//
+//	@Failure		404	{object}	map[string]string	"Station not found"
+//	@Failure		500	{object}	map[string]string	"Failed to fetch station from database"
+//	@Success		200	{object}	map[string]Station	"Station"
+//	@Router			/api/stations/{id} [get]
+func GetStation(c *fiber.Ctx) error {
+	repo := NewStationRepo(database.DBPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	station, err := repo.GetStation(ctx, c.Params("id"))
+	if err != nil {
+		return errorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"data": station,
+	})
+}
+
+// PostStation
+//
+//	@Summary		Registers a station
+//	@Description	Registers a new station. Stations are active unless the body says otherwise.
+//	@Tags			stations
+//	@Accept			json
+//	@Produce		json
+//
+//	@Param			request	body		Station				true	"Station"
+//
+//	@Failure		400		{object}	map[string]string	"Failed to parse request body"
+//	@Failure		409		{object}	map[string]string	"Station already exists"
+//	@Failure		422		{object}	map[string]any		"Invalid station, with field level errors"
+//	@Failure		500		{object}	map[string]string	"Failed to save station"
+//	@Success		201		{object}	map[string]Station	"Registered station"
+//	@Router			/api/stations [post]
+func PostStation(c *fiber.Ctx) error {
+	station := Station{Active: true}
+	if err := json.Unmarshal(c.Body(), &station); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Failed to parse request body - " + err.Error(),
+		})
+	}
+
+	if errs := station.Validate(); errs != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error":  "Invalid station",
+			"fields": errs,
+		})
+	}
+
+	repo := NewStationRepo(database.DBPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	created, err := repo.CreateStation(ctx, station)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"data": created,
+	})
+}
+
+// PutStation
+//
+//	@Summary		Updates a station
+//	@Description	Updates a registered station, fields missing from the body keep their current value.
+//	@Description	Moving a station does not move the readings it already reported.
+//	@Tags			stations
+//	@Accept			json
+//	@Produce		json
+//
+//	@Param			id		path		string				true	"Station id"
+//	@Param			request	body		Station				true	"Station"
+//
+//	@Failure		400		{object}	map[string]string	"Failed to parse request body"
+//	@Failure		404		{object}	map[string]string	"Station not found"
+//	@Failure		422		{object}	map[string]any		"Invalid station, with field level errors"
+//	@Failure		500		{object}	map[string]string	"Failed to save station"
+//	@Success		200		{object}	map[string]Station	"Updated station"
+//	@Router			/api/stations/{id} [put]
+func PutStation(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	repo := NewStationRepo(database.DBPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	station, err := repo.GetStation(ctx, id)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+
+	if err := json.Unmarshal(c.Body(), &station); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Failed to parse request body - " + err.Error(),
+		})
+	}
+
+	// The path identifies the station, not the body
+	station.ID = id
+
+	if errs := station.Validate(); errs != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error":  "Invalid station",
+			"fields": errs,
+		})
+	}
+
+	updated, err := repo.UpdateStation(ctx, station)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"data": updated,
+	})
+}
+
+// DeleteStation
+//
+//	@Summary		Deletes a station
+//	@Description	Removes a station from the registry. Its readings are kept and can still be queried by station id.
+//	@Description	Set active to false instead to stop accepting readings but keep the station.
+//	@Tags			stations
+//	@Produce		json
+//
+//	@Param			id	path		string				true	"Station id"
+//
+//	@Failure		404	{object}	map[string]string	"Station not found"
+//	@Failure		500	{object}	map[string]string	"Failed to delete station"
+//	@Success		200	{object}	map[string]string	"Station deleted"
+//	@Router			/api/stations/{id} [delete]
+func DeleteStation(c *fiber.Ctx) error {
+	repo := NewStationRepo(database.DBPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := repo.DeleteStation(ctx, c.Params("id")); err != nil {
+		return errorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Station deleted",
+	})
+}
+
+// GetStationReadings
+//
+//	@Summary		Gets station readings
+//	@Description	Gets the readings reported by a station for a time range, a page at a time ordered by time
+//	@Tags			stations
+//	@Produce		json
+//
+//	@Param			id			path		string										true	"Station id"
+//	@Param			from		query		string										false	"Start time, defaults to 24 hours ago"
+//	@Param			to			query		string										false	"End time, defaults to now"
+//	@Param			tz			query		string										false	"IANA time zone of times without an offset and of the returned times"	default(UTC)
+//	@Param			pollutant	query		string										false	"Pollutant"
+//	@Param			unit		query		string										false	"Unit to return the values in: µg/m³, mg/m³, ppb, ppm"
+//	@Param			limit		query		int											false	"Maximum number of readings, 1-10000"	default(1000)
+//	@Param			order		query		string										false	"Order by time: asc or desc, by pollutant and newest first if not set"
+//	@Param			cursor		query		string										false	"next_cursor of the previous page"
+//	@Param			fields		query		string										false	"Comma separated fields to return, e.g. time,value"
+//
+//	@Failure		400			{object}	map[string]string							"Invalid params"
+//	@Failure		404			{object}	map[string]string							"Station not found"
+//	@Failure		500			{object}	map[string]string							"Failed to fetch readings from database"
+//	@Success		200			{object}	map[string][]pollution.PollutionValueResponse	"Readings"
+//	@Router			/api/stations/{id}/readings [get]
+func GetStationReadings(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	fromStr := c.Query("from", "now-24h")
+	toStr := c.Query("to", "now")
+
+	loc, msg := pollution.ParseTimeZone(c.Query("tz"))
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	var from, to time.Time
+	ok, msg := pollution.ParseTimeRange(fromStr, toStr, loc, 0, &from, &to)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	pollutant := c.Query("pollutant")
+
+	unit, msg := pollution.ParseUnitParam(c.Query("unit"), pollutant)
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	page, msg := pollution.ParsePage[pollution.PollutionValueResponse](c)
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	repo := NewStationRepo(database.DBPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, stationErr := repo.GetStation(ctx, id)
+	if stationErr != nil && !errors.Is(stationErr, ErrNotFound) {
+		return errorResponse(c, stationErr)
+	}
+
+	readings, err := repo.GetReadings(ctx, id, from, to, pollutant, page)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch readings from database: " + err.Error(),
+		})
+	}
+
+	// Readings of deleted stations can still be queried
+	if stationErr != nil && len(readings) == 0 {
+		return errorResponse(c, stationErr)
+	}
+
+	for i, r := range readings {
+		readings[i].Value, readings[i].Unit = pollution.ConvertValue(r.Pollutant, r.Value, unit)
+	}
+	pollution.InLocation(readings, loc)
+
+	return pollution.WritePage(c, page, readings, pollution.PollutionValueCursor)
+}
+
+func errorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Station not found",
+		})
+	case errors.Is(err, ErrAlreadyExists):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Station already exists",
+		})
+	}
+
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": "Failed to access stations in database: " + err.Error(),
+	})
+}
@@ -0,0 +1,68 @@
+package station
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/AkifSahn/pollution-tracker/internal/pollution"
+)
+
+// Station is a registered sensor. Readings that reference a station take its
+// fixed location instead of the coordinates they were sent with.
+type Station struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Latitude   float64  `json:"latitude"`
+	Longitude  float64  `json:"longitude"`
+	Owner      string   `json:"owner,omitempty"`
+	Pollutants []string `json:"pollutants"`
+	// Active stations accept readings, decommissioned ones keep their history
+	Active      bool        `json:"active"`
+	Calibration Calibration `json:"calibration"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+type Calibration struct {
+	LastCalibratedAt *time.Time `json:"last_calibrated_at,omitempty"`
+	NextDueAt        *time.Time `json:"next_due_at,omitempty"`
+	Notes            string     `json:"notes,omitempty"`
+}
+
+var idPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// Validate returns the problems of the station as field errors
+func (s Station) Validate() pollution.ValidationErrors {
+	var errs pollution.ValidationErrors
+
+	if !idPattern.MatchString(s.ID) {
+		errs = append(errs, pollution.FieldError{Field: "id", Message: "must be 1-64 letters, digits, '.', '_' or '-'"})
+	}
+
+	if s.Name == "" {
+		errs = append(errs, pollution.FieldError{Field: "name", Message: "is required"})
+	}
+
+	if s.Latitude < -90 || s.Latitude > 90 {
+		errs = append(errs, pollution.FieldError{Field: "latitude", Message: "must be between -90 and 90"})
+	}
+
+	if s.Longitude < -180 || s.Longitude > 180 {
+		errs = append(errs, pollution.FieldError{Field: "longitude", Message: "must be between -180 and 180"})
+	}
+
+	if len(s.Pollutants) == 0 {
+		errs = append(errs, pollution.FieldError{Field: "pollutants", Message: "at least one pollutant is required"})
+	}
+	for i, p := range s.Pollutants {
+		if _, ok := pollution.KnownPollutants[p]; !ok {
+			errs = append(errs, pollution.FieldError{Field: "pollutants", Message: fmt.Sprintf("unknown pollutant %q", p)})
+		} else if slices.Contains(s.Pollutants[:i], p) {
+			errs = append(errs, pollution.FieldError{Field: "pollutants", Message: fmt.Sprintf("duplicate pollutant %q", p)})
+		}
+	}
+
+	return errs
+}
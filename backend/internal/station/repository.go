@@ -0,0 +1,193 @@
+package station
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/AkifSahn/pollution-tracker/internal/pollution"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrNotFound      = errors.New("station not found")
+	ErrAlreadyExists = errors.New("station already exists")
+)
+
+type StationRepo interface {
+	GetStations(ctx context.Context, activeOnly bool, page pollution.Page) ([]Station, error)
+	GetStation(ctx context.Context, id string) (Station, error)
+	CreateStation(ctx context.Context, station Station) (Station, error)
+	UpdateStation(ctx context.Context, station Station) (Station, error)
+	DeleteStation(ctx context.Context, id string) error
+
+	GetReadings(ctx context.Context, id string, from, to time.Time, pollutant string, page pollution.Page) ([]pollution.PollutionValueResponse, error)
+}
+
+type StationRepoImpl struct {
+	DB *pgxpool.Pool
+}
+
+func NewStationRepo(db *pgxpool.Pool) *StationRepoImpl {
+	return &StationRepoImpl{
+		DB: db,
+	}
+}
+
+const stationColumns = `
+    id, name, latitude, longitude, owner, pollutants, active,
+    last_calibrated_at, calibration_due_at, calibration_notes, created_at, updated_at
+`
+
+func scanStation(row pgx.Row) (Station, error) {
+	var s Station
+	err := row.Scan(&s.ID, &s.Name, &s.Latitude, &s.Longitude, &s.Owner, &s.Pollutants, &s.Active,
+		&s.Calibration.LastCalibratedAt, &s.Calibration.NextDueAt, &s.Calibration.Notes, &s.CreatedAt, &s.UpdatedAt)
+	return s, err
+}
+
+func (repo *StationRepoImpl) GetStations(ctx context.Context, activeOnly bool, page pollution.Page) ([]Station, error) {
+	query := `SELECT ` + stationColumns + ` FROM stations WHERE true`
+	if activeOnly {
+		query += " AND active"
+	}
+
+	var args []interface{}
+	keys := page.SortBy(pollution.AscText("id"))
+	query += page.After(&args, keys)
+	query += page.OrderBy(&args, keys)
+
+	rows, err := repo.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var stations []Station
+	for rows.Next() {
+		station, err := scanStation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to scan - %s", err.Error())
+		}
+		stations = append(stations, station)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows Error: %v\n", rows.Err())
+	}
+
+	return stations, nil
+}
+
+func (repo *StationRepoImpl) GetStation(ctx context.Context, id string) (Station, error) {
+	query := `SELECT ` + stationColumns + ` FROM stations WHERE id = $1;`
+
+	station, err := scanStation(repo.DB.QueryRow(ctx, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return station, ErrNotFound
+	}
+	if err != nil {
+		return station, fmt.Errorf("Unable to scan - %s", err.Error())
+	}
+
+	return station, nil
+}
+
+func (repo *StationRepoImpl) CreateStation(ctx context.Context, s Station) (Station, error) {
+	query := `
+    INSERT INTO stations (id, name, latitude, longitude, owner, pollutants, active,
+        last_calibrated_at, calibration_due_at, calibration_notes)
+    VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)
+    RETURNING ` + stationColumns + `;
+    `
+	created, err := scanStation(repo.DB.QueryRow(ctx, query, s.ID, s.Name, s.Latitude, s.Longitude, s.Owner,
+		s.Pollutants, s.Active, s.Calibration.LastCalibratedAt, s.Calibration.NextDueAt, s.Calibration.Notes))
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return created, ErrAlreadyExists
+	}
+	if err != nil {
+		return created, fmt.Errorf("Failed to insert into database - %s", err.Error())
+	}
+
+	return created, nil
+}
+
+func (repo *StationRepoImpl) UpdateStation(ctx context.Context, s Station) (Station, error) {
+	query := `
+    UPDATE stations SET
+        name = $2, latitude = $3, longitude = $4, owner = $5, pollutants = $6, active = $7,
+        last_calibrated_at = $8, calibration_due_at = $9, calibration_notes = $10,
+        updated_at = now()
+    WHERE id = $1
+    RETURNING ` + stationColumns + `;
+    `
+	updated, err := scanStation(repo.DB.QueryRow(ctx, query, s.ID, s.Name, s.Latitude, s.Longitude, s.Owner,
+		s.Pollutants, s.Active, s.Calibration.LastCalibratedAt, s.Calibration.NextDueAt, s.Calibration.Notes))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return updated, ErrNotFound
+	}
+	if err != nil {
+		return updated, fmt.Errorf("Failed to update station - %s", err.Error())
+	}
+
+	return updated, nil
+}
+
+// DeleteStation removes the station from the registry, its readings are kept
+func (repo *StationRepoImpl) DeleteStation(ctx context.Context, id string) error {
+	tag, err := repo.DB.Exec(ctx, `DELETE FROM stations WHERE id = $1;`, id)
+	if err != nil {
+		return fmt.Errorf("Failed to delete station - %s", err.Error())
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (repo *StationRepoImpl) GetReadings(ctx context.Context, id string, from, to time.Time, pollutant string, page pollution.Page) ([]pollution.PollutionValueResponse, error) {
+	query := `
+    SELECT id, time, value, pollutant FROM air_pollution
+    WHERE station_id = $1
+    AND time BETWEEN $2 AND $3
+    `
+	var args []interface{}
+	args = append(args, id, from, to)
+
+	if pollutant != "" {
+		query += " AND pollutant = $4"
+		args = append(args, pollutant)
+	}
+	keys := page.Sort(pollution.Asc("pollutant"), pollution.Desc("time"), pollution.Desc("id"))
+	query += page.After(&args, keys)
+	query += page.OrderBy(&args, keys)
+
+	rows, err := repo.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var readings []pollution.PollutionValueResponse
+	for rows.Next() {
+		var reading pollution.PollutionValueResponse
+		err = rows.Scan(&reading.ID, &reading.Time, &reading.Value, &reading.Pollutant)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to scan - %s", err.Error())
+		}
+		readings = append(readings, reading)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows Error: %v\n", rows.Err())
+	}
+
+	return readings, nil
+}
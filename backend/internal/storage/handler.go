@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/AkifSahn/pollution-tracker/internal/database"
+	"github.com/gofiber/fiber/v2"
+)
+
+func SetupRoutes(app *fiber.App) {
+
+	admin := app.Group("/api/admin")
+
+	admin.Get("storage", GetStorage)
+}
+
+// GetStorage
+//
+//	@Summary		Reports storage usage
+//	@Description	Reports the size and compression of the hypertables and continuous aggregates, and the active
+//	@Description	compression, retention and refresh policies. Chunks are listed on request, newest first.
+//	@Tags			admin
+//	@Produce		json
+//
+//	@Param			chunks	query		bool					false	"List the chunks"	default(false)
+//	@Param			table	query		string					false	"Only report this hypertable or continuous aggregate"
+//
+//	@Failure		404		{object}	map[string]string		"Table not found"
+//	@Failure		500		{object}	map[string]string		"Failed to fetch storage from database"
+//	@Success		200		{object}	map[string]Report		"Storage report"
+//	@Router			/api/admin/storage [get]
+func GetStorage(c *fiber.Ctx) error {
+	name := c.Query("table")
+
+	repo := NewStorageRepo(database.DBPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	tables, err := repo.GetTables(ctx)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch tables from database: " + err.Error(),
+		})
+	}
+
+	if name != "" {
+		var found []Table
+		for _, t := range tables {
+			if t.Name == name {
+				found = append(found, t)
+			}
+		}
+		if len(found) == 0 {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Table not found",
+			})
+		}
+		tables = found
+	}
+
+	policies, err := repo.GetPolicies(ctx)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch policies from database: " + err.Error(),
+		})
+	}
+
+	report := Report{Tables: tables, Policies: []Policy{}}
+	for _, p := range policies {
+		if name == "" || p.Table == name {
+			report.Policies = append(report.Policies, p)
+		}
+	}
+
+	if c.QueryBool("chunks") {
+		report.Chunks = []Chunk{}
+		for _, t := range tables {
+			chunks, err := repo.GetChunks(ctx, t)
+			if err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": "Failed to fetch chunks from database: " + err.Error(),
+				})
+			}
+			report.Chunks = append(report.Chunks, chunks...)
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"data": report,
+	})
+}
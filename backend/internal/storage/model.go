@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Table is the storage of a hypertable or continuous aggregate. The
+// compression fields are only set when compression is enabled for it.
+type Table struct {
+	Name string `json:"name"`
+	// Kind is hypertable or continuous_aggregate
+	Kind             string `json:"kind"`
+	TotalBytes       int64  `json:"total_bytes"`
+	Chunks           int64  `json:"chunks"`
+	CompressedChunks *int64 `json:"compressed_chunks,omitempty"`
+	// BeforeCompressionBytes and AfterCompressionBytes only cover the
+	// compressed chunks
+	BeforeCompressionBytes *int64 `json:"before_compression_bytes,omitempty"`
+	AfterCompressionBytes  *int64 `json:"after_compression_bytes,omitempty"`
+	// CompressionRatio is BeforeCompressionBytes / AfterCompressionBytes
+	CompressionRatio *float64 `json:"compression_ratio,omitempty"`
+
+	// relation is the hypertable holding the chunks
+	relation string
+}
+
+// Chunk is a chunk of a hypertable or continuous aggregate
+type Chunk struct {
+	Table      string    `json:"table"`
+	Name       string    `json:"name"`
+	RangeStart time.Time `json:"range_start"`
+	RangeEnd   time.Time `json:"range_end"`
+	Compressed bool      `json:"compressed"`
+	TotalBytes int64     `json:"total_bytes"`
+	// BeforeCompressionBytes is the size of a compressed chunk before it was
+	// compressed, its TotalBytes is the size after
+	BeforeCompressionBytes *int64   `json:"before_compression_bytes,omitempty"`
+	CompressionRatio       *float64 `json:"compression_ratio,omitempty"`
+}
+
+// Policy is a TimescaleDB job maintaining a table
+type Policy struct {
+	JobID int64 `json:"job_id"`
+	// Kind is compression, retention or refresh
+	Kind     string `json:"kind"`
+	Table    string `json:"table"`
+	Schedule string `json:"schedule_interval"`
+	// Config holds the settings of the job like compress_after or drop_after
+	Config        json.RawMessage `json:"config" swaggertype:"object"`
+	Scheduled     bool            `json:"scheduled"`
+	NextStart     *time.Time      `json:"next_start,omitempty"`
+	LastRunStatus string          `json:"last_run_status,omitempty"`
+	LastSuccess   *time.Time      `json:"last_successful_finish,omitempty"`
+	TotalFailures int64           `json:"total_failures"`
+}
+
+// Report is the storage usage and the active policies of the database
+type Report struct {
+	Tables   []Table  `json:"tables"`
+	Policies []Policy `json:"policies"`
+	Chunks   []Chunk  `json:"chunks,omitempty"`
+}
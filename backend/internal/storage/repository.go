@@ -0,0 +1,179 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type StorageRepo interface {
+	GetTables(ctx context.Context) ([]Table, error)
+	GetChunks(ctx context.Context, table Table) ([]Chunk, error)
+	GetPolicies(ctx context.Context) ([]Policy, error)
+}
+
+type StorageRepoImpl struct {
+	DB *pgxpool.Pool
+}
+
+func NewStorageRepo(db *pgxpool.Pool) *StorageRepoImpl {
+	return &StorageRepoImpl{
+		DB: db,
+	}
+}
+
+// ratio returns before / after, nil if either is unknown or after is zero
+func ratio(before, after *int64) *float64 {
+	if before == nil || after == nil || *after == 0 {
+		return nil
+	}
+
+	r := float64(*before) / float64(*after)
+	return &r
+}
+
+// GetTables returns the hypertables and continuous aggregates. The chunks of
+// a continuous aggregate belong to its materialization hypertable.
+func (repo *StorageRepoImpl) GetTables(ctx context.Context) ([]Table, error) {
+	query := `
+    WITH tables AS (
+        SELECT hypertable_name AS name, 'hypertable' AS kind,
+            format('%I.%I', hypertable_schema, hypertable_name) AS relation,
+            compression_enabled
+        FROM timescaledb_information.hypertables
+        WHERE hypertable_schema = 'public'
+        UNION ALL
+        SELECT view_name, 'continuous_aggregate',
+            format('%I.%I', materialization_hypertable_schema, materialization_hypertable_name),
+            compression_enabled
+        FROM timescaledb_information.continuous_aggregates
+    )
+    SELECT t.name, t.kind, t.relation,
+        COALESCE(hypertable_size(t.relation::regclass), 0),
+        (SELECT count(*) FROM show_chunks(t.relation::regclass)),
+        t.compression_enabled,
+        s.number_compressed_chunks, s.before_compression_total_bytes, s.after_compression_total_bytes
+    FROM tables t
+    LEFT JOIN LATERAL hypertable_compression_stats(t.relation::regclass) s ON t.compression_enabled
+    ORDER BY t.kind DESC, t.name
+    `
+	rows, err := repo.DB.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var tables []Table
+	for rows.Next() {
+		var t Table
+		var compression bool
+		err := rows.Scan(&t.Name, &t.Kind, &t.relation, &t.TotalBytes, &t.Chunks, &compression,
+			&t.CompressedChunks, &t.BeforeCompressionBytes, &t.AfterCompressionBytes)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to scan - %s", err.Error())
+		}
+
+		// Compression is enabled but no chunk was compressed yet
+		if compression && t.CompressedChunks == nil {
+			var zero int64
+			t.CompressedChunks = &zero
+		}
+		t.CompressionRatio = ratio(t.BeforeCompressionBytes, t.AfterCompressionBytes)
+
+		tables = append(tables, t)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows Error: %v\n", rows.Err())
+	}
+
+	return tables, nil
+}
+
+// GetChunks returns the chunks of the table, newest first. The size of a
+// compressed chunk is its size after compression.
+func (repo *StorageRepoImpl) GetChunks(ctx context.Context, table Table) ([]Chunk, error) {
+	query := `
+    SELECT c.chunk_name, c.range_start, c.range_end, c.is_compressed,
+        CASE WHEN c.is_compressed THEN COALESCE(cs.after_compression_total_bytes, d.total_bytes) ELSE d.total_bytes END,
+        cs.before_compression_total_bytes
+    FROM timescaledb_information.chunks c
+    JOIN chunks_detailed_size($1::text::regclass) d
+        ON d.chunk_schema = c.chunk_schema AND d.chunk_name = c.chunk_name
+    LEFT JOIN chunk_compression_stats($1::text::regclass) cs
+        ON cs.chunk_schema = c.chunk_schema AND cs.chunk_name = c.chunk_name
+       AND cs.compression_status = 'Compressed'
+    WHERE format('%I.%I', c.hypertable_schema, c.hypertable_name) = $1
+    ORDER BY c.range_start DESC
+    `
+	rows, err := repo.DB.Query(ctx, query, table.relation)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var chunks []Chunk
+	for rows.Next() {
+		c := Chunk{Table: table.Name}
+		err := rows.Scan(&c.Name, &c.RangeStart, &c.RangeEnd, &c.Compressed, &c.TotalBytes, &c.BeforeCompressionBytes)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to scan - %s", err.Error())
+		}
+		if c.Compressed {
+			c.CompressionRatio = ratio(c.BeforeCompressionBytes, &c.TotalBytes)
+		}
+
+		chunks = append(chunks, c)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows Error: %v\n", rows.Err())
+	}
+
+	return chunks, nil
+}
+
+// GetPolicies returns the compression, retention and refresh jobs
+func (repo *StorageRepoImpl) GetPolicies(ctx context.Context) ([]Policy, error) {
+	query := `
+    SELECT j.job_id,
+        CASE j.proc_name
+            WHEN 'policy_compression' THEN 'compression'
+            WHEN 'policy_retention' THEN 'retention'
+            ELSE 'refresh'
+        END AS kind,
+        COALESCE(ca.view_name, j.hypertable_name) AS table_name,
+        j.schedule_interval::text, j.config, j.scheduled, j.next_start,
+        COALESCE(s.last_run_status, ''), s.last_successful_finish, COALESCE(s.total_failures, 0)
+    FROM timescaledb_information.jobs j
+    LEFT JOIN timescaledb_information.job_stats s ON s.job_id = j.job_id
+    LEFT JOIN timescaledb_information.continuous_aggregates ca
+        ON ca.materialization_hypertable_schema = j.hypertable_schema
+       AND ca.materialization_hypertable_name = j.hypertable_name
+    WHERE j.proc_name IN ('policy_compression', 'policy_retention', 'policy_refresh_continuous_aggregate')
+    ORDER BY table_name, kind
+    `
+	rows, err := repo.DB.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var policies []Policy
+	for rows.Next() {
+		var p Policy
+		err := rows.Scan(&p.JobID, &p.Kind, &p.Table, &p.Schedule, &p.Config, &p.Scheduled, &p.NextStart,
+			&p.LastRunStatus, &p.LastSuccess, &p.TotalFailures)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to scan - %s", err.Error())
+		}
+		policies = append(policies, p)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows Error: %v\n", rows.Err())
+	}
+
+	return policies, nil
+}
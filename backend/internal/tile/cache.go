@@ -0,0 +1,80 @@
+package tile
+
+import (
+	"sync"
+	"time"
+)
+
+type cacheEntry struct {
+	data    []byte
+	expires time.Time
+}
+
+// Cache keeps rendered tiles in memory for a fixed time. When it is full the
+// entry closest to expiring is evicted, which is also the oldest one.
+type Cache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	entries    map[string]cacheEntry
+}
+
+func NewCache(ttl time.Duration, maxEntries int) *Cache {
+	return &Cache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]cacheEntry),
+	}
+}
+
+// Get returns the tile stored under the key, false if it is missing or expired
+func (c *Cache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	return entry.data, true
+}
+
+func (c *Cache) Set(key string, data []byte) {
+	if c.ttl <= 0 || c.maxEntries <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if _, exists := c.entries[key]; !exists && len(c.entries) >= c.maxEntries {
+		c.evict(now)
+	}
+
+	c.entries[key] = cacheEntry{data: data, expires: now.Add(c.ttl)}
+}
+
+// evict removes the expired entries, or the oldest entry if none expired
+func (c *Cache) evict(now time.Time) {
+	var oldestKey string
+	var oldest time.Time
+	for key, entry := range c.entries {
+		if now.After(entry.expires) {
+			delete(c.entries, key)
+			continue
+		}
+		if oldestKey == "" || entry.expires.Before(oldest) {
+			oldestKey, oldest = key, entry.expires
+		}
+	}
+
+	if len(c.entries) >= c.maxEntries {
+		delete(c.entries, oldestKey)
+	}
+}
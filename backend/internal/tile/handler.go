@@ -0,0 +1,205 @@
+package tile
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AkifSahn/pollution-tracker/config"
+	"github.com/AkifSahn/pollution-tracker/internal/database"
+	"github.com/AkifSahn/pollution-tracker/internal/pollution"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	FormatMVT = "mvt"
+	FormatPNG = "png"
+)
+
+var cache *Cache
+
+func SetupRoutes(app *fiber.App, cfg *config.Config) {
+	cache = NewCache(cfg.TileCacheTTL, cfg.TileCacheSize)
+
+	api := app.Group("/api")
+
+	api.Get("tiles/:z/:x/:y.:format", GetTile)
+}
+
+// GetTile
+//
+//	@Summary		Gets a map tile
+//	@Description	Serves the readings of a web mercator tile as a Mapbox Vector Tile (.mvt) or a PNG heat map (.png).
+//	@Description	Vector tiles have a "pollution" layer with a point per pixel and pollutant, carrying avg, max and count.
+//	@Description	PNG tiles require a pollutant and colour the readings by the category of the AQI scale.
+//	@Description	Tiles are cached in memory for TILE_CACHE_TTL.
+//	@Tags			tiles
+//	@Produce		application/vnd.mapbox-vector-tile
+//	@Produce		png
+//
+//	@Param			z			path		int					true	"Zoom level, 0-22"
+//	@Param			x			path		int					true	"Tile column"
+//	@Param			y			path		int					true	"Tile row"
+//	@Param			format		path		string				true	"mvt or png"
+//	@Param			from		query		string				false	"Start time, defaults to 24 hours ago"
+//	@Param			to			query		string				false	"End time, defaults to now"
+//	@Param			tz			query		string				false	"IANA time zone of times without an offset"	default(UTC)
+//	@Param			pollutant	query		string				false	"Pollutant, required for PNG tiles"
+//	@Param			unit		query		string				false	"Unit of the vector tile values: µg/m³, mg/m³, ppb, ppm"
+//	@Param			scale		query		string				false	"AQI scale of the PNG colours: epa, caqi, daqi"	default(epa)
+//
+//	@Failure		400			{object}	map[string]string	"Invalid params"
+//	@Failure		404			{object}	map[string]string	"Unknown format"
+//	@Failure		500			{object}	map[string]string	"Failed to build the tile"
+//	@Success		200			{file}		file				"Tile"
+//	@Router			/api/tiles/{z}/{x}/{y}.{format} [get]
+func GetTile(c *fiber.Ctx) error {
+	format := c.Params("format")
+	if format != FormatMVT && format != FormatPNG {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Unknown tile format, expected mvt or png",
+		})
+	}
+
+	var t Tile
+	var errX, errY, errZ error
+	t.Z, errZ = strconv.Atoi(c.Params("z"))
+	t.X, errX = strconv.Atoi(c.Params("x"))
+	t.Y, errY = strconv.Atoi(c.Params("y"))
+	if errZ != nil || errX != nil || errY != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Incorrect tile coordinates!",
+		})
+	}
+	if err := t.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Incorrect tile coordinates, " + err.Error(),
+		})
+	}
+
+	fromStr := c.Query("from", "now-24h")
+	toStr := c.Query("to", "now")
+	pollutant := c.Query("pollutant")
+
+	loc, msg := pollution.ParseTimeZone(c.Query("tz"))
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	var from, to time.Time
+	ok, msg := pollution.ParseTimeRange(fromStr, toStr, loc, pollution.MaxRawSpan, &from, &to)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	if format == FormatPNG {
+		return getHeatmapTile(c, t, from, to, pollutant)
+	}
+
+	return getVectorTile(c, t, from, to, pollutant)
+}
+
+func getVectorTile(c *fiber.Ctx, t Tile, from, to time.Time, pollutant string) error {
+	if c.Query("unit") != "" && pollutant == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Unit requires a pollutant!",
+		})
+	}
+
+	unit, msg := pollution.ParseUnitParam(c.Query("unit"), pollutant)
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	key := c.OriginalURL()
+	if data, ok := cache.Get(key); ok {
+		return sendTile(c, "application/vnd.mapbox-vector-tile", data)
+	}
+
+	// Conversions are linear, so the values are scaled in the query
+	factor := 1.0
+	if pollutant != "" {
+		factor, unit = pollution.ConvertValue(pollutant, 1, unit)
+	}
+
+	repo := NewTileRepo(database.DBPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	data, err := repo.GetVectorTile(ctx, t, from, to, pollutant, factor, unit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to build the tile: " + err.Error(),
+		})
+	}
+
+	cache.Set(key, data)
+
+	return sendTile(c, "application/vnd.mapbox-vector-tile", data)
+}
+
+func getHeatmapTile(c *fiber.Ctx, t Tile, from, to time.Time, pollutant string) error {
+	if pollutant == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "PNG tiles require a pollutant!",
+		})
+	}
+
+	scale, ok := pollution.AQIScales[c.Query("scale", pollution.DefaultAQIScale)]
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Incorrect scale, expected one of " + strings.Join(pollution.AQIScaleNames(), ", "),
+		})
+	}
+	if _, covered := scale.Pollutants[pollutant]; !covered {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Scale %s does not cover %s", scale.Name, pollutant),
+		})
+	}
+
+	key := c.OriginalURL()
+	if data, ok := cache.Get(key); ok {
+		return sendTile(c, "image/png", data)
+	}
+
+	repo := pollution.NewPollutionRepo(database.DBPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Cells just outside the tile are drawn too, so circles continue across tile edges
+	bounds := t.Bounds(pointRadius)
+	limit := (cellsPerTile + 4) * (cellsPerTile + 4)
+
+	cells, err := repo.GetGridCells(ctx, t.cellSize(), &bounds, from, to, pollutant, limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch grid from database: " + err.Error(),
+		})
+	}
+
+	data, err := renderHeatmap(t, cells, scale)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to render the tile: " + err.Error(),
+		})
+	}
+
+	cache.Set(key, data)
+
+	return sendTile(c, "image/png", data)
+}
+
+func sendTile(c *fiber.Ctx, contentType string, data []byte) error {
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderCacheControl, fmt.Sprintf("public, max-age=%d", int(cache.ttl.Seconds())))
+
+	return c.Status(fiber.StatusOK).Send(data)
+}
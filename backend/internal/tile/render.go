@@ -0,0 +1,114 @@
+package tile
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"slices"
+
+	"github.com/AkifSahn/pollution-tracker/internal/pollution"
+)
+
+const (
+	// cellsPerTile is the number of grid cells along the side of a PNG tile
+	cellsPerTile = 32
+	// pointRadius is the radius in pixels a cell is drawn with
+	pointRadius = 12
+	// maxAlpha is the opacity at the centre of a cell
+	maxAlpha = 0.6
+)
+
+// categoryColors are the colours of the AQI categories from the best to the
+// worst, the EPA palette. Scales with fewer categories use the first ones
+// and the last category always takes the last colour.
+var categoryColors = []color.NRGBA{
+	{0, 228, 0, 255},
+	{255, 255, 0, 255},
+	{255, 126, 0, 255},
+	{255, 0, 0, 255},
+	{143, 63, 151, 255},
+	{126, 0, 35, 255},
+}
+
+// cellSize returns the side of a grid cell in degrees for the tile
+func (t Tile) cellSize() float64 {
+	return 360 / math.Exp2(float64(t.Z)) / cellsPerTile
+}
+
+// categoryColor returns the colour of the category the concentration of the
+// pollutant falls into on the scale
+func categoryColor(scale *pollution.AQIScale, pollutant string, concentration float64) (color.NRGBA, bool) {
+	sub, ok := scale.SubIndex(pollutant, concentration)
+	if !ok {
+		return color.NRGBA{}, false
+	}
+
+	i := slices.IndexFunc(scale.Categories, func(c pollution.AQICategory) bool { return c.Name == sub.Category })
+	if i == len(scale.Categories)-1 || i >= len(categoryColors) {
+		i = len(categoryColors) - 1
+	}
+
+	return categoryColors[i], true
+}
+
+// renderHeatmap draws the cells as soft circles coloured by their AQI
+// category and encodes the tile as PNG
+func renderHeatmap(t Tile, cells []pollution.GridCell, scale *pollution.AQIScale) ([]byte, error) {
+	img := image.NewNRGBA(image.Rect(0, 0, Size, Size))
+
+	for _, cell := range cells {
+		c, ok := categoryColor(scale, cell.Pollutant, cell.Avg)
+		if !ok {
+			continue
+		}
+
+		cx, cy := t.Pixel(cell.Latitude, cell.Longitude)
+		for y := int(cy - pointRadius); y <= int(cy+pointRadius); y++ {
+			for x := int(cx - pointRadius); x <= int(cx+pointRadius); x++ {
+				if x < 0 || y < 0 || x >= Size || y >= Size {
+					continue
+				}
+
+				d := math.Hypot(float64(x)-cx, float64(y)-cy)
+				if d > pointRadius {
+					continue
+				}
+
+				c.A = uint8(255 * maxAlpha * (1 - d/pointRadius))
+				blend(img, x, y, c)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// blend draws the colour over the pixel with the "over" operator
+func blend(img *image.NRGBA, x, y int, src color.NRGBA) {
+	dst := img.NRGBAAt(x, y)
+
+	sa := float64(src.A) / 255
+	da := float64(dst.A) / 255
+	a := sa + da*(1-sa)
+	if a == 0 {
+		return
+	}
+
+	mix := func(s, d uint8) uint8 {
+		return uint8((float64(s)*sa + float64(d)*da*(1-sa)) / a)
+	}
+
+	img.SetNRGBA(x, y, color.NRGBA{
+		R: mix(src.R, dst.R),
+		G: mix(src.G, dst.G),
+		B: mix(src.B, dst.B),
+		A: uint8(a * 255),
+	})
+}
@@ -0,0 +1,71 @@
+package tile
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type TileRepo interface {
+	GetVectorTile(ctx context.Context, t Tile, from, to time.Time, pollutant string, factor float64, unit string) ([]byte, error)
+}
+
+type TileRepoImpl struct {
+	DB *pgxpool.Pool
+}
+
+func NewTileRepo(db *pgxpool.Pool) *TileRepoImpl {
+	return &TileRepoImpl{
+		DB: db,
+	}
+}
+
+// GetVectorTile builds a Mapbox Vector Tile with a "pollution" layer. Readings
+// falling on the same pixel of the tile are merged into one point per pollutant
+// with avg, max and count attributes. Values are multiplied by factor to bring
+// them into unit, an empty unit leaves the attribute out.
+func (repo *TileRepoImpl) GetVectorTile(ctx context.Context, t Tile, from, to time.Time, pollutant string, factor float64, unit string) ([]byte, error) {
+	bounds := t.Bounds(0)
+
+	query := `
+    WITH points AS (
+        SELECT ST_AsMVTGeom(
+                ST_Transform(ST_SetSRID(ST_MakePoint(longitude, latitude), 4326), 3857),
+                ST_TileEnvelope($1, $2, $3), $4, 0, false
+            ) AS geom,
+            pollutant, value
+        FROM air_pollution
+        WHERE time BETWEEN $5 AND $6
+          AND latitude BETWEEN $7 AND $8
+          AND longitude BETWEEN $9 AND $10
+    `
+	var args []interface{}
+	args = append(args, t.Z, t.X, t.Y, Size, from, to, bounds.LatFrom, bounds.LatTo, bounds.LongFrom, bounds.LongTo)
+
+	if pollutant != "" {
+		args = append(args, pollutant)
+		query += fmt.Sprintf(" AND pollutant = $%d", len(args))
+	}
+
+	args = append(args, factor, unit)
+	query += fmt.Sprintf(`
+    )
+    SELECT COALESCE(ST_AsMVT(tile, 'pollution', $4, 'geom'), '') FROM (
+        SELECT geom, pollutant,
+            AVG(value) * $%[1]d AS avg, MAX(value) * $%[1]d AS max, COUNT(*) AS count,
+            NULLIF($%[2]d::text, '') AS unit
+        FROM points
+        WHERE geom IS NOT NULL
+        GROUP BY geom, pollutant
+    ) AS tile;
+    `, len(args)-1, len(args))
+
+	var data []byte
+	if err := repo.DB.QueryRow(ctx, query, args...).Scan(&data); err != nil {
+		return nil, fmt.Errorf("Unable to scan - %s", err.Error())
+	}
+
+	return data, nil
+}
@@ -0,0 +1,70 @@
+package tile
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/AkifSahn/pollution-tracker/internal/pollution"
+)
+
+const (
+	// MaxZoom is the highest zoom level tiles are served for
+	MaxZoom = 22
+	// Size is the side of a tile in pixels, also the extent of vector tiles
+	Size = 256
+)
+
+// Tile is a tile of the web mercator (EPSG:3857) tile pyramid
+type Tile struct {
+	Z, X, Y int
+}
+
+func (t Tile) Validate() error {
+	if t.Z < 0 || t.Z > MaxZoom {
+		return fmt.Errorf("zoom must be between 0 and %d", MaxZoom)
+	}
+
+	n := 1 << t.Z
+	if t.X < 0 || t.X >= n || t.Y < 0 || t.Y >= n {
+		return fmt.Errorf("x and y must be between 0 and %d at zoom %d", n-1, t.Z)
+	}
+
+	return nil
+}
+
+// Bounds returns the area covered by the tile, grown by the given number of
+// pixels on every side so points just outside it can be drawn at the edges
+func (t Tile) Bounds(buffer float64) pollution.Bounds {
+	return pollution.Bounds{
+		LatFrom:  t.latitude(float64(t.Y+1)*Size + buffer),
+		LatTo:    t.latitude(float64(t.Y)*Size - buffer),
+		LongFrom: t.longitude(float64(t.X)*Size - buffer),
+		LongTo:   t.longitude(float64(t.X+1)*Size + buffer),
+	}
+}
+
+// worldSize is the side of the whole map in pixels at the zoom of the tile
+func (t Tile) worldSize() float64 {
+	return Size * math.Exp2(float64(t.Z))
+}
+
+// longitude returns the longitude of the global pixel column px
+func (t Tile) longitude(px float64) float64 {
+	return math.Max(-180, math.Min(180, px/t.worldSize()*360-180))
+}
+
+// latitude returns the latitude of the global pixel row py
+func (t Tile) latitude(py float64) float64 {
+	py = math.Max(0, math.Min(t.worldSize(), py))
+	return math.Atan(math.Sinh(math.Pi*(1-2*py/t.worldSize()))) * 180 / math.Pi
+}
+
+// Pixel returns the position of the coordinate in the pixels of the tile
+func (t Tile) Pixel(latitude, longitude float64) (float64, float64) {
+	x := (longitude + 180) / 360 * t.worldSize()
+
+	sin := math.Sin(latitude * math.Pi / 180)
+	y := (0.5 - math.Log((1+sin)/(1-sin))/(4*math.Pi)) * t.worldSize()
+
+	return x - float64(t.X)*Size, y - float64(t.Y)*Size
+}
@@ -2,13 +2,21 @@ package main
 
 import (
 	"log"
+	"os"
 
 	"github.com/AkifSahn/pollution-tracker/config"
 	"github.com/AkifSahn/pollution-tracker/internal/database"
+	"github.com/AkifSahn/pollution-tracker/internal/deadletter"
+	"github.com/AkifSahn/pollution-tracker/internal/export"
+	"github.com/AkifSahn/pollution-tracker/internal/importer"
 	"github.com/AkifSahn/pollution-tracker/internal/ingest"
 	"github.com/AkifSahn/pollution-tracker/internal/notification"
 	"github.com/AkifSahn/pollution-tracker/internal/pollution"
 	"github.com/AkifSahn/pollution-tracker/internal/rabbitmq"
+	"github.com/AkifSahn/pollution-tracker/internal/region"
+	"github.com/AkifSahn/pollution-tracker/internal/station"
+	"github.com/AkifSahn/pollution-tracker/internal/storage"
+	"github.com/AkifSahn/pollution-tracker/internal/tile"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
@@ -22,6 +30,16 @@ import (
 // @description	API documentation for pollution-tracker app
 func main() {
 
+	// Subcommands run instead of the server
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "import":
+			os.Exit(importer.Run(os.Args[2:]))
+		case "migrate":
+			os.Exit(database.RunMigrate(os.Args[2:]))
+		}
+	}
+
 	app := fiber.New()
 
 	cfg := config.LoadConfig()
@@ -32,16 +50,14 @@ func main() {
 	// Init rabbitmq
 	log.Printf("Initializing RabbitMQ")
 	rabbitmq.Connect(cfg)
-	rabbitmq.DeclareQueues()
-	defer rabbitmq.AmqpConn.Close()
-	defer rabbitmq.AmqpCh.Close()
+	defer rabbitmq.Close()
 
-	go ingest.ListenIngestion()
+	ingest.ListenIngestion(cfg)
 
 	hub := notification.NewHub()
 	go hub.Run()
 
-	go notification.ListenAndConsumeNotifications(hub)
+	notification.ListenAndConsumeNotifications(hub)
 
 	app.Use(cors.New())
 	app.Use(logger.New())
@@ -55,6 +71,12 @@ func main() {
 	})
 
 	pollution.SetupRoutes(app)
+	deadletter.SetupRoutes(app)
+	storage.SetupRoutes(app)
+	station.SetupRoutes(app)
+	region.SetupRoutes(app)
+	tile.SetupRoutes(app, cfg)
+	export.SetupRoutes(app, cfg)
 	app.Get("/ws", websocket.New(func(c *websocket.Conn) {
 		notification.NewWs(hub, c)
 	}))
@@ -15,6 +15,32 @@ export async function fetchPollutions(fromDate, toDate, pollutant) {
     return data;
 }
 
+// Get Pollution Grid of the cells inside bounds, a Leaflet LatLngBounds
+export async function fetchPollutionGrid(fromDate, toDate, pollutant, zoom, bounds) {
+    // The view may reach past the poles and the antimeridian when zoomed out
+    const lat = (v) => Math.max(-90, Math.min(90, v));
+    const lng = (v) => Math.max(-180, Math.min(180, v));
+
+    var url = `${API_BASE_URL}/pollutions/grid?` +
+        `from=${encodeURIComponent(fromDate)}&` +
+        `to=${encodeURIComponent(toDate)}&` +
+        `zoom=${encodeURIComponent(zoom)}&` +
+        `latFrom=${encodeURIComponent(lat(bounds.getSouth()))}&` +
+        `latTo=${encodeURIComponent(lat(bounds.getNorth()))}&` +
+        `longFrom=${encodeURIComponent(lng(bounds.getWest()))}&` +
+        `longTo=${encodeURIComponent(lng(bounds.getEast()))}`
+    if (pollutant) {
+        url += `&pollutant=${encodeURIComponent(pollutant)}`;
+    }
+
+    const response = await fetch(url);
+    const data = await response.json();
+    if (!response.ok) {
+        throw new Error(data.error || `Request failed with status ${response.status}`);
+    }
+    return data;
+}
+
 // Get Pollutants
 export async function fetchPollutants() {
     const url = `${API_BASE_URL}/pollutants`;
@@ -23,11 +49,15 @@ export async function fetchPollutants() {
     return data;
 }
 
-// Get Anomalies
-export async function fetchAnomaliesOfRange(fromDate, toDate) {
-    const url = `${API_BASE_URL}/anomalies` +
+// Get Anomalies, a page at a time. Passing the next link of a page fetches
+// the page after it.
+export async function fetchAnomaliesOfRange(fromDate, toDate, next) {
+    var url = `${API_BASE_URL}/anomalies` +
         `?from=${encodeURIComponent(fromDate)}&` +
         `to=${encodeURIComponent(toDate)}`;
+    if (next) {
+        url = new URL(next, API_BASE_URL).href;
+    }
 
     const response = await fetch(url);
     const data = await response.json();